@@ -8,18 +8,18 @@ import (
 )
 
 func main() {
-	// Create new server
+	// Create new server with a test process type registered up front
 	srv, err := server.NewServer(server.Config{
 		Port:     8080,
 		DocsPath: "./docs", // Optional: for documentation
+		ProcessTypes: map[string]server.ProcessFactory{
+			"FileReader": &MockFileReaderFactory{},
+		},
 	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Register a test process type
-	srv.RegisterProcessType("FileReader", &MockFileReaderFactory{})
-
 	// Start the server
 	log.Printf("Starting server on port 8080...")
 	if err := srv.Start(context.Background()); err != nil {
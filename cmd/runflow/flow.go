@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/core/process"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+	flownodes "github.com/elleshadow/noPromises/pkg/nodes/flow"
+	"github.com/elleshadow/noPromises/pkg/nodes/transform"
+	"github.com/elleshadow/noPromises/pkg/server/config"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEdgeBuffer is the buffer of an edge that does not set one, when
+// the flow has no memory hint
+const DefaultEdgeBuffer = 1
+
+// PacketSizeEstimate is the size in bytes assumed for a packet when edge
+// buffers are sized from the flow's memory hint
+const PacketSizeEstimate = 1 << 10
+
+// flowFile is a flow definition in the server's config format
+type flowFile struct {
+	Nodes map[string]struct {
+		Type    string                 `json:"type" yaml:"type"`
+		Config  map[string]interface{} `json:"config" yaml:"config"`
+		Initial map[string]interface{} `json:"initial" yaml:"initial"`
+	} `json:"nodes" yaml:"nodes"`
+	Edges []struct {
+		From      string `json:"from" yaml:"from"`
+		FromPort  string `json:"from_port" yaml:"from_port"`
+		To        string `json:"to" yaml:"to"`
+		Port      string `json:"port" yaml:"port"`
+		Buffer    *int   `json:"buffer" yaml:"buffer"`
+		When      string `json:"when" yaml:"when"`
+		Transform string `json:"transform" yaml:"transform"`
+	} `json:"edges" yaml:"edges"`
+	Limits   map[string]interface{} `json:"limits" yaml:"limits"`
+	Defaults map[string]interface{} `json:"defaults" yaml:"defaults"`
+}
+
+// loadFlow reads a flow file, as YAML for .yaml and .yml files and as
+// JSON otherwise
+func loadFlow(path string) (*flowFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var flow flowFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &flow)
+	default:
+		err = json.Unmarshal(data, &flow)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(flow.Nodes) == 0 {
+		return nil, fmt.Errorf("%s defines no nodes", path)
+	}
+	return &flow, nil
+}
+
+// flowNode runs a built node under its id in the flow. When the node
+// finishes, it closes the channels of its outgoing edges, so the flow
+// winds down once its sources are done.
+type flowNode struct {
+	node    process.Process
+	id      string
+	outputs []chan *ip.IP[any]
+}
+
+func (n *flowNode) Name() string {
+	return n.id
+}
+
+func (n *flowNode) Initialize(ctx context.Context) error {
+	return n.node.Initialize(ctx)
+}
+
+func (n *flowNode) Shutdown(ctx context.Context) error {
+	return n.node.Shutdown(ctx)
+}
+
+func (n *flowNode) IsInitialized() bool {
+	return n.node.IsInitialized()
+}
+
+func (n *flowNode) Port(name string) (ports.AnyPort, bool) {
+	if resolver, ok := n.node.(nodes.PortResolver); ok {
+		return resolver.Port(name)
+	}
+	return nil, false
+}
+
+func (n *flowNode) Ports() []ports.AnyPort {
+	if lister, ok := n.node.(interface{ Ports() []ports.AnyPort }); ok {
+		return lister.Ports()
+	}
+	return nil
+}
+
+func (n *flowNode) Process(ctx context.Context) error {
+	defer func() {
+		for _, ch := range n.outputs {
+			close(ch)
+		}
+	}()
+	return n.node.Process(ctx)
+}
+
+// buildNetwork creates the flow's nodes from the built-in types, each with
+// its config resolved over the flow's defaults, connects its edges and
+// applies its limits. A guarded edge ("when") runs through a
+// filter node over the named built-in predicate, and an edge with a
+// "transform" through a mapper node over the named built-in transform.
+// Guards see packets before they are transformed. A node's "initial" maps
+// input port names to a value each port delivers once, as an initial
+// information packet, before any packet of its edges.
+func buildNetwork(flow *flowFile, out io.Writer) (*network.Network, error) {
+	limits, err := network.ParseLimits(flow.Limits)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(flow.Nodes))
+	for id := range flow.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	built := make(map[string]*flowNode, len(ids))
+	for _, id := range ids {
+		node := flow.Nodes[id]
+		factory, exists := builtins[node.Type]
+		if !exists {
+			return nil, fmt.Errorf("node %q: unknown type %q", id, node.Type)
+		}
+		p, err := factory(config.Resolve(flow.Defaults, node.Config), out)
+		if err != nil {
+			return nil, fmt.Errorf("node %q: %w", id, err)
+		}
+		built[id] = &flowNode{node: p, id: id}
+	}
+
+	// Edges that do not set a buffer share the memory hint evenly
+	unsized := 0
+	for _, edge := range flow.Edges {
+		if edge.Buffer == nil {
+			unsized++
+		}
+	}
+	defaultBuffer := limits.BufferSize(unsized*PacketSizeEstimate, DefaultEdgeBuffer)
+
+	// Each edge gets its own channel; an input fed by several edges ends
+	// once all of them are closed
+	var inserted []*flowNode
+	for i, edge := range flow.Edges {
+		from, exists := built[edge.From]
+		if !exists {
+			return nil, fmt.Errorf("edge %d: unknown node %q", i, edge.From)
+		}
+		to, exists := built[edge.To]
+		if !exists {
+			return nil, fmt.Errorf("edge %d: unknown node %q", i, edge.To)
+		}
+		out, err := lookupPort(from, edge.FromPort, "out")
+		if err != nil {
+			return nil, fmt.Errorf("edge %d: %w", i, err)
+		}
+		in, err := lookupPort(to, edge.Port, "in")
+		if err != nil {
+			return nil, fmt.Errorf("edge %d: %w", i, err)
+		}
+
+		buffer := defaultBuffer
+		if edge.Buffer != nil {
+			buffer = *edge.Buffer
+		}
+		if buffer < 0 {
+			return nil, fmt.Errorf("edge %d: buffer must not be negative", i)
+		}
+
+		if edge.When != "" {
+			predicate, exists := predicates[edge.When]
+			if !exists {
+				return nil, fmt.Errorf("edge %d: unknown predicate %q", i, edge.When)
+			}
+			guard := flownodes.NewFilter[any](predicate)
+			node := &flowNode{node: guard, id: fmt.Sprintf("edge %d guard", i)}
+			if err := connect(from, out, guard.InPort, buffer); err != nil {
+				return nil, fmt.Errorf("edge %d: %w", i, err)
+			}
+			from, out = node, guard.OutPort
+			inserted = append(inserted, node)
+		}
+		if edge.Transform != "" {
+			fn, exists := transforms[edge.Transform]
+			if !exists {
+				return nil, fmt.Errorf("edge %d: unknown transform %q", i, edge.Transform)
+			}
+			mapper := transform.NewMapper[any, any](fn)
+			node := &flowNode{node: mapper, id: fmt.Sprintf("edge %d transform", i)}
+			if err := connect(from, out, mapper.InPort, buffer); err != nil {
+				return nil, fmt.Errorf("edge %d: %w", i, err)
+			}
+			from, out = node, mapper.OutPort
+			inserted = append(inserted, node)
+		}
+		if err := connect(from, out, in, buffer); err != nil {
+			return nil, fmt.Errorf("edge %d: %w", i, err)
+		}
+	}
+
+	n := network.New()
+	n.SetLimits(limits)
+	for _, id := range ids {
+		n.AddProcess(built[id])
+	}
+	for _, node := range inserted {
+		n.AddProcess(node)
+	}
+
+	for _, id := range ids {
+		initial := flow.Nodes[id].Initial
+		names := make([]string, 0, len(initial))
+		for name := range initial {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := network.AddInitial[any](n, id, name, initial[name]); err != nil {
+				return nil, fmt.Errorf("node %q: initial: %w", id, err)
+			}
+		}
+	}
+	return n, nil
+}
+
+// connect joins out to in over a new channel of buffer packets, which is
+// closed when from finishes
+func connect(from *flowNode, out, in *ports.Port[any], buffer int) error {
+	ch := make(chan *ip.IP[any], buffer)
+	if err := ports.Connect(in, ch); err != nil {
+		return err
+	}
+	if err := ports.Connect(out, ch); err != nil {
+		return err
+	}
+	from.outputs = append(from.outputs, ch)
+	return nil
+}
+
+// lookupPort resolves a named port of a node, defaulting to name
+func lookupPort(node *flowNode, name, fallback string) (*ports.Port[any], error) {
+	if name == "" {
+		name = fallback
+	}
+	resolver, ok := node.node.(nodes.PortResolver)
+	if !ok {
+		return nil, fmt.Errorf("node %q has no ports", node.id)
+	}
+	port, err := nodes.LookupPort[any](resolver, name)
+	if err != nil {
+		return nil, fmt.Errorf("node %q: %w", node.id, err)
+	}
+	return port, nil
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFlow writes a flow file with the given name into a temp dir
+func writeFlow(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestRunFlow(t *testing.T) {
+	run := func(path string, timeout time.Duration) (int, string, string) {
+		var out, errOut bytes.Buffer
+		status := runFlow(context.Background(), path, timeout, &out, &errOut)
+		return status, out.String(), errOut.String()
+	}
+
+	t.Run("generator to collector", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"values": ["a", "b", "c"]}},
+				"collect": {"type": "print"}
+			},
+			"edges": [{"from": "gen", "to": "collect"}]
+		}`)
+		status, out, errOut := run(path, 5*time.Second)
+		assert.Equal(t, exitOK, status, errOut)
+		assert.Equal(t, "\"a\"\n\"b\"\n\"c\"\n", out)
+	})
+
+	t.Run("generator to logger", func(t *testing.T) {
+		var logged bytes.Buffer
+		log.SetOutput(&logged)
+		defer log.SetOutput(os.Stderr)
+
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"count": 2}},
+				"log": {"type": "logger", "config": {"prefix": "seen"}}
+			},
+			"edges": [{"from": "gen", "to": "log"}]
+		}`)
+		status, _, errOut := run(path, 5*time.Second)
+		assert.Equal(t, exitOK, status, errOut)
+		assert.Contains(t, logged.String(), "seen: 1")
+		assert.Contains(t, logged.String(), "seen: 2")
+	})
+
+	t.Run("flow defaults", func(t *testing.T) {
+		var logged bytes.Buffer
+		log.SetOutput(&logged)
+		defer log.SetOutput(os.Stderr)
+
+		path := writeFlow(t, "flow.yaml", `
+defaults: {prefix: shared, count: 1}
+nodes:
+  gen: {type: generator}
+  more: {type: generator, config: {count: 2}}
+  shared: {type: logger}
+  own: {type: logger, config: {prefix: own}}
+edges:
+  - {from: gen, to: shared}
+  - {from: more, to: own}
+`)
+		status, _, errOut := run(path, 5*time.Second)
+		assert.Equal(t, exitOK, status, errOut)
+		assert.Contains(t, logged.String(), "shared: 1")
+		assert.Contains(t, logged.String(), "own: 2", "node config should override the defaults")
+		assert.NotContains(t, logged.String(), "shared: 2")
+	})
+
+	t.Run("yaml with fan-in", func(t *testing.T) {
+		path := writeFlow(t, "flow.yaml", `
+nodes:
+  evens: {type: generator, config: {values: [2, 4]}}
+  odds: {type: generator, config: {count: 1}}
+  collect: {type: print}
+edges:
+  - {from: evens, to: collect}
+  - {from: odds, to: collect, buffer: 0}
+`)
+		status, out, errOut := run(path, 5*time.Second)
+		assert.Equal(t, exitOK, status, errOut)
+		lines := strings.Fields(out)
+		sort.Strings(lines)
+		assert.Equal(t, []string{"1", "2", "4"}, lines)
+	})
+
+	t.Run("guarded edge", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"count": 5}},
+				"collect": {"type": "print"}
+			},
+			"edges": [{"from": "gen", "to": "collect", "when": "even"}]
+		}`)
+		status, out, errOut := run(path, 5*time.Second)
+		assert.Equal(t, exitOK, status, errOut)
+		assert.Equal(t, "2\n4\n", out)
+	})
+
+	t.Run("unknown predicate", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"count": 1}},
+				"collect": {"type": "print"}
+			},
+			"edges": [{"from": "gen", "to": "collect", "when": "prime"}]
+		}`)
+		status, _, errOut := run(path, time.Second)
+		assert.Equal(t, exitFailed, status)
+		assert.Contains(t, errOut, `edge 0: unknown predicate "prime"`)
+	})
+
+	t.Run("edge transform", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"values": ["hello", "world"]}},
+				"collect": {"type": "print"}
+			},
+			"edges": [{"from": "gen", "to": "collect", "transform": "toUpper"}]
+		}`)
+		status, out, errOut := run(path, 5*time.Second)
+		assert.Equal(t, exitOK, status, errOut)
+		assert.Equal(t, "\"HELLO\"\n\"WORLD\"\n", out)
+	})
+
+	t.Run("guarded and transformed edge", func(t *testing.T) {
+		path := writeFlow(t, "flow.yaml", `
+nodes:
+  gen: {type: generator, config: {count: 4}}
+  collect: {type: print}
+edges:
+  - {from: gen, to: collect, when: odd, transform: double}
+`)
+		status, out, errOut := run(path, 5*time.Second)
+		assert.Equal(t, exitOK, status, errOut)
+		assert.Equal(t, "2\n6\n", out, "the guard should see packets before the transform")
+	})
+
+	t.Run("unknown transform", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"count": 1}},
+				"collect": {"type": "print"}
+			},
+			"edges": [{"from": "gen", "to": "collect", "transform": "reverse"}]
+		}`)
+		status, _, errOut := run(path, time.Second)
+		assert.Equal(t, exitFailed, status)
+		assert.Contains(t, errOut, `edge 0: unknown transform "reverse"`)
+	})
+
+	t.Run("initial packet", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"values": ["b", "c"]}},
+				"collect": {"type": "print", "initial": {"in": "a"}}
+			},
+			"edges": [{"from": "gen", "to": "collect"}]
+		}`)
+		status, out, errOut := run(path, 5*time.Second)
+		assert.Equal(t, exitOK, status, errOut)
+		assert.Equal(t, "\"a\"\n\"b\"\n\"c\"\n", out, "the initial packet should arrive first")
+	})
+
+	t.Run("initial packet on an unknown port", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"count": 1}, "initial": {"out": 0}},
+				"collect": {"type": "print"}
+			},
+			"edges": [{"from": "gen", "to": "collect"}]
+		}`)
+		status, _, errOut := run(path, time.Second)
+		assert.Equal(t, exitFailed, status)
+		assert.Contains(t, errOut, `node "gen": initial: port gen.out is not an input port`)
+	})
+
+	t.Run("invalid flow", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{"nodes": {"x": {"type": "missing"}}}`)
+		status, _, errOut := run(path, time.Second)
+		assert.Equal(t, exitFailed, status)
+		assert.Contains(t, errOut, `node "x": unknown type "missing"`)
+	})
+
+	t.Run("unconnected required port", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{"nodes": {"collect": {"type": "print"}}}`)
+		status, _, errOut := run(path, time.Second)
+		assert.Equal(t, exitFailed, status)
+		assert.Contains(t, errOut, "collect.in")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"count": 3}},
+				"slow": {"type": "delay", "config": {"duration": "1s"}},
+				"collect": {"type": "print"}
+			},
+			"edges": [{"from": "gen", "to": "slow"}, {"from": "slow", "to": "collect"}]
+		}`)
+		status, _, errOut := run(path, 50*time.Millisecond)
+		assert.Equal(t, exitFailed, status)
+		assert.Contains(t, errOut, "did not finish within 50ms")
+	})
+
+	t.Run("interrupted", func(t *testing.T) {
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"count": 3}},
+				"slow": {"type": "delay", "config": {"duration": "1s"}},
+				"collect": {"type": "print"}
+			},
+			"edges": [{"from": "gen", "to": "slow"}, {"from": "slow", "to": "collect"}]
+		}`)
+		ctx, interrupt := context.WithCancel(context.Background())
+		defer interrupt()
+		time.AfterFunc(20*time.Millisecond, interrupt)
+
+		var out, errOut bytes.Buffer
+		assert.Equal(t, exitInterrupted, runFlow(ctx, path, 0, &out, &errOut))
+	})
+}
+
+func TestEdgeBufferFromMemoryHint(t *testing.T) {
+	build := func(limits string) *flowNode {
+		path := writeFlow(t, "flow.json", `{
+			"nodes": {
+				"gen": {"type": "generator", "config": {"count": 1}},
+				"a": {"type": "print"},
+				"b": {"type": "print"}
+			},
+			"edges": [{"from": "gen", "to": "a"}, {"from": "gen", "to": "b", "buffer": 3}],
+			"limits": `+limits+`
+		}`)
+		flow, err := loadFlow(path)
+		require.NoError(t, err)
+		n, err := buildNetwork(flow, &bytes.Buffer{})
+		require.NoError(t, err)
+		return n.GetProcess("gen").(*flowNode)
+	}
+
+	gen := build(`{}`)
+	require.Len(t, gen.outputs, 2)
+	assert.Equal(t, DefaultEdgeBuffer, cap(gen.outputs[0]))
+	assert.Equal(t, 3, cap(gen.outputs[1]), "an explicit buffer wins")
+
+	gen = build(`{"memory_hint": 65536}`)
+	assert.Equal(t, 65536/PacketSizeEstimate, cap(gen.outputs[0]))
+	assert.Equal(t, 3, cap(gen.outputs[1]))
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/core/process"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+	"github.com/elleshadow/noPromises/pkg/nodes/control"
+	"github.com/elleshadow/noPromises/pkg/nodes/debug"
+)
+
+// nodeFactory builds a node of a built-in type from its config. Nodes
+// write their results to out.
+type nodeFactory func(config map[string]interface{}, out io.Writer) (process.Process, error)
+
+// builtins are the node types a flow file may use. Every port carries
+// untyped values (any) so built-in nodes can be wired freely.
+var builtins = map[string]nodeFactory{
+	"generator": newGenerator,
+	"print":     newPrinter,
+	"logger": func(config map[string]interface{}, _ io.Writer) (process.Process, error) {
+		prefix, _ := config["prefix"].(string)
+		return debug.NewLogger[any](prefix), nil
+	},
+	"delay": func(config map[string]interface{}, _ io.Writer) (process.Process, error) {
+		raw, _ := config["duration"].(string)
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %q: %w", raw, err)
+		}
+		return control.NewDelay[any](duration), nil
+	},
+}
+
+// predicates are the named predicates a guarded edge may reference with
+// "when"
+var predicates = map[string]func(any) bool{
+	"even": func(value any) bool {
+		n, ok := toInt(value)
+		return ok && n%2 == 0
+	},
+	"odd": func(value any) bool {
+		n, ok := toInt(value)
+		return ok && n%2 != 0
+	},
+}
+
+// transforms are the named functions an edge may map its packets with
+// using "transform". Values they do not apply to pass unchanged.
+var transforms = map[string]func(any) any{
+	"toUpper": func(value any) any {
+		if s, ok := value.(string); ok {
+			return strings.ToUpper(s)
+		}
+		return value
+	},
+	"toLower": func(value any) any {
+		if s, ok := value.(string); ok {
+			return strings.ToLower(s)
+		}
+		return value
+	},
+	"double": func(value any) any {
+		if n, ok := toInt(value); ok {
+			return n * 2
+		}
+		return value
+	},
+}
+
+// generator sends its configured values, then ends its output stream.
+// Config "values" lists the values to send; "count" sends 1 to count.
+type generator struct {
+	process.BaseProcess
+	Out    *ports.Port[any]
+	values []any
+}
+
+func newGenerator(config map[string]interface{}, _ io.Writer) (process.Process, error) {
+	g := &generator{
+		BaseProcess: process.NewBaseProcess("generator"),
+		Out:         ports.NewOutput[any]("out", "Generated values", true),
+	}
+	switch {
+	case config["values"] != nil:
+		values, ok := config["values"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("values must be a list")
+		}
+		g.values = values
+	case config["count"] != nil:
+		count, ok := toInt(config["count"])
+		if !ok || count < 0 {
+			return nil, fmt.Errorf("count must be a non-negative integer")
+		}
+		for i := 1; i <= count; i++ {
+			g.values = append(g.values, i)
+		}
+	default:
+		return nil, fmt.Errorf("generator needs values or count")
+	}
+	return g, nil
+}
+
+func (g *generator) Port(name string) (ports.AnyPort, bool) {
+	if name == g.Out.Name() {
+		return g.Out, true
+	}
+	return nil, false
+}
+
+func (g *generator) Ports() []ports.AnyPort {
+	return []ports.AnyPort{g.Out}
+}
+
+func (g *generator) Process(ctx context.Context) error {
+	for _, value := range g.values {
+		if err := g.Out.Send(ctx, ip.New[any](value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printer writes each value it receives to out as a line of JSON
+type printer struct {
+	process.BaseProcess
+	In  *ports.Port[any]
+	out io.Writer
+}
+
+// outputMu keeps the lines of printers sharing an output whole
+var outputMu sync.Mutex
+
+func newPrinter(_ map[string]interface{}, out io.Writer) (process.Process, error) {
+	return &printer{
+		BaseProcess: process.NewBaseProcess("print"),
+		In:          ports.NewInput[any]("in", "Values to print", true),
+		out:         out,
+	}, nil
+}
+
+func (p *printer) Port(name string) (ports.AnyPort, bool) {
+	if name == p.In.Name() {
+		return p.In, true
+	}
+	return nil, false
+}
+
+func (p *printer) Ports() []ports.AnyPort {
+	return []ports.AnyPort{p.In}
+}
+
+func (p *printer) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := p.In.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+			line, err := json.Marshal(packet.Data())
+			if err != nil {
+				return err
+			}
+			outputMu.Lock()
+			_, err = fmt.Fprintf(p.out, "%s\n", line)
+			outputMu.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toInt converts a decoded JSON (float64) or YAML (int) number
+func toInt(value interface{}) (int, bool) {
+	switch n := value.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), n == float64(int(n))
+	}
+	return 0, false
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/elleshadow/noPromises/pkg/server"
 )
@@ -15,6 +18,11 @@ func main() {
 	// Parse command line flags
 	port := flag.Int("port", 8080, "Server port")
 	docsPath := flag.String("docs", "", "Path to documentation files")
+	selftest := flag.Bool("selftest", false, "Validate the configuration and exit without serving")
+	slowRequest := flag.Duration("slow-request", 0, "Log only requests at least this slow (0 logs every request)")
+	logSample := flag.Float64("log-sample", 0, "Fraction of faster requests still logged with -slow-request")
+	tenantHeader := flag.String("tenant-header", "", "Scope requests to the tenant named by this header")
+	logBodies := flag.Bool("log-bodies", false, "Log flow API request and response bodies, redacted")
 	flag.Parse()
 
 	// Get absolute path for docs
@@ -28,19 +36,32 @@ func main() {
 		absDocsPath = filepath.Join(cwd, "docs")
 	}
 
+	config := server.Config{
+		Port:      *port,
+		DocsPath:  absDocsPath,
+		LogBodies: *logBodies,
+		Middleware: buildMiddleware(middlewareOptions{
+			slowRequest:  *slowRequest,
+			logSample:    *logSample,
+			tenantHeader: *tenantHeader,
+		}),
+	}
+	if *selftest {
+		os.Exit(selfTest(config, os.Stdout))
+	}
+
 	// Create and configure server
-	srv, err := server.NewServer(server.Config{
-		Port:     *port,
-		DocsPath: absDocsPath,
-	})
+	srv, err := server.NewServer(config)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
 
-	// Start server
-	log.Printf("Server starting on http://localhost:%d", *port)
+	// Start server; an interrupt shuts it down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	log.Printf("Documentation path: %s", absDocsPath)
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", *port), srv); err != nil {
+	if err := srv.Start(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
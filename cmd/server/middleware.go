@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/server/api/middleware"
+)
+
+// middlewareOptions are the flag-controlled parts of the request chain
+type middlewareOptions struct {
+	// slowRequest logs only requests at least this slow; zero logs all
+	slowRequest time.Duration
+	// logSample is the fraction of faster requests still logged
+	logSample float64
+	// tenantHeader scopes requests to the tenant it names; empty disables
+	// tenant scoping
+	tenantHeader string
+}
+
+// buildMiddleware returns the chain wrapping the flow API: recovery inside
+// logging, so a recovered panic is logged as the 500 it became, then the
+// optional tenant scoping.
+func buildMiddleware(opts middlewareOptions) []middleware.Middleware {
+	var logging []middleware.LoggingOption
+	if opts.slowRequest > 0 {
+		logging = append(logging,
+			middleware.WithSlowRequestThreshold(opts.slowRequest),
+			middleware.WithFastRequestSampling(opts.logSample))
+	}
+
+	chain := []middleware.Middleware{
+		middleware.NewLoggingMiddleware(logging...),
+		middleware.RecoveryMiddleware,
+	}
+	if opts.tenantHeader != "" {
+		chain = append(chain, middleware.TenantMiddleware(opts.tenantHeader))
+	}
+	return chain
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/server/api/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logs)
+
+	t.Run("panics are recovered and logged", func(t *testing.T) {
+		logs.Reset()
+		handler := middleware.Chain(buildMiddleware(middlewareOptions{})...)(
+			http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") }))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/flows", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, logs.String(), "panic: boom")
+		assert.Contains(t, logs.String(), "GET /api/v1/flows 500")
+	})
+
+	t.Run("tenant header", func(t *testing.T) {
+		var tenant string
+		handler := middleware.Chain(buildMiddleware(middlewareOptions{tenantHeader: "X-Team"})...)(
+			http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				tenant = middleware.TenantFromContext(r.Context())
+			}))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/flows", nil)
+		req.Header.Set("X-Team", "blue")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, "blue", tenant)
+	})
+}
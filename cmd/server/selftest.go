@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/elleshadow/noPromises/pkg/server"
+)
+
+// selfTest validates the server configuration without starting the HTTP
+// listener, returning the process exit status
+func selfTest(config server.Config, out io.Writer) (status int) {
+	// The web UI panics on startup when its templates are missing
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(out, "self-test failed: %v\n", r)
+			status = 1
+		}
+	}()
+
+	srv, err := server.NewServer(config)
+	if err != nil {
+		fmt.Fprintf(out, "self-test failed: %v\n", err)
+		return 1
+	}
+	if err := srv.SelfTest(); err != nil {
+		fmt.Fprintf(out, "%v\n", err)
+		return 1
+	}
+	fmt.Fprintln(out, "self-test passed")
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chdir switches to dir for the rest of the test
+func chdir(t *testing.T, dir string) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(cwd) })
+}
+
+func TestSelfTest(t *testing.T) {
+	// The web UI loads its templates relative to the working directory
+	workDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(workDir, "web", "templates"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "web", "templates", "index.html"),
+		[]byte("<h1>{{.Title}}</h1>"), 0644))
+	chdir(t, workDir)
+
+	t.Run("valid setup", func(t *testing.T) {
+		docsPath := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(docsPath, "api"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(docsPath, "README.md"), []byte("# Docs"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(docsPath, "api", "swagger.json"), []byte("{}"), 0644))
+
+		var out bytes.Buffer
+		assert.Equal(t, 0, selfTest(server.Config{DocsPath: docsPath}, &out))
+		assert.Contains(t, out.String(), "self-test passed")
+	})
+
+	t.Run("missing docs", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.Equal(t, 1, selfTest(server.Config{DocsPath: t.TempDir()}, &out))
+		assert.Contains(t, out.String(), "required file missing")
+	})
+
+	t.Run("missing web templates", func(t *testing.T) {
+		docsPath := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(docsPath, "api"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(docsPath, "README.md"), []byte("# Docs"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(docsPath, "api", "swagger.json"), []byte("{}"), 0644))
+		chdir(t, t.TempDir())
+
+		var out bytes.Buffer
+		assert.Equal(t, 1, selfTest(server.Config{DocsPath: docsPath}, &out))
+		assert.Contains(t, out.String(), "self-test failed")
+	})
+}
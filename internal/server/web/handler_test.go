@@ -174,7 +174,7 @@ func TestStaticFileServer(t *testing.T) {
 // Mock flow manager for testing
 type mockFlowManager struct{}
 
-func (m *mockFlowManager) List() []ManagedFlow {
+func (m *mockFlowManager) List(string) []ManagedFlow {
 	return []ManagedFlow{
 		{ID: "test-flow-1", Status: "running"},
 		{ID: "test-flow-2", Status: "stopped"},
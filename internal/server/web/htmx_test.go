@@ -109,3 +109,21 @@ func TestFlowVisualization(t *testing.T) {
 		})
 	}
 }
+
+func TestFlowCreateBodyLimit(t *testing.T) {
+	server := NewServer(
+		WithTemplates(setupTestServer().templates),
+		WithFlowManager(&mockFlowManager{}),
+		WithMaxBodySize(64),
+	)
+
+	body := `{"id": "` + strings.Repeat("x", 128) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	server.HandleFlows()(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+}
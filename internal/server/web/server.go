@@ -2,10 +2,13 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
 	"strings"
+
+	"github.com/elleshadow/noPromises/pkg/server/api/middleware"
 )
 
 // ManagedFlow represents a flow in the system
@@ -16,20 +19,26 @@ type ManagedFlow struct {
 
 // FlowManager interface for managing flows
 type FlowManager interface {
-	List() []ManagedFlow
+	// List returns the flows of tenant; "" lists unscoped flows
+	List(tenant string) []ManagedFlow
 }
 
+// DefaultMaxBodySize is the request body limit used unless WithMaxBodySize is given
+const DefaultMaxBodySize int64 = 1 << 20 // 1 MiB
+
 // Server handles web interface requests
 type Server struct {
-	templates *template.Template
-	flows     FlowManager
-	static    http.Handler
+	templates   *template.Template
+	flows       FlowManager
+	static      http.Handler
+	maxBodySize int64
 }
 
 // NewServer creates a new web interface server
 func NewServer(opts ...ServerOption) *Server {
 	s := &Server{
-		flows: &defaultFlowManager{},
+		flows:       &defaultFlowManager{},
+		maxBodySize: DefaultMaxBodySize,
 	}
 
 	// Apply options
@@ -73,6 +82,13 @@ func WithFlowManager(fm FlowManager) ServerOption {
 	}
 }
 
+// WithMaxBodySize sets the request body limit in bytes
+func WithMaxBodySize(n int64) ServerOption {
+	return func(s *Server) {
+		s.maxBodySize = n
+	}
+}
+
 // ServeHTTP implements http.Handler
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if strings.HasPrefix(r.URL.Path, "/static/") {
@@ -96,7 +112,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // HandleHome returns the handler for the home page
 func (s *Server) HandleHome() http.HandlerFunc {
-	return func(w http.ResponseWriter, _ *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
 		if s.templates == nil {
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
@@ -107,7 +123,7 @@ func (s *Server) HandleHome() http.HandlerFunc {
 			Flows []ManagedFlow
 		}{
 			Title: "noPromises Dashboard",
-			Flows: s.flows.List(),
+			Flows: s.flows.List(middleware.TenantFromContext(r.Context())),
 		}
 
 		err := s.templates.ExecuteTemplate(w, "index.html", data)
@@ -123,7 +139,7 @@ func (s *Server) HandleFlows() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			flows := s.flows.List()
+			flows := s.flows.List(middleware.TenantFromContext(r.Context()))
 			w.Header().Set("Content-Type", "text/html")
 			for _, flow := range flows {
 				fmt.Fprintf(w, `<div class="flow-item">%s</div>`, flow.ID)
@@ -134,7 +150,13 @@ func (s *Server) HandleFlows() http.HandlerFunc {
 				ID     string         `json:"id"`
 				Config map[string]any `json:"config"`
 			}
+			r.Body = http.MaxBytesReader(w, r.Body, s.maxBodySize)
 			if err := json.NewDecoder(r.Body).Decode(&newFlow); err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
 				http.Error(w, "Invalid request body", http.StatusBadRequest)
 				return
 			}
@@ -155,7 +177,7 @@ func (s *Server) HandleFlowVisualization() http.HandlerFunc {
 
 		// Check if flow exists
 		found := false
-		for _, flow := range s.flows.List() {
+		for _, flow := range s.flows.List(middleware.TenantFromContext(r.Context())) {
 			if flow.ID == flowID {
 				found = true
 				break
@@ -192,7 +214,7 @@ func (s *Server) HandleFlowVisualization() http.HandlerFunc {
 // defaultFlowManager is a basic implementation of FlowManager
 type defaultFlowManager struct{}
 
-func (m *defaultFlowManager) List() []ManagedFlow {
+func (m *defaultFlowManager) List(string) []ManagedFlow {
 	return []ManagedFlow{
 		{ID: "test-flow-1", Status: "running"},
 		{ID: "test-flow-2", Status: "stopped"},
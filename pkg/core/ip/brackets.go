@@ -0,0 +1,92 @@
+package ip
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrBracketTooDeep is returned when an open bracket exceeds the maximum nesting depth
+	ErrBracketTooDeep = errors.New("bracket nesting too deep")
+	// ErrUnmatchedBracket is returned when a close bracket has no matching open bracket
+	ErrUnmatchedBracket = errors.New("unmatched close bracket")
+)
+
+// BracketTracker tracks the nesting depth of bracketed substreams
+type BracketTracker struct {
+	depth    int
+	maxDepth int
+	onClose  func()
+	mu       sync.RWMutex
+}
+
+// NewBracketTracker creates a tracker that calls onClose whenever the
+// outermost substream closes. onClose may be nil.
+func NewBracketTracker(onClose func()) *BracketTracker {
+	return &BracketTracker{
+		onClose: onClose,
+	}
+}
+
+// SetMaxDepth limits how deeply brackets may nest; 0 means unlimited
+func (bt *BracketTracker) SetMaxDepth(max int) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	bt.maxDepth = max
+}
+
+// MaxDepth returns the maximum nesting depth, 0 meaning unlimited
+func (bt *BracketTracker) MaxDepth() int {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	return bt.maxDepth
+}
+
+// Depth returns the current nesting depth
+func (bt *BracketTracker) Depth() int {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+	return bt.depth
+}
+
+// OpenBracket enters a substream
+func (bt *BracketTracker) OpenBracket() error {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	if bt.maxDepth > 0 && bt.depth >= bt.maxDepth {
+		return ErrBracketTooDeep
+	}
+	bt.depth++
+	return nil
+}
+
+// CloseBracket leaves a substream
+func (bt *BracketTracker) CloseBracket() error {
+	bt.mu.Lock()
+	if bt.depth == 0 {
+		bt.mu.Unlock()
+		return ErrUnmatchedBracket
+	}
+	bt.depth--
+	closed := bt.depth == 0
+	bt.mu.Unlock()
+
+	if closed && bt.onClose != nil {
+		bt.onClose()
+	}
+	return nil
+}
+
+// Track updates the depth for a packet of the given type, ignoring
+// anything that is not a bracket
+func (bt *BracketTracker) Track(t Type) error {
+	switch t {
+	case TypeBracketOpen:
+		return bt.OpenBracket()
+	case TypeBracketClose:
+		return bt.CloseBracket()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+package ip_test
+
+import (
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBracketTracker(t *testing.T) {
+	t.Run("depth and close callback", func(t *testing.T) {
+		closed := 0
+		bt := ip.NewBracketTracker(func() { closed++ })
+
+		require.NoError(t, bt.OpenBracket())
+		require.NoError(t, bt.OpenBracket())
+		assert.Equal(t, 2, bt.Depth())
+
+		require.NoError(t, bt.CloseBracket())
+		assert.Equal(t, 0, closed, "inner close should not fire callback")
+		require.NoError(t, bt.CloseBracket())
+		assert.Equal(t, 1, closed)
+		assert.Equal(t, 0, bt.Depth())
+	})
+
+	t.Run("unmatched close", func(t *testing.T) {
+		bt := ip.NewBracketTracker(nil)
+		assert.ErrorIs(t, bt.CloseBracket(), ip.ErrUnmatchedBracket)
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		bt := ip.NewBracketTracker(nil)
+		bt.SetMaxDepth(3)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, bt.Track(ip.TypeBracketOpen))
+		}
+		assert.ErrorIs(t, bt.Track(ip.TypeBracketOpen), ip.ErrBracketTooDeep)
+		assert.Equal(t, 3, bt.Depth(), "rejected bracket must not change depth")
+
+		require.NoError(t, bt.Track(ip.TypeBracketClose))
+		assert.NoError(t, bt.Track(ip.TypeBracketOpen))
+	})
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		bt := ip.NewBracketTracker(nil)
+		for i := 0; i < 1000; i++ {
+			require.NoError(t, bt.OpenBracket())
+		}
+		assert.NoError(t, bt.Track(ip.TypeNormal))
+	})
+}
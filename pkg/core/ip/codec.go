@@ -0,0 +1,79 @@
+package ip
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// wireIP is the JSON representation of an Information Packet
+type wireIP[T any] struct {
+	ID        string         `json:"id"`
+	Type      Type           `json:"type"`
+	Data      T              `json:"data"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	Owner     string         `json:"owner,omitempty"`
+	Immutable bool           `json:"immutable,omitempty"`
+}
+
+// MarshalJSON encodes the IP, including its ID, type, metadata and ownership
+func (ip *IP[T]) MarshalJSON() ([]byte, error) {
+	ip.mu.RLock()
+	defer ip.mu.RUnlock()
+
+	return json.Marshal(wireIP[T]{
+		ID:        ip.id,
+		Type:      ip.ipType,
+		Data:      ip.data,
+		Metadata:  ip.metadata,
+		Owner:     ip.owner,
+		Immutable: ip.immutable,
+	})
+}
+
+// UnmarshalJSON decodes an IP previously encoded with MarshalJSON
+func (ip *IP[T]) UnmarshalJSON(b []byte) error {
+	var w wireIP[T]
+	if err := json.Unmarshal(b, &w); err != nil {
+		return fmt.Errorf("invalid IP encoding: %w", err)
+	}
+	if w.ID == "" {
+		return fmt.Errorf("invalid IP encoding: missing id")
+	}
+
+	w.Metadata = restoreMetadata(w.Metadata)
+
+	ip.mu.Lock()
+	defer ip.mu.Unlock()
+
+	ip.id = w.ID
+	ip.ipType = w.Type
+	ip.data = w.Data
+	ip.metadata = w.Metadata
+	ip.owner = w.Owner
+	ip.immutable = w.Immutable
+	return nil
+}
+
+// restoreMetadata fixes up metadata decoded from JSON
+func restoreMetadata(metadata map[string]any) map[string]any {
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	// Restore the creation timestamp, which JSON turns into a string
+	if s, ok := metadata["created_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			metadata["created_at"] = t
+		}
+	}
+	return metadata
+}
+
+// Decode creates an IP from its JSON encoding
+func Decode[T any](b []byte) (*IP[T], error) {
+	packet := new(IP[T])
+	if err := packet.UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+	return packet, nil
+}
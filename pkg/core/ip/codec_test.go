@@ -0,0 +1,65 @@
+package ip_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		packet := ip.New(map[string]int{"count": 3})
+		packet.SetMetadata("source", "test")
+		require.NoError(t, packet.SetOwner("proc1"))
+
+		data, err := json.Marshal(packet)
+		require.NoError(t, err)
+
+		decoded, err := ip.Decode[map[string]int](data)
+		require.NoError(t, err)
+
+		assert.Equal(t, packet.ID(), decoded.ID())
+		assert.Equal(t, ip.TypeNormal, decoded.Type())
+		assert.Equal(t, map[string]int{"count": 3}, decoded.Data())
+		assert.Equal(t, "proc1", decoded.Owner())
+
+		val, ok := decoded.GetMetadata("source")
+		assert.True(t, ok)
+		assert.Equal(t, "test", val)
+
+		created, ok := decoded.GetMetadata("created_at")
+		assert.True(t, ok)
+		_, isTime := created.(time.Time)
+		assert.True(t, isTime, "created_at should be restored as time.Time")
+	})
+
+	t.Run("brackets and IIPs", func(t *testing.T) {
+		data, err := json.Marshal(ip.NewOpenBracket[string]())
+		require.NoError(t, err)
+		decoded, err := ip.Decode[string](data)
+		require.NoError(t, err)
+		assert.Equal(t, ip.TypeBracketOpen, decoded.Type())
+
+		data, err = json.Marshal(ip.NewIIP("config"))
+		require.NoError(t, err)
+		decoded, err = ip.Decode[string](data)
+		require.NoError(t, err)
+		assert.Equal(t, ip.TypeInitial, decoded.Type())
+		assert.True(t, decoded.IsImmutable())
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := ip.Decode[string]([]byte(`not json`))
+		assert.Error(t, err)
+
+		_, err = ip.Decode[string]([]byte(`{"data":"x"}`))
+		assert.Error(t, err)
+
+		_, err = ip.Decode[int]([]byte(`{"id":"1","data":"x"}`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,19 @@
+package ip
+
+// MetadataFlush is the metadata key marking a flush signal
+const MetadataFlush = "flush"
+
+// NewFlush creates a flush signal: a data-less packet asking sinks to
+// flush any buffered output and acknowledge downstream
+func NewFlush[T any]() *IP[T] {
+	var zero T
+	ip := New(zero)
+	ip.metadata[MetadataFlush] = true
+	return ip
+}
+
+// IsFlush reports whether the IP is a flush signal
+func (ip *IP[T]) IsFlush() bool {
+	flush, _ := ip.GetBoolMeta(MetadataFlush)
+	return flush
+}
@@ -0,0 +1,87 @@
+package ip
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// frameHeaderSize is the size of the length prefix of a frame
+const frameHeaderSize = 4
+
+// frameHeader is everything in a frame but the payload
+type frameHeader struct {
+	ID        string         `json:"id"`
+	Type      Type           `json:"type"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	Owner     string         `json:"owner,omitempty"`
+	Immutable bool           `json:"immutable,omitempty"`
+}
+
+// EncodeFrame encodes an IP as a binary frame: a big-endian uint32 header
+// length, a JSON header with the IP's id, type, metadata and ownership,
+// then the payload. []byte payloads are written raw, avoiding the base64
+// encoding JSON would apply; other payloads are JSON encoded.
+func EncodeFrame[T any](packet *IP[T]) ([]byte, error) {
+	packet.mu.RLock()
+	defer packet.mu.RUnlock()
+
+	header, err := json.Marshal(frameHeader{
+		ID:        packet.id,
+		Type:      packet.ipType,
+		Metadata:  packet.metadata,
+		Owner:     packet.owner,
+		Immutable: packet.immutable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode frame header: %w", err)
+	}
+
+	var payload []byte
+	if raw, ok := any(packet.data).([]byte); ok {
+		payload = raw
+	} else if payload, err = json.Marshal(packet.data); err != nil {
+		return nil, fmt.Errorf("failed to encode frame payload: %w", err)
+	}
+
+	frame := make([]byte, frameHeaderSize, frameHeaderSize+len(header)+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(header)))
+	frame = append(frame, header...)
+	return append(frame, payload...), nil
+}
+
+// DecodeFrame creates an IP from a frame written by EncodeFrame. A []byte
+// payload aliases b.
+func DecodeFrame[T any](b []byte) (*IP[T], error) {
+	if len(b) < frameHeaderSize {
+		return nil, fmt.Errorf("invalid IP frame: %d bytes is too short", len(b))
+	}
+	size := binary.BigEndian.Uint32(b)
+	if uint64(size) > uint64(len(b)-frameHeaderSize) {
+		return nil, fmt.Errorf("invalid IP frame: header length %d exceeds frame", size)
+	}
+	body := b[frameHeaderSize:]
+
+	var header frameHeader
+	if err := json.Unmarshal(body[:size], &header); err != nil {
+		return nil, fmt.Errorf("invalid IP frame header: %w", err)
+	}
+	if header.ID == "" {
+		return nil, fmt.Errorf("invalid IP frame: missing id")
+	}
+
+	packet := &IP[T]{
+		id:        header.ID,
+		ipType:    header.Type,
+		metadata:  restoreMetadata(header.Metadata),
+		owner:     header.Owner,
+		immutable: header.Immutable,
+	}
+	payload := body[size:]
+	if raw, ok := any(&packet.data).(*[]byte); ok {
+		*raw = payload
+	} else if err := json.Unmarshal(payload, &packet.data); err != nil {
+		return nil, fmt.Errorf("invalid IP frame payload: %w", err)
+	}
+	return packet, nil
+}
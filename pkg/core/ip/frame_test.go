@@ -0,0 +1,104 @@
+package ip_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrame(t *testing.T) {
+	t.Run("binary payload", func(t *testing.T) {
+		payload := make([]byte, 1<<20)
+		rand.New(rand.NewSource(1)).Read(payload)
+
+		packet := ip.New(payload)
+		packet.SetMetadata("source", "test")
+		require.NoError(t, packet.SetOwner("proc1"))
+
+		frame, err := ip.EncodeFrame(packet)
+		require.NoError(t, err)
+		// The payload is carried raw, not base64 encoded
+		assert.Less(t, len(frame), len(payload)+1024)
+
+		decoded, err := ip.DecodeFrame[[]byte](frame)
+		require.NoError(t, err)
+		assert.Equal(t, packet.ID(), decoded.ID())
+		assert.Equal(t, "proc1", decoded.Owner())
+		assert.True(t, bytes.Equal(payload, decoded.Data()), "payload should round trip byte for byte")
+
+		val, ok := decoded.GetMetadata("source")
+		assert.True(t, ok)
+		assert.Equal(t, "test", val)
+	})
+
+	t.Run("other payloads", func(t *testing.T) {
+		frame, err := ip.EncodeFrame(ip.New(map[string]int{"count": 3}))
+		require.NoError(t, err)
+		decoded, err := ip.DecodeFrame[map[string]int](frame)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"count": 3}, decoded.Data())
+
+		frame, err = ip.EncodeFrame(ip.NewCloseBracket[[]byte]())
+		require.NoError(t, err)
+		bracket, err := ip.DecodeFrame[[]byte](frame)
+		require.NoError(t, err)
+		assert.Equal(t, ip.TypeBracketClose, bracket.Type())
+		assert.Empty(t, bracket.Data())
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := ip.DecodeFrame[[]byte]([]byte{0, 0})
+		assert.Error(t, err)
+
+		oversized := binary.BigEndian.AppendUint32(nil, 100)
+		_, err = ip.DecodeFrame[[]byte](append(oversized, `{}`...))
+		assert.Error(t, err)
+
+		header := `{"type":0}`
+		missingID := binary.BigEndian.AppendUint32(nil, uint32(len(header)))
+		_, err = ip.DecodeFrame[[]byte](append(missingID, header...))
+		assert.Error(t, err)
+	})
+}
+
+func benchmarkPayload() *ip.IP[[]byte] {
+	payload := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(payload)
+	return ip.New(payload)
+}
+
+func BenchmarkCodec1MB(b *testing.B) {
+	packet := benchmarkPayload()
+
+	b.Run("json", func(b *testing.B) {
+		b.SetBytes(int64(len(packet.Data())))
+		for i := 0; i < b.N; i++ {
+			data, err := json.Marshal(packet)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := ip.Decode[[]byte](data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("binary", func(b *testing.B) {
+		b.SetBytes(int64(len(packet.Data())))
+		for i := 0; i < b.N; i++ {
+			frame, err := ip.EncodeFrame(packet)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := ip.DecodeFrame[[]byte](frame); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
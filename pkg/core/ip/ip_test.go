@@ -1,6 +1,7 @@
 package ip_test
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -66,3 +67,108 @@ func TestIP(t *testing.T) {
 		})
 	})
 }
+
+func TestFlush(t *testing.T) {
+	flush := ip.NewFlush[[]byte]()
+	assert.True(t, flush.IsFlush())
+	assert.Nil(t, flush.Data())
+	assert.Equal(t, ip.TypeNormal, flush.Type())
+
+	packet := ip.New([]byte("data"))
+	assert.False(t, packet.IsFlush())
+
+	// The flag survives cloning and can be set on any packet
+	packet.SetMetadata(ip.MetadataFlush, true)
+	assert.True(t, packet.Clone().IsFlush())
+}
+
+func TestTypedMetadata(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	packet := ip.New("test")
+	packet.SetMetadata("name", "orders")
+	packet.SetMetadata("retry", true)
+	packet.SetMetadata("count", 3)
+	packet.SetMetadata("size", int64(42))
+	packet.SetMetadata("ratio", 0.5)
+	packet.SetMetadata("at", created)
+	packet.SetMetadata("ttl", 2*time.Second)
+
+	t.Run("string", func(t *testing.T) {
+		s, ok := packet.GetStringMeta("name")
+		assert.True(t, ok)
+		assert.Equal(t, "orders", s)
+		_, ok = packet.GetStringMeta("count")
+		assert.False(t, ok)
+	})
+
+	t.Run("bool", func(t *testing.T) {
+		b, ok := packet.GetBoolMeta("retry")
+		assert.True(t, ok)
+		assert.True(t, b)
+		_, ok = packet.GetBoolMeta("name")
+		assert.False(t, ok)
+	})
+
+	t.Run("int", func(t *testing.T) {
+		n, ok := packet.GetIntMeta("count")
+		assert.True(t, ok)
+		assert.Equal(t, 3, n)
+		n, ok = packet.GetIntMeta("size")
+		assert.True(t, ok)
+		assert.Equal(t, 42, n)
+		_, ok = packet.GetIntMeta("ratio")
+		assert.False(t, ok, "fractional numbers are not integers")
+		_, ok = packet.GetIntMeta("name")
+		assert.False(t, ok)
+	})
+
+	t.Run("float", func(t *testing.T) {
+		f, ok := packet.GetFloatMeta("ratio")
+		assert.True(t, ok)
+		assert.Equal(t, 0.5, f)
+		f, ok = packet.GetFloatMeta("count")
+		assert.True(t, ok)
+		assert.Equal(t, 3.0, f)
+		_, ok = packet.GetFloatMeta("retry")
+		assert.False(t, ok)
+	})
+
+	t.Run("time", func(t *testing.T) {
+		at, ok := packet.GetTimeMeta("at")
+		assert.True(t, ok)
+		assert.True(t, created.Equal(at))
+		_, ok = packet.GetTimeMeta("count")
+		assert.False(t, ok)
+		_, ok = packet.GetTimeMeta("name")
+		assert.False(t, ok, "strings must be RFC 3339 times")
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		d, ok := packet.GetDurationMeta("ttl")
+		assert.True(t, ok)
+		assert.Equal(t, 2*time.Second, d)
+		_, ok = packet.GetDurationMeta("count")
+		assert.False(t, ok)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, ok := packet.GetStringMeta("missing")
+		assert.False(t, ok)
+		_, ok = packet.GetIntMeta("missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("after a JSON round trip", func(t *testing.T) {
+		data, err := json.Marshal(packet)
+		require.NoError(t, err)
+		decoded, err := ip.Decode[string](data)
+		require.NoError(t, err)
+
+		n, ok := decoded.GetIntMeta("count")
+		assert.True(t, ok)
+		assert.Equal(t, 3, n)
+		at, ok := decoded.GetTimeMeta("at")
+		assert.True(t, ok)
+		assert.True(t, created.Equal(at))
+	})
+}
@@ -0,0 +1,113 @@
+package ip
+
+import (
+	"math"
+	"time"
+)
+
+// Typed metadata getters. Each returns false when the key is missing or
+// its value has another type, instead of panicking on a bad assertion.
+// Values as they come back from the JSON codec are accepted too: whole
+// float64 numbers for integers and RFC 3339 strings for times.
+
+// GetStringMeta returns a string metadata value
+func (ip *IP[T]) GetStringMeta(key string) (string, bool) {
+	value, _ := ip.GetMetadata(key)
+	s, ok := value.(string)
+	return s, ok
+}
+
+// GetBoolMeta returns a bool metadata value
+func (ip *IP[T]) GetBoolMeta(key string) (bool, bool) {
+	value, _ := ip.GetMetadata(key)
+	b, ok := value.(bool)
+	return b, ok
+}
+
+// GetIntMeta returns an integer metadata value of any integer type that
+// fits in an int
+func (ip *IP[T]) GetIntMeta(key string) (int, bool) {
+	value, _ := ip.GetMetadata(key)
+	switch n := value.(type) {
+	case int:
+		return n, true
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		if n < math.MinInt || n > math.MaxInt {
+			return 0, false
+		}
+		return int(n), true
+	case uint:
+		if n > math.MaxInt {
+			return 0, false
+		}
+		return int(n), true
+	case uint8:
+		return int(n), true
+	case uint16:
+		return int(n), true
+	case uint32:
+		if uint64(n) > math.MaxInt {
+			return 0, false
+		}
+		return int(n), true
+	case uint64:
+		if n > math.MaxInt {
+			return 0, false
+		}
+		return int(n), true
+	case float64:
+		if n != math.Trunc(n) || n < math.MinInt || n >= math.MaxInt {
+			return 0, false
+		}
+		return int(n), true
+	}
+	return 0, false
+}
+
+// GetFloatMeta returns a numeric metadata value as a float64
+func (ip *IP[T]) GetFloatMeta(key string) (float64, bool) {
+	value, _ := ip.GetMetadata(key)
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+	if n, ok := ip.GetIntMeta(key); ok {
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// GetTimeMeta returns a time.Time metadata value
+func (ip *IP[T]) GetTimeMeta(key string) (time.Time, bool) {
+	value, _ := ip.GetMetadata(key)
+	switch t := value.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		return parsed, err == nil
+	}
+	return time.Time{}, false
+}
+
+// GetDurationMeta returns a time.Duration metadata value, also accepting
+// strings such as "1.5s"
+func (ip *IP[T]) GetDurationMeta(key string) (time.Duration, bool) {
+	value, _ := ip.GetMetadata(key)
+	switch d := value.(type) {
+	case time.Duration:
+		return d, true
+	case string:
+		parsed, err := time.ParseDuration(d)
+		return parsed, err == nil
+	}
+	return 0, false
+}
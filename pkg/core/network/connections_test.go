@@ -0,0 +1,60 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes/control"
+	"github.com/elleshadow/noPromises/pkg/nodes/debug"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartRequiresConnectedPorts(t *testing.T) {
+	n := New()
+	connected := newPortProcess("connected")
+	require.NoError(t, ports.Connect(connected.in, make(chan *ip.IP[string])))
+	n.AddProcess(connected)
+	n.AddProcess(newPortProcess("dangling"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := n.Start(ctx)
+	assert.ErrorIs(t, err, ErrUnconnectedPorts)
+	assert.EqualError(t, err, "required ports not connected: dangling.in")
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "Start should fail fast")
+	assert.False(t, connected.IsInitialized(), "no process should be initialized")
+
+	t.Run("initial packets satisfy an input", func(t *testing.T) {
+		require.NoError(t, AddInitial(n, "dangling", "in", "config"))
+		assert.NoError(t, n.CheckConnections())
+	})
+}
+
+func TestStartAcceptsBaseNodeSourcesAndSinks(t *testing.T) {
+	heartbeat := control.NewHeartbeat(5 * time.Millisecond)
+	watchdog := control.NewWatchdog(time.Second)
+	logger := debug.NewLogger[time.Time]("beat")
+	toWatchdog := make(chan *ip.IP[time.Time], 1)
+	toLogger := make(chan *ip.IP[time.Time], 1)
+	require.NoError(t, ports.Connect(heartbeat.OutPort, toWatchdog))
+	require.NoError(t, ports.Connect(heartbeat.OutPort, toLogger))
+	require.NoError(t, ports.Connect(watchdog.InPort, toWatchdog))
+	require.NoError(t, ports.Connect(logger.InPort, toLogger))
+
+	// Heartbeat.in, Watchdog.out and Logger.out are left unconnected
+	n := New()
+	n.AddProcess(heartbeat)
+	n.AddProcess(watchdog)
+	n.AddProcess(logger)
+	require.NoError(t, n.CheckConnections())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	assert.NoError(t, n.Start(ctx))
+}
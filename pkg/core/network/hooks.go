@@ -0,0 +1,175 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/core/process"
+)
+
+// DefaultHookConcurrency is how many hook calls may run at once per network
+const DefaultHookConcurrency = 64
+
+// ErrUnknownEdge is returned by OnPacket for an edge that does not name an
+// observable port of a process in the network
+var ErrUnknownEdge = errors.New("unknown edge")
+
+// ProcessState is a lifecycle state reported to OnStateChange hooks
+type ProcessState string
+
+const (
+	// StateRunning is reported when a process starts its processing loop
+	StateRunning ProcessState = "running"
+	// StateStopped is reported when a process finishes or is cancelled
+	StateStopped ProcessState = "stopped"
+	// StateFailed is reported when a process returns an error or panics
+	StateFailed ProcessState = "failed"
+)
+
+// hooks holds a network's observability callbacks. Every call runs on its
+// own goroutine so a slow hook cannot stall the flow; when
+// DefaultHookConcurrency calls are already running, new events are
+// dropped and counted instead of queued. Calls are therefore unordered.
+type hooks struct {
+	errors  []func(process string, err error)
+	states  []func(process string, state ProcessState)
+	slots   chan struct{}
+	dropped atomic.Uint64
+	mu      sync.RWMutex
+}
+
+func newHooks() *hooks {
+	return &hooks{slots: make(chan struct{}, DefaultHookConcurrency)}
+}
+
+// dispatch runs fn asynchronously if a slot is free, recovering its panics
+func (h *hooks) dispatch(fn func()) {
+	select {
+	case h.slots <- struct{}{}:
+	default:
+		h.dropped.Add(1)
+		return
+	}
+	go func() {
+		defer func() {
+			<-h.slots
+			if r := recover(); r != nil {
+				log.Printf("network hook panicked: %v", r)
+			}
+		}()
+		fn()
+	}()
+}
+
+func (h *hooks) stateChanged(name string, state ProcessState) {
+	h.mu.RLock()
+	fns := h.states
+	h.mu.RUnlock()
+	for _, fn := range fns {
+		fn := fn
+		h.dispatch(func() { fn(name, state) })
+	}
+}
+
+func (h *hooks) failed(name string, err error) {
+	h.mu.RLock()
+	fns := h.errors
+	h.mu.RUnlock()
+	for _, fn := range fns {
+		fn := fn
+		h.dispatch(func() { fn(name, err) })
+	}
+}
+
+type hooksKey struct{}
+
+// withHooks returns a context carrying h, so processes run anywhere below
+// the network, including under supervisors, report to it
+func withHooks(ctx context.Context, h *hooks) context.Context {
+	return context.WithValue(ctx, hooksKey{}, h)
+}
+
+func hooksFromContext(ctx context.Context) *hooks {
+	h, _ := ctx.Value(hooksKey{}).(*hooks)
+	return h
+}
+
+// OnError registers fn to be called with every error a process of the
+// network fails with, including recovered panics
+func (n *Network) OnError(fn func(process string, err error)) {
+	n.hooks.mu.Lock()
+	defer n.hooks.mu.Unlock()
+	n.hooks.errors = append(n.hooks.errors, fn)
+}
+
+// OnStateChange registers fn to be called as processes of the network
+// start, stop and fail
+func (n *Network) OnStateChange(fn func(process string, state ProcessState)) {
+	n.hooks.mu.Lock()
+	defer n.hooks.mu.Unlock()
+	n.hooks.states = append(n.hooks.states, fn)
+}
+
+// OnPacket registers fn to be called with every packet sent on edge, named
+// "process.port" after the sending output port
+func (n *Network) OnPacket(edge string, fn func(edge string, packet any)) error {
+	port, err := n.edgePort(edge)
+	if err != nil {
+		return err
+	}
+	port.Observe(func(packet any) {
+		n.hooks.dispatch(func() { fn(edge, packet) })
+	})
+	return nil
+}
+
+// DroppedHookEvents returns how many hook calls were skipped because too
+// many were already running
+func (n *Network) DroppedHookEvents() uint64 {
+	return n.hooks.dropped.Load()
+}
+
+// edgePort resolves "process.port" to an observable port
+func (n *Network) edgePort(edge string) (ports.Observable, error) {
+	for i := len(edge) - 1; i > 0; i-- {
+		if edge[i] != '.' {
+			continue
+		}
+		n.mu.RLock()
+		p, exists := n.processes[edge[:i]]
+		n.mu.RUnlock()
+		if !exists {
+			continue
+		}
+		lister, ok := p.(portLister)
+		if !ok {
+			break
+		}
+		for _, port := range lister.Ports() {
+			if observable, ok := port.(ports.Observable); ok && port.Name() == edge[i+1:] {
+				return observable, nil
+			}
+		}
+		break
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownEdge, edge)
+}
+
+// reportExit tells the hooks in ctx how a process's run ended
+func reportExit(ctx context.Context, p process.Process, err error) {
+	h := hooksFromContext(ctx)
+	if h == nil {
+		return
+	}
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ports.ErrStreamClosed) {
+		h.stateChanged(p.Name(), StateStopped)
+		return
+	}
+	h.failed(p.Name(), err)
+	h.stateChanged(p.Name(), StateFailed)
+}
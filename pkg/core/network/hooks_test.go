@@ -0,0 +1,118 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnErrorHook(t *testing.T) {
+	boom := errors.New("boom")
+	n := New()
+	n.AddProcess(newTestProcessFunc("failing", func(context.Context) error { return boom }))
+
+	errs := make(chan error, 1)
+	n.OnError(func(process string, err error) {
+		assert.Equal(t, "failing", process)
+		errs <- err
+	})
+
+	var mu sync.Mutex
+	var states []ProcessState
+	stateSeen := make(chan struct{}, 2)
+	n.OnStateChange(func(_ string, state ProcessState) {
+		mu.Lock()
+		states = append(states, state)
+		mu.Unlock()
+		stateSeen <- struct{}{}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.ErrorIs(t, n.Start(ctx), boom)
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, boom)
+	case <-ctx.Done():
+		t.Fatal("OnError hook was not called")
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-stateSeen:
+		case <-ctx.Done():
+			t.Fatal("OnStateChange hook was not called")
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// Hooks run asynchronously, so only the set of states is guaranteed
+	assert.ElementsMatch(t, []ProcessState{StateRunning, StateFailed}, states)
+}
+
+func TestOnPacketHook(t *testing.T) {
+	n := New()
+	p := newPortProcess("echo")
+	n.AddProcess(p)
+
+	in := make(chan *ip.IP[string], 1)
+	out := make(chan *ip.IP[string], 1)
+	require.NoError(t, ports.Connect(p.in, in))
+	require.NoError(t, ports.Connect(p.out, out))
+
+	seen := make(chan any, 1)
+	require.NoError(t, n.OnPacket("echo.out", func(edge string, packet any) {
+		assert.Equal(t, "echo.out", edge)
+		seen <- packet
+	}))
+	assert.ErrorIs(t, n.OnPacket("echo.missing", func(string, any) {}), ErrUnknownEdge)
+	assert.ErrorIs(t, n.OnPacket("nobody.out", func(string, any) {}), ErrUnknownEdge)
+
+	require.NoError(t, p.out.Send(context.Background(), ip.New("hello")))
+
+	select {
+	case packet := <-seen:
+		assert.Equal(t, "hello", packet.(*ip.IP[string]).Data())
+	case <-time.After(time.Second):
+		t.Fatal("OnPacket hook was not called")
+	}
+	assert.Equal(t, "hello", (<-out).Data())
+}
+
+func TestSlowHookDoesNotStallFlow(t *testing.T) {
+	n := New()
+	p := newPortProcess("echo")
+	n.AddProcess(p)
+
+	out := make(chan *ip.IP[string], DefaultHookConcurrency*2)
+	require.NoError(t, ports.Connect(p.out, out))
+
+	release := make(chan struct{})
+	defer close(release)
+	require.NoError(t, n.OnPacket("echo.out", func(string, any) { <-release }))
+
+	// Twice as many packets as hook slots go through without waiting
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < DefaultHookConcurrency*2; i++ {
+			assert.NoError(t, p.out.Send(context.Background(), ip.New("x")))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a blocked hook stalled the sender")
+	}
+	assert.Len(t, out, DefaultHookConcurrency*2)
+	assert.Equal(t, uint64(DefaultHookConcurrency), n.DroppedHookEvents())
+}
@@ -0,0 +1,36 @@
+package network
+
+import (
+	"fmt"
+
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// AddInitial attaches an initial information packet carrying value to the
+// named input port of a process. The process receives it once, before any
+// other packet on that port.
+func AddInitial[T any](n *Network, processName, portName string, value T) error {
+	proc := n.GetProcess(processName)
+	if proc == nil {
+		return fmt.Errorf("process %s not found", processName)
+	}
+	resolver, ok := proc.(nodes.PortResolver)
+	if !ok {
+		return fmt.Errorf("process %s does not expose named ports", processName)
+	}
+	port, ok := resolver.Port(portName)
+	if !ok {
+		return fmt.Errorf("process %s has no port %s", processName, portName)
+	}
+	if port.Type() != ports.TypeInput {
+		return fmt.Errorf("port %s.%s is not an input port", processName, portName)
+	}
+	typed, ok := port.(*ports.Port[T])
+	if !ok {
+		return fmt.Errorf("port %s.%s does not accept %T", processName, portName, value)
+	}
+
+	typed.AddInitial(value)
+	return nil
+}
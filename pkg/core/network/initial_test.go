@@ -0,0 +1,93 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/core/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// portProcess records the packets received on its input port
+type portProcess struct {
+	process.BaseProcess
+	in       *ports.Port[string]
+	out      *ports.Port[string]
+	received chan *ip.IP[string]
+}
+
+func newPortProcess(name string) *portProcess {
+	return &portProcess{
+		BaseProcess: process.NewBaseProcess(name),
+		in:          ports.NewInput[string]("in", "Input", true),
+		out:         ports.NewOutput[string]("out", "Output", false),
+		received:    make(chan *ip.IP[string], 10),
+	}
+}
+
+func (p *portProcess) Port(name string) (ports.AnyPort, bool) {
+	switch name {
+	case "in":
+		return p.in, true
+	case "out":
+		return p.out, true
+	}
+	return nil, false
+}
+
+func (p *portProcess) Ports() []ports.AnyPort {
+	return []ports.AnyPort{p.in, p.out}
+}
+
+func (p *portProcess) Process(ctx context.Context) error {
+	for {
+		packet, err := p.in.Receive(ctx)
+		if err != nil {
+			return err
+		}
+		p.received <- packet
+	}
+}
+
+func TestAddInitial(t *testing.T) {
+	n := New()
+	p := newPortProcess("worker")
+	n.AddProcess(p)
+
+	ch := make(chan *ip.IP[string], 1)
+	require.NoError(t, ports.Connect(p.in, ch))
+	ch <- ip.New("data")
+
+	require.NoError(t, AddInitial(n, "worker", "in", "settings"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go func() {
+		_ = n.Start(ctx)
+	}()
+
+	for _, want := range []struct {
+		data string
+		typ  ip.Type
+	}{{"settings", ip.TypeInitial}, {"data", ip.TypeNormal}} {
+		select {
+		case packet := <-p.received:
+			assert.Equal(t, want.data, packet.Data())
+			assert.Equal(t, want.typ, packet.Type())
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for packet")
+		}
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		assert.ErrorContains(t, AddInitial(n, "missing", "in", "x"), "not found")
+		assert.ErrorContains(t, AddInitial(n, "worker", "missing", "x"), "no port")
+		assert.ErrorContains(t, AddInitial(n, "worker", "out", "x"), "not an input port")
+		assert.ErrorContains(t, AddInitial(n, "worker", "in", 42), "does not accept")
+		assert.ErrorContains(t, AddInitial(n, "", "in", "x"), "not found")
+	})
+}
@@ -0,0 +1,184 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidLimits is returned by ParseLimits for a malformed limits config
+var ErrInvalidLimits = errors.New("invalid limits")
+
+// Limits bounds the resources a single network may use
+type Limits struct {
+	// MaxGoroutines caps the worker goroutines nodes may spawn; 0 means unlimited
+	MaxGoroutines int
+	// MemoryHint is a soft budget in bytes used to size buffers; 0 means no hint
+	MemoryHint int64
+	// Resources caps concurrent use of named external resources, such as
+	// one database or API, shared by every process of the network. Nodes
+	// calling a resource hold a slot of ResourceFromContext while they do.
+	Resources map[string]int
+}
+
+// ParseLimits reads limits from the "limits" object of a flow config, with
+// the keys max_goroutines, memory_hint and resources (resource name to
+// limit). Missing keys leave the limit unset.
+func ParseLimits(config map[string]interface{}) (Limits, error) {
+	var limits Limits
+	for key, value := range config {
+		switch key {
+		case "max_goroutines":
+			n, ok := limitValue(value)
+			if !ok {
+				return Limits{}, fmt.Errorf("%w: max_goroutines must be a non-negative integer", ErrInvalidLimits)
+			}
+			limits.MaxGoroutines = n
+		case "memory_hint":
+			n, ok := limitValue(value)
+			if !ok {
+				return Limits{}, fmt.Errorf("%w: memory_hint must be a non-negative integer", ErrInvalidLimits)
+			}
+			limits.MemoryHint = int64(n)
+		case "resources":
+			resources, ok := value.(map[string]interface{})
+			if !ok {
+				return Limits{}, fmt.Errorf("%w: resources must be an object", ErrInvalidLimits)
+			}
+			limits.Resources = make(map[string]int, len(resources))
+			for name, raw := range resources {
+				n, ok := limitValue(raw)
+				if !ok || n == 0 {
+					return Limits{}, fmt.Errorf("%w: resource %q must have a positive integer limit", ErrInvalidLimits, name)
+				}
+				limits.Resources[name] = n
+			}
+		default:
+			return Limits{}, fmt.Errorf("%w: unknown key %q", ErrInvalidLimits, key)
+		}
+	}
+	return limits, nil
+}
+
+// limitValue converts a decoded JSON (float64) or YAML (int) number to a
+// non-negative int
+func limitValue(value interface{}) (int, bool) {
+	switch n := value.(type) {
+	case int:
+		return n, n >= 0
+	case float64:
+		if n < 0 || n != math.Trunc(n) || n >= math.MaxInt {
+			return 0, false
+		}
+		return int(n), true
+	}
+	return 0, false
+}
+
+// BufferSize returns how many items of itemSize bytes fit in the memory
+// hint, or fallback when no hint is set. The result is at least 1.
+func (l Limits) BufferSize(itemSize int, fallback int) int {
+	size := fallback
+	if l.MemoryHint > 0 && itemSize > 0 {
+		size = int(l.MemoryHint / int64(itemSize))
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// Semaphore limits how many workers run at once. A nil Semaphore never blocks.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore creates a semaphore with n slots, or returns nil when n <= 0
+func NewSemaphore(n int) *Semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return &Semaphore{slots: make(chan struct{}, n)}
+}
+
+// Acquire blocks until a slot is free or the context is done
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (s *Semaphore) Release() {
+	if s == nil {
+		return
+	}
+	<-s.slots
+}
+
+// InUse returns the number of slots currently held
+func (s *Semaphore) InUse() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.slots)
+}
+
+type resourcesKey struct{}
+
+// resources is what a network passes to its processes through the context
+type resources struct {
+	limits    Limits
+	semaphore *Semaphore
+	shared    map[string]*Semaphore
+}
+
+// WithLimits returns a context carrying limits, a semaphore enforcing
+// MaxGoroutines and one per limited resource. Network.Start does this for
+// its processes, so they all share the same semaphores.
+func WithLimits(ctx context.Context, limits Limits) context.Context {
+	shared := make(map[string]*Semaphore, len(limits.Resources))
+	for name, n := range limits.Resources {
+		shared[name] = NewSemaphore(n)
+	}
+	return context.WithValue(ctx, resourcesKey{}, &resources{
+		limits:    limits,
+		semaphore: NewSemaphore(limits.MaxGoroutines),
+		shared:    shared,
+	})
+}
+
+// LimitsFromContext returns the limits of the network running the process
+func LimitsFromContext(ctx context.Context) Limits {
+	if r, ok := ctx.Value(resourcesKey{}).(*resources); ok {
+		return r.limits
+	}
+	return Limits{}
+}
+
+// SemaphoreFromContext returns the semaphore nodes must hold for each
+// worker goroutine they spawn. It is nil when goroutines are unlimited.
+func SemaphoreFromContext(ctx context.Context) *Semaphore {
+	if r, ok := ctx.Value(resourcesKey{}).(*resources); ok {
+		return r.semaphore
+	}
+	return nil
+}
+
+// ResourceFromContext returns the semaphore limiting concurrent use of the
+// named resource across the network. Nodes hold a slot for each call to
+// the resource. It is nil, and never blocks, when the resource has no
+// limit.
+func ResourceFromContext(ctx context.Context, name string) *Semaphore {
+	if r, ok := ctx.Value(resourcesKey{}).(*resources); ok {
+		return r.shared[name]
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemaphore(t *testing.T) {
+	t.Run("blocks at capacity", func(t *testing.T) {
+		sem := NewSemaphore(2)
+		ctx := context.Background()
+
+		require.NoError(t, sem.Acquire(ctx))
+		require.NoError(t, sem.Acquire(ctx))
+		assert.Equal(t, 2, sem.InUse())
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, sem.Acquire(timeoutCtx), context.DeadlineExceeded)
+
+		sem.Release()
+		assert.NoError(t, sem.Acquire(ctx))
+	})
+
+	t.Run("nil is unlimited", func(t *testing.T) {
+		sem := NewSemaphore(0)
+		assert.Nil(t, sem)
+		assert.NoError(t, sem.Acquire(context.Background()))
+		sem.Release()
+		assert.Equal(t, 0, sem.InUse())
+	})
+}
+
+func TestLimits(t *testing.T) {
+	t.Run("context", func(t *testing.T) {
+		ctx := context.Background()
+		assert.Nil(t, SemaphoreFromContext(ctx))
+		assert.Equal(t, Limits{}, LimitsFromContext(ctx))
+
+		limits := Limits{MaxGoroutines: 3, MemoryHint: 1 << 20}
+		ctx = WithLimits(ctx, limits)
+		assert.Equal(t, limits, LimitsFromContext(ctx))
+		require.NotNil(t, SemaphoreFromContext(ctx))
+		assert.Same(t, SemaphoreFromContext(ctx), SemaphoreFromContext(ctx))
+	})
+
+	t.Run("resources", func(t *testing.T) {
+		ctx := WithLimits(context.Background(), Limits{Resources: map[string]int{"db": 2}})
+		db := ResourceFromContext(ctx, "db")
+		require.NotNil(t, db)
+		assert.Same(t, db, ResourceFromContext(ctx, "db"), "processes share one semaphore per resource")
+		assert.Nil(t, ResourceFromContext(ctx, "api"), "unlisted resources are unlimited")
+		assert.Nil(t, ResourceFromContext(context.Background(), "db"))
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		limits, err := ParseLimits(map[string]interface{}{
+			"max_goroutines": float64(4),
+			"memory_hint":    1024,
+			"resources":      map[string]interface{}{"api": float64(1)},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, Limits{MaxGoroutines: 4, MemoryHint: 1024, Resources: map[string]int{"api": 1}}, limits)
+
+		for name, config := range map[string]map[string]interface{}{
+			"fraction":      {"max_goroutines": 1.5},
+			"negative":      {"memory_hint": -1},
+			"not an object": {"resources": "api"},
+			"zero resource": {"resources": map[string]interface{}{"api": 0}},
+			"unknown key":   {"max_threads": 2},
+		} {
+			_, err := ParseLimits(config)
+			assert.ErrorIs(t, err, ErrInvalidLimits, name)
+		}
+	})
+
+	t.Run("buffer size", func(t *testing.T) {
+		assert.Equal(t, 16, Limits{}.BufferSize(1024, 16))
+		assert.Equal(t, 4, Limits{MemoryHint: 4096}.BufferSize(1024, 16))
+		assert.Equal(t, 1, Limits{MemoryHint: 10}.BufferSize(1024, 16))
+	})
+}
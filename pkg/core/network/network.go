@@ -2,15 +2,35 @@ package network
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/elleshadow/noPromises/pkg/core/ports"
 	"github.com/elleshadow/noPromises/pkg/core/process"
 )
 
+// ErrUnconnectedPorts is returned by Start when required ports have nothing connected
+var ErrUnconnectedPorts = errors.New("required ports not connected")
+
+// portLister is implemented by processes that can enumerate their ports
+type portLister interface {
+	Ports() []ports.AnyPort
+}
+
 // Network represents a collection of connected processes
 type Network struct {
 	processes map[string]process.Process
+	order     []string
+	limits    Limits
+	root      *Supervisor
+	hooks     *hooks
+	taps      atomic.Int64
+	// ownership makes every port enforce packet ownership once started
+	ownership bool
 	mu        sync.RWMutex
 }
 
@@ -18,6 +38,7 @@ type Network struct {
 func New() *Network {
 	return &Network{
 		processes: make(map[string]process.Process),
+		hooks:     newHooks(),
 	}
 }
 
@@ -25,6 +46,9 @@ func New() *Network {
 func (n *Network) AddProcess(p process.Process) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
+	if _, exists := n.processes[p.Name()]; !exists {
+		n.order = append(n.order, p.Name())
+	}
 	n.processes[p.Name()] = p
 }
 
@@ -35,6 +59,49 @@ func (n *Network) GetProcess(name string) process.Process {
 	return n.processes[name]
 }
 
+// SetLimits sets the resource limits applied when the network starts
+func (n *Network) SetLimits(limits Limits) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.limits = limits
+}
+
+// Limits returns the network's resource limits
+func (n *Network) Limits() Limits {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.limits
+}
+
+// EnforceOwnership turns packet ownership enforcement on or off for the
+// ports of every process when the network starts. With it on, a process
+// sending a packet it received but has since handed to another process
+// fails with ports.ErrOwnershipViolation, catching packets mutated by two
+// processes at once. It only covers processes listing their ports.
+func (n *Network) EnforceOwnership(enabled bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.ownership = enabled
+}
+
+// Supervise runs the network's processes under a root supervisor with the
+// given strategy, so a failed process is restarted instead of stopping the
+// network. Processes are supervised in the order they were added. The
+// returned supervisor can be used to adjust restart limits.
+func (n *Network) Supervise(strategy Strategy) *Supervisor {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.root = NewSupervisor("root", strategy)
+	return n.root
+}
+
+// Supervisor returns the root supervisor, or nil if the network is not supervised
+func (n *Network) Supervisor() *Supervisor {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.root
+}
+
 // ProcessCount returns the number of processes in the network
 func (n *Network) ProcessCount() int {
 	n.mu.RLock()
@@ -45,12 +112,23 @@ func (n *Network) ProcessCount() int {
 // Start starts all processes in the network
 func (n *Network) Start(ctx context.Context) error {
 	n.mu.RLock()
-	processes := make([]process.Process, 0, len(n.processes))
-	for _, p := range n.processes {
-		processes = append(processes, p)
-	}
+	processes := n.orderedProcesses()
+	limits := n.limits
+	root := n.root
+	ownership := n.ownership
 	n.mu.RUnlock()
 
+	if err := checkConnections(processes); err != nil {
+		return err
+	}
+	if ownership {
+		enforceOwnership(processes)
+	}
+
+	// Share one set of limits and hooks across all processes
+	ctx = WithLimits(ctx, limits)
+	ctx = withHooks(ctx, n.hooks)
+
 	// Initialize all processes
 	for _, p := range processes {
 		if err := p.Initialize(ctx); err != nil {
@@ -58,6 +136,17 @@ func (n *Network) Start(ctx context.Context) error {
 		}
 	}
 
+	if root != nil {
+		root.mu.Lock()
+		root.children = processes
+		root.mu.Unlock()
+
+		if err := root.Process(ctx); err != nil && err != context.Canceled {
+			return err
+		}
+		return nil
+	}
+
 	// Start all processes
 	errCh := make(chan error, len(processes))
 	var wg sync.WaitGroup
@@ -66,7 +155,8 @@ func (n *Network) Start(ctx context.Context) error {
 		wg.Add(1)
 		go func(p process.Process) {
 			defer wg.Done()
-			if err := p.Process(ctx); err != nil && err != context.Canceled {
+			err := runProcess(ctx, p)
+			if err != nil && err != context.Canceled && !errors.Is(err, ports.ErrStreamClosed) {
 				errCh <- fmt.Errorf("process %s failed: %w", p.Name(), err)
 			}
 		}(p)
@@ -86,20 +176,89 @@ func (n *Network) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop stops all processes in the network
-func (n *Network) Stop(ctx context.Context) error {
+// enforceOwnership makes each port of each process enforce ownership on
+// the process's behalf
+func enforceOwnership(processes []process.Process) {
+	for _, p := range processes {
+		lister, ok := p.(portLister)
+		if !ok {
+			continue
+		}
+		for _, port := range lister.Ports() {
+			if enforcer, ok := port.(ports.OwnershipEnforcer); ok {
+				enforcer.EnforceOwnership(p.Name())
+			}
+		}
+	}
+}
+
+// CheckConnections verifies that every required port of every process is
+// connected, listing the unconnected ones as "process.port". Start runs
+// this check before starting any process.
+func (n *Network) CheckConnections() error {
 	n.mu.RLock()
-	processes := make([]process.Process, 0, len(n.processes))
-	for _, p := range n.processes {
-		processes = append(processes, p)
+	processes := n.orderedProcesses()
+	n.mu.RUnlock()
+	return checkConnections(processes)
+}
+
+func checkConnections(processes []process.Process) error {
+	var unconnected []string
+	for _, p := range processes {
+		lister, ok := p.(portLister)
+		if !ok {
+			continue
+		}
+		for _, port := range lister.Ports() {
+			if port.Required() && !port.Connected() {
+				unconnected = append(unconnected, p.Name()+"."+port.Name())
+			}
+		}
 	}
+	if len(unconnected) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnconnectedPorts, strings.Join(unconnected, ", "))
+	}
+	return nil
+}
+
+// Stop stops all processes in the network, in ascending order of their
+// ConfigStopPriority and otherwise in the order they were added. Every
+// process is stopped even when others fail; the report records how each
+// stop went and the error joins every failure.
+func (n *Network) Stop(ctx context.Context) (*ShutdownReport, error) {
+	n.mu.RLock()
+	processes := stopOrder(n.orderedProcesses())
 	n.mu.RUnlock()
 
-	var lastErr error
+	report := &ShutdownReport{Processes: make([]ProcessShutdown, 0, len(processes))}
+	start := time.Now()
+	var errs []error
 	for _, p := range processes {
-		if err := p.Shutdown(ctx); err != nil {
-			lastErr = fmt.Errorf("failed to stop process %s: %w", p.Name(), err)
+		stopped := time.Now()
+		err := p.Shutdown(ctx)
+		result := ProcessShutdown{
+			Process:  p.Name(),
+			Duration: time.Since(stopped),
+			Err:      err,
+		}
+		if reporter, ok := p.(DrainReporter); ok {
+			result.Drained = reporter.DrainedPackets()
+		}
+		report.Processes = append(report.Processes, result)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop process %s: %w", p.Name(), err))
 		}
 	}
-	return lastErr
+	report.Duration = time.Since(start)
+	return report, errors.Join(errs...)
+}
+
+// orderedProcesses returns the processes in the order they were added; the
+// caller must hold n.mu
+func (n *Network) orderedProcesses() []process.Process {
+	processes := make([]process.Process, 0, len(n.order))
+	for _, name := range n.order {
+		processes = append(processes, n.processes[name])
+	}
+	return processes
 }
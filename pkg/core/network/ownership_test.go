@@ -0,0 +1,103 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/core/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resendProcess forwards its first packet, then sends it again once
+// handedOff is closed, after the downstream process has taken it over
+type resendProcess struct {
+	process.BaseProcess
+	in        *ports.Port[string]
+	out       *ports.Port[string]
+	handedOff chan struct{}
+}
+
+func (p *resendProcess) Ports() []ports.AnyPort {
+	return []ports.AnyPort{p.in, p.out}
+}
+
+func (p *resendProcess) Process(ctx context.Context) error {
+	packet, err := p.in.Receive(ctx)
+	if err != nil {
+		return err
+	}
+	if err := p.out.Send(ctx, packet); err != nil {
+		return err
+	}
+	<-p.handedOff
+	if err := p.out.Send(ctx, packet); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestEnforceOwnership(t *testing.T) {
+	run := func(t *testing.T, enforce bool) error {
+		n := New()
+		n.EnforceOwnership(enforce)
+		sender := &resendProcess{
+			BaseProcess: process.NewBaseProcess("sender"),
+			in:          ports.NewInput[string]("in", "Input", true),
+			out:         ports.NewOutput[string]("out", "Output", true),
+			handedOff:   make(chan struct{}),
+		}
+		sink := newPortProcess("sink")
+		n.AddProcess(sender)
+		n.AddProcess(sink)
+
+		input := make(chan *ip.IP[string], 1)
+		edge := make(chan *ip.IP[string], 2)
+		require.NoError(t, ports.Connect(sender.in, input))
+		require.NoError(t, ports.Connect(sender.out, edge))
+		require.NoError(t, ports.Connect(sink.in, edge))
+		input <- ip.New("data")
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- n.Start(ctx)
+		}()
+
+		select {
+		case packet := <-sink.received:
+			if enforce {
+				assert.Equal(t, "sink", packet.Owner())
+			} else {
+				assert.Empty(t, packet.Owner())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for packet")
+		}
+		close(sender.handedOff)
+		if !enforce {
+			select {
+			case <-sink.received:
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for resent packet")
+			}
+			cancel()
+		}
+		return <-errCh
+	}
+
+	t.Run("enforced", func(t *testing.T) {
+		err := run(t, true)
+		assert.ErrorIs(t, err, ports.ErrOwnershipViolation)
+		assert.ErrorContains(t, err, "sender sent packet")
+	})
+
+	t.Run("not enforced", func(t *testing.T) {
+		assert.NoError(t, run(t, false))
+	})
+}
@@ -0,0 +1,40 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/elleshadow/noPromises/pkg/core/process"
+)
+
+// PanicError reports a panic recovered from a process's processing loop
+type PanicError struct {
+	Process string
+	Value   any
+	Stack   []byte
+}
+
+// Error returns the panic description
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("process %s panicked: %v", e.Process, e.Value)
+}
+
+// runProcess runs p's processing loop, converting a panic into a
+// *PanicError so one faulty node cannot crash the whole program. It reports
+// the process's state changes and errors to the network's hooks.
+func runProcess(ctx context.Context, p process.Process) (err error) {
+	if h := hooksFromContext(ctx); h != nil {
+		h.stateChanged(p.Name(), StateRunning)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			log.Printf("process %s panicked: %v\n%s", p.Name(), r, stack)
+			err = &PanicError{Process: p.Name(), Value: r, Stack: stack}
+		}
+		reportExit(ctx, p, err)
+	}()
+	return p.Process(ctx)
+}
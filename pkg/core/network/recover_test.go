@@ -0,0 +1,75 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessPanicRecovery(t *testing.T) {
+	var logBuf bytes.Buffer
+	original := log.Writer()
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(original)
+
+	packets := make(chan interface{}, 3)
+	packets <- 1
+	packets <- 2
+	packets <- "bad"
+
+	n := New()
+	n.AddProcess(newTestProcessFunc("summer", func(ctx context.Context) error {
+		sum := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case packet := <-packets:
+				sum += packet.(int) // panics on the bad packet
+			}
+		}
+	}))
+	n.AddProcess(newTestProcessFunc("idle", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := n.Start(ctx)
+	require.Error(t, err)
+
+	var panicErr *PanicError
+	require.True(t, errors.As(err, &panicErr), "expected a PanicError, got %v", err)
+	assert.Equal(t, "summer", panicErr.Process)
+	assert.Contains(t, err.Error(), "process summer failed")
+	assert.NotEmpty(t, panicErr.Stack)
+	assert.Contains(t, logBuf.String(), "process summer panicked")
+}
+
+func TestSupervisorRestartsPanickedProcess(t *testing.T) {
+	original := log.Writer()
+	log.SetOutput(&bytes.Buffer{})
+	defer log.SetOutput(original)
+
+	runs := 0
+	s := NewSupervisor("sup", OneForOne)
+	s.Add(newTestProcessFunc("flaky", func(context.Context) error {
+		runs++
+		if runs == 1 {
+			panic("first run")
+		}
+		return nil
+	}))
+
+	assert.NoError(t, s.Process(context.Background()))
+	assert.Equal(t, 2, runs)
+	assert.Equal(t, 1, s.Restarts())
+}
@@ -0,0 +1,98 @@
+package network
+
+import (
+	"sort"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/process"
+)
+
+// ConfigStopPriority is the node configuration key ordering shutdown.
+// Network.Stop stops processes with higher priorities later, so a sink
+// that must drain can be given a priority above its producers. Processes
+// without one have priority 0 and equal priorities stop in the order the
+// processes were added.
+const ConfigStopPriority = "stopPriority"
+
+// configurable is implemented by processes with a configuration map
+type configurable interface {
+	GetConfig() map[string]interface{}
+}
+
+// stopPriority returns a process's configured stop priority
+func stopPriority(p process.Process) int {
+	c, ok := p.(configurable)
+	if !ok {
+		return 0
+	}
+	switch priority := c.GetConfig()[ConfigStopPriority].(type) {
+	case int:
+		return priority
+	case int64:
+		return int(priority)
+	case float64:
+		// Numbers decoded from JSON flow configs
+		return int(priority)
+	default:
+		return 0
+	}
+}
+
+// stopOrder sorts processes into the order Stop shuts them down
+func stopOrder(processes []process.Process) []process.Process {
+	priorities := make(map[process.Process]int, len(processes))
+	for _, p := range processes {
+		priorities[p] = stopPriority(p)
+	}
+	sort.SliceStable(processes, func(i, j int) bool {
+		return priorities[processes[i]] < priorities[processes[j]]
+	})
+	return processes
+}
+
+// DrainReporter is implemented by processes that drain buffered packets
+// when shut down, so the shutdown report can count them
+type DrainReporter interface {
+	DrainedPackets() int
+}
+
+// ProcessShutdown is how stopping one process went
+type ProcessShutdown struct {
+	Process  string
+	Duration time.Duration
+	Err      error
+	// Drained counts the packets the process drained, for DrainReporters
+	Drained int
+}
+
+// ShutdownReport describes a Network.Stop, listing processes in the order
+// they were stopped
+type ShutdownReport struct {
+	Duration  time.Duration
+	Processes []ProcessShutdown
+}
+
+// Failed returns the processes that failed to stop
+func (r *ShutdownReport) Failed() []ProcessShutdown {
+	var failed []ProcessShutdown
+	for _, p := range r.Processes {
+		if p.Err != nil {
+			failed = append(failed, p)
+		}
+	}
+	return failed
+}
+
+// Slowest returns the process that took longest to stop
+func (r *ShutdownReport) Slowest() (ProcessShutdown, bool) {
+	if len(r.Processes) == 0 {
+		return ProcessShutdown{}, false
+	}
+	slowest := r.Processes[0]
+	for _, p := range r.Processes[1:] {
+		if p.Duration > slowest.Duration {
+			slowest = p
+		}
+	}
+	return slowest, true
+}
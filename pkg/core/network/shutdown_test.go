@@ -0,0 +1,119 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stopRecorder records the order processes are shut down in
+type stopRecorder struct {
+	order []string
+	mu    sync.Mutex
+}
+
+// prioritizedProcess is a configurable process reporting its shutdown
+type prioritizedProcess struct {
+	process.BaseProcess
+	config   map[string]interface{}
+	recorder *stopRecorder
+}
+
+func (p *prioritizedProcess) GetConfig() map[string]interface{} {
+	return p.config
+}
+
+func (p *prioritizedProcess) Shutdown(ctx context.Context) error {
+	p.recorder.mu.Lock()
+	p.recorder.order = append(p.recorder.order, p.Name())
+	p.recorder.mu.Unlock()
+	return p.BaseProcess.Shutdown(ctx)
+}
+
+func TestStopPriority(t *testing.T) {
+	recorder := &stopRecorder{}
+	add := func(n *Network, name string, priority interface{}) {
+		config := map[string]interface{}{}
+		if priority != nil {
+			config[ConfigStopPriority] = priority
+		}
+		n.AddProcess(&prioritizedProcess{
+			BaseProcess: process.NewBaseProcess(name),
+			config:      config,
+			recorder:    recorder,
+		})
+	}
+
+	n := New()
+	add(n, "sink", 10)
+	add(n, "source", -1)
+	add(n, "transform", nil)
+	add(n, "audit", float64(10)) // as decoded from JSON
+	add(n, "enricher", nil)
+	n.AddProcess(newTestProcess("plain"))
+
+	_, err := n.Stop(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"source", "transform", "enricher", "sink", "audit"}, recorder.order)
+}
+
+// stoppingProcess shuts down slowly, with an error, or after draining
+type stoppingProcess struct {
+	process.BaseProcess
+	delay   time.Duration
+	err     error
+	drained int
+}
+
+func (p *stoppingProcess) Shutdown(ctx context.Context) error {
+	time.Sleep(p.delay)
+	if p.err != nil {
+		return p.err
+	}
+	return p.BaseProcess.Shutdown(ctx)
+}
+
+type drainingProcess struct {
+	stoppingProcess
+}
+
+func (p *drainingProcess) DrainedPackets() int {
+	return p.drained
+}
+
+func TestShutdownReport(t *testing.T) {
+	errStuck := errors.New("connection stuck")
+
+	n := New()
+	n.AddProcess(&stoppingProcess{BaseProcess: process.NewBaseProcess("broken"), err: errStuck})
+	n.AddProcess(&stoppingProcess{BaseProcess: process.NewBaseProcess("slow"), delay: 50 * time.Millisecond})
+	n.AddProcess(&drainingProcess{stoppingProcess{BaseProcess: process.NewBaseProcess("sink"), drained: 3}})
+
+	report, err := n.Stop(context.Background())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errStuck)
+	assert.Contains(t, err.Error(), "failed to stop process broken")
+
+	// Every process is stopped despite the failure
+	require.Len(t, report.Processes, 3)
+	assert.Equal(t, "broken", report.Processes[0].Process)
+	assert.ErrorIs(t, report.Processes[0].Err, errStuck)
+	assert.NoError(t, report.Processes[1].Err)
+	assert.Equal(t, 3, report.Processes[2].Drained)
+
+	failed := report.Failed()
+	require.Len(t, failed, 1)
+	assert.Equal(t, "broken", failed[0].Process)
+
+	slowest, ok := report.Slowest()
+	require.True(t, ok)
+	assert.Equal(t, "slow", slowest.Process)
+	assert.GreaterOrEqual(t, slowest.Duration, 50*time.Millisecond)
+	assert.GreaterOrEqual(t, report.Duration, slowest.Duration)
+}
@@ -0,0 +1,46 @@
+package network
+
+import "github.com/elleshadow/noPromises/pkg/core/ports"
+
+// PortStats is a snapshot of one port of a process in a network
+type PortStats struct {
+	Process     string
+	Port        string
+	Type        ports.PortType
+	Connections int
+	// Queued counts the packets buffered in the port's channels. A channel
+	// is counted by both the output and the input port it joins.
+	Queued  int
+	Dropped uint64
+}
+
+// PortStats snapshots every measurable port of the network's processes,
+// in the order the processes were added
+func (n *Network) PortStats() []PortStats {
+	n.mu.RLock()
+	processes := n.orderedProcesses()
+	n.mu.RUnlock()
+
+	var stats []PortStats
+	for _, p := range processes {
+		lister, ok := p.(portLister)
+		if !ok {
+			continue
+		}
+		for _, port := range lister.Ports() {
+			measurable, ok := port.(ports.Measurable)
+			if !ok {
+				continue
+			}
+			stats = append(stats, PortStats{
+				Process:     p.Name(),
+				Port:        port.Name(),
+				Type:        port.Type(),
+				Connections: measurable.Connections(),
+				Queued:      measurable.Len(),
+				Dropped:     measurable.Dropped(),
+			})
+		}
+	}
+	return stats
+}
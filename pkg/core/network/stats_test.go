@@ -0,0 +1,35 @@
+package network
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortStats(t *testing.T) {
+	n := New()
+	source := newPortProcess("source")
+	sink := newPortProcess("sink")
+	n.AddProcess(source)
+	n.AddProcess(sink)
+
+	ch := make(chan *ip.IP[string], 4)
+	require.NoError(t, ports.Connect(source.out, ch))
+	require.NoError(t, ports.Connect(sink.in, ch))
+
+	ctx := context.Background()
+	require.NoError(t, source.out.Send(ctx, ip.New("a")))
+	require.NoError(t, source.out.Send(ctx, ip.New("b")))
+	require.NoError(t, AddInitial(n, "sink", "in", "first"))
+
+	assert.Equal(t, []PortStats{
+		{Process: "source", Port: "in", Type: ports.TypeInput},
+		{Process: "source", Port: "out", Type: ports.TypeOutput, Connections: 1, Queued: 2},
+		{Process: "sink", Port: "in", Type: ports.TypeInput, Connections: 1, Queued: 3},
+		{Process: "sink", Port: "out", Type: ports.TypeOutput},
+	}, n.PortStats())
+}
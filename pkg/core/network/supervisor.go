@@ -0,0 +1,265 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/process"
+)
+
+// Strategy decides which children a supervisor restarts when one fails
+type Strategy int
+
+const (
+	// OneForOne restarts only the failed child
+	OneForOne Strategy = iota
+	// OneForAll restarts every child when one fails
+	OneForAll
+	// RestForOne restarts the failed child and the children added after it
+	RestForOne
+)
+
+// String returns the strategy name
+func (s Strategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one_for_one"
+	case OneForAll:
+		return "one_for_all"
+	case RestForOne:
+		return "rest_for_one"
+	default:
+		return fmt.Sprintf("Strategy(%d)", int(s))
+	}
+}
+
+const (
+	// DefaultMaxRestarts is how many restarts a supervisor allows per window
+	DefaultMaxRestarts = 3
+	// DefaultRestartWindow is the period over which restarts are counted
+	DefaultRestartWindow = 5 * time.Second
+)
+
+// ErrTooManyRestarts is returned by a supervisor that exceeded its restart
+// limit, escalating the failure to its parent
+var ErrTooManyRestarts = errors.New("too many restarts")
+
+// Supervisor runs a group of child processes and restarts them when they
+// fail, following its Strategy. A child returning nil has finished and is
+// not restarted; a child returning an error is restarted along with the
+// siblings its strategy selects.
+//
+// Supervisors are processes themselves, so they nest into trees. When more
+// than MaxRestarts restarts happen within Window the supervisor stops its
+// children and fails with ErrTooManyRestarts.
+type Supervisor struct {
+	process.BaseProcess
+	Strategy    Strategy
+	MaxRestarts int
+	Window      time.Duration
+
+	children []process.Process
+	restarts []time.Time
+	total    int
+	mu       sync.Mutex
+}
+
+// NewSupervisor creates a new supervisor with the default restart limits
+func NewSupervisor(name string, strategy Strategy) *Supervisor {
+	return &Supervisor{
+		BaseProcess: process.NewBaseProcess(name),
+		Strategy:    strategy,
+		MaxRestarts: DefaultMaxRestarts,
+		Window:      DefaultRestartWindow,
+	}
+}
+
+// Add appends child processes; their order matters for RestForOne
+func (s *Supervisor) Add(children ...process.Process) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.children = append(s.children, children...)
+}
+
+// Children returns the supervised processes in order
+func (s *Supervisor) Children() []process.Process {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]process.Process(nil), s.children...)
+}
+
+// Restarts returns how many times the supervisor has restarted a failed child
+func (s *Supervisor) Restarts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total
+}
+
+// Initialize prepares the supervisor and its children
+func (s *Supervisor) Initialize(ctx context.Context) error {
+	if err := s.BaseProcess.Initialize(ctx); err != nil {
+		return err
+	}
+	for _, child := range s.Children() {
+		if err := child.Initialize(ctx); err != nil {
+			return fmt.Errorf("failed to initialize process %s: %w", child.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Shutdown shuts down the children, then the supervisor
+func (s *Supervisor) Shutdown(ctx context.Context) error {
+	var lastErr error
+	for _, child := range s.Children() {
+		if err := child.Shutdown(ctx); err != nil {
+			lastErr = fmt.Errorf("failed to stop process %s: %w", child.Name(), err)
+		}
+	}
+	if err := s.BaseProcess.Shutdown(ctx); err != nil {
+		return err
+	}
+	return lastErr
+}
+
+// childRun is one running instance of a child process
+type childRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// childExit reports that a child run returned
+type childExit struct {
+	index int
+	run   *childRun
+	err   error
+}
+
+// Process runs the children until all finish, the context is cancelled, or
+// the restart limit is exceeded
+func (s *Supervisor) Process(ctx context.Context) error {
+	children := s.Children()
+	if len(children) == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	exits := make(chan childExit)
+	running := make([]*childRun, len(children))
+
+	start := func(i int) {
+		childCtx, cancel := context.WithCancel(ctx)
+		run := &childRun{cancel: cancel, done: make(chan struct{})}
+		running[i] = run
+		go func() {
+			err := runProcess(childCtx, children[i])
+			close(run.done)
+			select {
+			case exits <- childExit{index: i, run: run, err: err}:
+			case <-childCtx.Done():
+			}
+		}()
+	}
+	stop := func(i int) {
+		if run := running[i]; run != nil {
+			run.cancel()
+			<-run.done
+			running[i] = nil
+		}
+	}
+	stopAll := func() {
+		for i := range running {
+			stop(i)
+		}
+	}
+
+	for i := range children {
+		start(i)
+	}
+	active := len(children)
+
+	for {
+		select {
+		case <-ctx.Done():
+			stopAll()
+			return ctx.Err()
+
+		case exit := <-exits:
+			if running[exit.index] != exit.run {
+				continue // stale exit of a run that was already stopped
+			}
+
+			if exit.err == nil || errors.Is(exit.err, context.Canceled) {
+				exit.run.cancel()
+				running[exit.index] = nil
+				active--
+				if active == 0 {
+					return nil
+				}
+				continue
+			}
+
+			if !s.allowRestart(time.Now()) {
+				stopAll()
+				return fmt.Errorf("%w: process %s failed: %w",
+					ErrTooManyRestarts, children[exit.index].Name(), exit.err)
+			}
+
+			exit.run.cancel()
+			running[exit.index] = nil
+			for _, i := range s.affected(exit.index, len(children)) {
+				if i == exit.index {
+					start(i)
+				} else if running[i] != nil {
+					stop(i)
+					start(i)
+				}
+			}
+		}
+	}
+}
+
+// affected returns the indexes of the children restarted when the child at
+// index fails, in start order
+func (s *Supervisor) affected(index, count int) []int {
+	var from, to int
+	switch s.Strategy {
+	case OneForAll:
+		from, to = 0, count
+	case RestForOne:
+		from, to = index, count
+	default:
+		from, to = index, index+1
+	}
+
+	indexes := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// allowRestart records a restart at now and reports whether it stays
+// within the restart limit
+func (s *Supervisor) allowRestart(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recent := s.restarts[:0]
+	for _, t := range s.restarts {
+		if now.Sub(t) < s.Window {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= s.MaxRestarts {
+		s.restarts = recent
+		return false
+	}
+
+	s.restarts = append(recent, now)
+	s.total++
+	return true
+}
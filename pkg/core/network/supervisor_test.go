@@ -0,0 +1,163 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errChildFailed = errors.New("child failed")
+
+// flakyProcess fails its first failures runs, then runs until cancelled
+type flakyProcess struct {
+	process.BaseProcess
+	failures int32
+	runs     atomic.Int32
+}
+
+func newFlakyProcess(name string, failures int32) *flakyProcess {
+	return &flakyProcess{
+		BaseProcess: process.NewBaseProcess(name),
+		failures:    failures,
+	}
+}
+
+func (p *flakyProcess) Process(ctx context.Context) error {
+	if p.runs.Add(1) <= p.failures {
+		return errChildFailed
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// runSupervisor runs s until the runs of each process reach want
+func runSupervisor(t *testing.T, s *Supervisor, want map[*flakyProcess]int32) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Process(ctx)
+	}()
+
+	for p, runs := range want {
+		assert.Eventually(t, func() bool {
+			return p.runs.Load() >= runs
+		}, time.Second, 5*time.Millisecond, "%s runs", p.Name())
+	}
+	// Give unexpected restarts a chance to happen before checking counts
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+	for p, runs := range want {
+		assert.Equal(t, runs, p.runs.Load(), "%s runs", p.Name())
+	}
+}
+
+func TestSupervisorStrategies(t *testing.T) {
+	t.Run("one for one", func(t *testing.T) {
+		a, b, c := newFlakyProcess("a", 0), newFlakyProcess("b", 1), newFlakyProcess("c", 0)
+		s := NewSupervisor("sup", OneForOne)
+		s.Add(a, b, c)
+
+		runSupervisor(t, s, map[*flakyProcess]int32{a: 1, b: 2, c: 1})
+		assert.Equal(t, 1, s.Restarts())
+	})
+
+	t.Run("one for all", func(t *testing.T) {
+		a, b, c := newFlakyProcess("a", 0), newFlakyProcess("b", 1), newFlakyProcess("c", 0)
+		s := NewSupervisor("sup", OneForAll)
+		s.Add(a, b, c)
+
+		runSupervisor(t, s, map[*flakyProcess]int32{a: 2, b: 2, c: 2})
+	})
+
+	t.Run("rest for one", func(t *testing.T) {
+		a, b, c := newFlakyProcess("a", 0), newFlakyProcess("b", 1), newFlakyProcess("c", 0)
+		s := NewSupervisor("sup", RestForOne)
+		s.Add(a, b, c)
+
+		runSupervisor(t, s, map[*flakyProcess]int32{a: 1, b: 2, c: 2})
+	})
+}
+
+func TestSupervisorRestartLimit(t *testing.T) {
+	failing := newFlakyProcess("failing", 100)
+	s := NewSupervisor("sup", OneForOne)
+	s.MaxRestarts = 2
+	s.Add(failing)
+
+	err := s.Process(context.Background())
+	assert.ErrorIs(t, err, ErrTooManyRestarts)
+	assert.ErrorIs(t, err, errChildFailed)
+	assert.Equal(t, int32(3), failing.runs.Load())
+}
+
+func TestSupervisorTree(t *testing.T) {
+	// The nested supervisor gives up and fails, so the parent restarts it
+	failing := newFlakyProcess("failing", 2)
+	inner := NewSupervisor("inner", OneForOne)
+	inner.MaxRestarts = 1
+	inner.Add(failing)
+
+	sibling := newFlakyProcess("sibling", 0)
+	outer := NewSupervisor("outer", OneForOne)
+	outer.Add(inner, sibling)
+
+	runSupervisor(t, outer, map[*flakyProcess]int32{failing: 3, sibling: 1})
+	assert.Equal(t, 1, outer.Restarts())
+}
+
+func TestSupervisorFinishedChildren(t *testing.T) {
+	s := NewSupervisor("sup", OneForAll)
+	s.Add(newTestProcessFunc("done", func(context.Context) error { return nil }))
+
+	assert.NoError(t, s.Process(context.Background()))
+	assert.Equal(t, 0, s.Restarts())
+}
+
+func TestNetworkSupervise(t *testing.T) {
+	n := New()
+	flaky := newFlakyProcess("flaky", 1)
+	stable := newFlakyProcess("stable", 0)
+	n.AddProcess(flaky)
+	n.AddProcess(stable)
+	root := n.Supervise(OneForOne)
+	require.Same(t, root, n.Supervisor())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- n.Start(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return flaky.runs.Load() == 2
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-errCh)
+	assert.Equal(t, int32(1), stable.runs.Load())
+	assert.Equal(t, 1, root.Restarts())
+}
+
+// funcProcess runs fn as its processing loop
+type funcProcess struct {
+	process.BaseProcess
+	fn func(context.Context) error
+}
+
+func newTestProcessFunc(name string, fn func(context.Context) error) *funcProcess {
+	return &funcProcess{BaseProcess: process.NewBaseProcess(name), fn: fn}
+}
+
+func (p *funcProcess) Process(ctx context.Context) error {
+	return p.fn(ctx)
+}
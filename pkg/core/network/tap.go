@@ -0,0 +1,127 @@
+package network
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTapBuffer is how many packets a Tap holds for a slow reader
+const DefaultTapBuffer = 64
+
+// TapOptions selects the packets a Tap passes on
+type TapOptions struct {
+	// SampleEvery passes one packet in every SampleEvery; 0 passes all
+	SampleEvery int
+	// MaxPerSecond caps how many packets pass each second; 0 means no cap
+	MaxPerSecond int
+	// Buffer is how many packets may wait for the reader before new ones
+	// are dropped; 0 means DefaultTapBuffer
+	Buffer int
+}
+
+// Tap is a temporary observer of an edge for live debugging. It never
+// blocks the flow: packets beyond the sampling, rate or buffer limits are
+// skipped. Close removes it.
+type Tap struct {
+	edge        string
+	opts        TapOptions
+	packets     chan any
+	remove      func()
+	seen        uint64
+	windowStart time.Time
+	windowCount int
+	closed      bool
+	dropped     atomic.Uint64
+	mu          sync.Mutex
+}
+
+// Tap starts observing the packets sent on edge, named "process.port"
+// after the sending output port, until the tap is closed
+func (n *Network) Tap(edge string, opts TapOptions) (*Tap, error) {
+	port, err := n.edgePort(edge)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := opts.Buffer
+	if buffer <= 0 {
+		buffer = DefaultTapBuffer
+	}
+	t := &Tap{
+		edge:    edge,
+		opts:    opts,
+		packets: make(chan any, buffer),
+	}
+	remove := port.Observe(t.observe)
+	n.taps.Add(1)
+	t.remove = func() {
+		remove()
+		n.taps.Add(-1)
+	}
+	return t, nil
+}
+
+// observe runs on the sender's goroutine, so it only filters and queues
+func (t *Tap) observe(packet any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+
+	t.seen++
+	if t.opts.SampleEvery > 1 && (t.seen-1)%uint64(t.opts.SampleEvery) != 0 {
+		return
+	}
+	if t.opts.MaxPerSecond > 0 {
+		now := time.Now()
+		if now.Sub(t.windowStart) >= time.Second {
+			t.windowStart = now
+			t.windowCount = 0
+		}
+		if t.windowCount >= t.opts.MaxPerSecond {
+			t.dropped.Add(1)
+			return
+		}
+		t.windowCount++
+	}
+
+	select {
+	case t.packets <- packet:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+// Edge returns the tapped edge
+func (t *Tap) Edge() string {
+	return t.edge
+}
+
+// Packets returns the tapped packets. It is closed by Close.
+func (t *Tap) Packets() <-chan any {
+	return t.packets
+}
+
+// Dropped returns how many sampled packets were skipped because of the
+// rate limit or a full buffer
+func (t *Tap) Dropped() uint64 {
+	return t.dropped.Load()
+}
+
+// Close removes the tap from its edge and closes Packets
+func (t *Tap) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.closed {
+		t.closed = true
+		t.remove()
+		close(t.packets)
+	}
+}
+
+// ActiveTaps returns how many taps are open on the network
+func (n *Network) ActiveTaps() int {
+	return int(n.taps.Load())
+}
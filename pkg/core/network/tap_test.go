@@ -0,0 +1,55 @@
+package network
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTap(t *testing.T) {
+	n := New()
+	src := newPortProcess("src")
+	n.AddProcess(src)
+
+	ch := make(chan *ip.IP[string], 100)
+	require.NoError(t, ports.Connect(src.out, ch))
+
+	_, err := n.Tap("src.missing", TapOptions{})
+	assert.ErrorIs(t, err, ErrUnknownEdge)
+
+	all, err := n.Tap("src.out", TapOptions{})
+	require.NoError(t, err)
+	sampled, err := n.Tap("src.out", TapOptions{SampleEvery: 3})
+	require.NoError(t, err)
+	limited, err := n.Tap("src.out", TapOptions{MaxPerSecond: 2})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 6; i++ {
+		require.NoError(t, src.out.Send(ctx, ip.New(strconv.Itoa(i))))
+	}
+
+	// Closing removes the tap; later packets still flow but are not seen
+	for _, tap := range []*Tap{all, sampled, limited} {
+		tap.Close()
+	}
+	require.NoError(t, src.out.Send(ctx, ip.New("6")))
+	assert.Len(t, ch, 7)
+
+	data := func(tap *Tap) []string {
+		var values []string
+		for packet := range tap.Packets() {
+			values = append(values, packet.(*ip.IP[string]).Data())
+		}
+		return values
+	}
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5"}, data(all))
+	assert.Equal(t, []string{"0", "3"}, data(sampled))
+	assert.Equal(t, []string{"0", "1"}, data(limited))
+	assert.Equal(t, uint64(4), limited.Dropped())
+}
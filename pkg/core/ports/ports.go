@@ -2,9 +2,11 @@ package ports
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 
 	"github.com/elleshadow/noPromises/pkg/core/ip"
 )
@@ -16,6 +18,105 @@ const (
 	TypeOutput
 )
 
+// Connection limits for SetMaxConnections
+const (
+	// UnlimitedConnections allows any number of connections (the default)
+	UnlimitedConnections = 0
+	// SingleConnection allows exactly one connection
+	SingleConnection = 1
+)
+
+var (
+	// ErrMaxConnections is returned by Connect when the port is full
+	ErrMaxConnections = errors.New("maximum connections reached")
+	// ErrInvalidMaxConnections is returned for a negative limit or one below
+	// the number of existing connections
+	ErrInvalidMaxConnections = errors.New("invalid maximum connections")
+	// ErrAlreadyConnected is returned by Connect for a channel the port already uses
+	ErrAlreadyConnected = errors.New("channel already connected")
+	// ErrStreamClosed is returned by Receive once every connected channel
+	// is closed. It marks the normal end of the input stream, not a failure.
+	ErrStreamClosed = errors.New("stream closed")
+	// ErrOwnershipViolation is returned by Send on a port enforcing
+	// ownership when the packet is owned by another process
+	ErrOwnershipViolation = errors.New("ownership violation")
+)
+
+// PartialSendError is returned by Send when a broadcast failed after
+// reaching some of the port's connections, so the packet was delivered
+// downstream only in part. It wraps the error that stopped the send.
+type PartialSendError struct {
+	// Delivered lists the indexes, in connection order, of the
+	// connections that received the packet
+	Delivered []int
+	// Connections is the number of connections the packet was sent to
+	Connections int
+	Err         error
+}
+
+func (e *PartialSendError) Error() string {
+	return fmt.Sprintf("packet delivered to %d of %d connections: %v", len(e.Delivered), e.Connections, e.Err)
+}
+
+func (e *PartialSendError) Unwrap() error {
+	return e.Err
+}
+
+// SendMode controls how a port distributes packets across its connections
+type SendMode int
+
+const (
+	// SendModeBroadcast delivers every packet to every connection
+	SendModeBroadcast SendMode = iota
+	// SendModeRoundRobin delivers each packet to one connection in turn,
+	// in proportion to the configured send weights
+	SendModeRoundRobin
+)
+
+// OverflowPolicy controls what Send does when a connection's buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the buffer (the default)
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the packet being sent
+	OverflowDropNewest
+	// OverflowDropOldest evicts the oldest buffered packet to make room
+	OverflowDropOldest
+)
+
+// AnyPort is implemented by ports of every element type, letting ports be
+// handled by name without knowing their type
+type AnyPort interface {
+	Name() string
+	Description() string
+	Required() bool
+	Type() PortType
+	Connected() bool
+}
+
+// OwnershipEnforcer is implemented by ports that can enforce packet
+// ownership on behalf of the process they belong to
+type OwnershipEnforcer interface {
+	AnyPort
+	EnforceOwnership(process string)
+}
+
+// Measurable is implemented by ports that report their connections and
+// the packets queued in them
+type Measurable interface {
+	AnyPort
+	Connections() int
+	Len() int
+	Dropped() uint64
+}
+
+// Observable is implemented by ports that report the packets they send
+type Observable interface {
+	AnyPort
+	Observe(fn func(packet any)) (remove func())
+}
+
 type Port[T any] struct {
 	name           string
 	description    string
@@ -23,9 +124,25 @@ type Port[T any] struct {
 	portType       PortType
 	channels       []chan *ip.IP[T]
 	maxConnections int
+	sendMode       SendMode
+	weights        []int // aligned with channels
+	currentWeights []int // smooth weighted round-robin state
+	initial        []*ip.IP[T]
+	overflow       OverflowPolicy
+	dropped        atomic.Uint64
+	observers      []observer
+	lastObserver   uint64
+	process        string // owning process when enforcing ownership
+	closed         map[chan *ip.IP[T]]bool
 	mu             sync.RWMutex
 }
 
+// observer is a callback registered with Observe
+type observer struct {
+	id uint64
+	fn func(packet any)
+}
+
 func NewInput[T any](name, description string, required bool) *Port[T] {
 	return &Port[T]{
 		name:        name,
@@ -62,10 +179,168 @@ func (p *Port[T]) Type() PortType {
 	return p.portType
 }
 
-func (p *Port[T]) SetMaxConnections(max int) {
+// Connections returns the number of channels connected to the port
+func (p *Port[T]) Connections() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.channels)
+}
+
+// Len returns how many packets are queued in the port's channels, plus
+// any initial packets not yet received. Unbuffered channels never queue.
+func (p *Port[T]) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	n := len(p.initial)
+	for _, ch := range p.channels {
+		n += len(ch)
+	}
+	return n
+}
+
+// Connected reports whether the port has a connected channel or, for an
+// input port, queued initial packets to receive
+func (p *Port[T]) Connected() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.channels) > 0 || len(p.initial) > 0
+}
+
+// SetMaxConnections limits how many channels may be connected to the port.
+// Use UnlimitedConnections (0) for no limit or SingleConnection for exactly
+// one. Negative limits, and limits below the current number of
+// connections, are rejected.
+func (p *Port[T]) SetMaxConnections(max int) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+
+	if max < 0 {
+		return fmt.Errorf("%w: %d is negative", ErrInvalidMaxConnections, max)
+	}
+	if max != UnlimitedConnections && max < len(p.channels) {
+		return fmt.Errorf("%w: %d is below the %d existing connections",
+			ErrInvalidMaxConnections, max, len(p.channels))
+	}
 	p.maxConnections = max
+	return nil
+}
+
+// MaxConnections returns the connection limit, UnlimitedConnections meaning none
+func (p *Port[T]) MaxConnections() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.maxConnections
+}
+
+// SetSendMode sets how Send distributes packets across connections
+func (p *Port[T]) SetSendMode(mode SendMode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sendMode = mode
+}
+
+// SendMode returns the port's send mode
+func (p *Port[T]) SendMode() SendMode {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.sendMode
+}
+
+// SetOverflowPolicy sets what Send does when a connection's buffer is full.
+// Packets discarded by the drop policies are counted by Dropped.
+func (p *Port[T]) SetOverflowPolicy(policy OverflowPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.overflow = policy
+}
+
+// OverflowPolicy returns the port's overflow policy
+func (p *Port[T]) OverflowPolicy() OverflowPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.overflow
+}
+
+// Dropped returns how many packets the overflow policy has discarded
+func (p *Port[T]) Dropped() uint64 {
+	return p.dropped.Load()
+}
+
+// EnforceOwnership makes the port enforce the FBP rule that only the
+// owner of a packet may use it: Receive makes process the owner of each
+// packet, and Send refuses packets owned by any other process with
+// ErrOwnershipViolation. Unowned packets, such as ones the process just
+// created, may always be sent. An empty process turns enforcement off.
+func (p *Port[T]) EnforceOwnership(process string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.process = process
+}
+
+// Observe registers fn to be called with each packet (an *ip.IP[T]) sent
+// from the port, before it is delivered. fn runs on the sender's goroutine,
+// so it must return quickly. Calling remove unregisters fn.
+func (p *Port[T]) Observe(fn func(packet any)) (remove func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastObserver++
+	id := p.lastObserver
+	// Copy on write, so Send can range over a snapshot without the lock
+	p.observers = append(p.observers[:len(p.observers):len(p.observers)], observer{id: id, fn: fn})
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		kept := make([]observer, 0, len(p.observers))
+		for _, o := range p.observers {
+			if o.id != id {
+				kept = append(kept, o)
+			}
+		}
+		p.observers = kept
+	}
+}
+
+// SetSendWeights sets the round-robin weight of each connection, in
+// connection order. Connections added later get a weight of 1.
+func (p *Port[T]) SetSendWeights(weights []int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(weights) != len(p.channels) {
+		return fmt.Errorf("got %d weights for %d connections", len(weights), len(p.channels))
+	}
+	for i, w := range weights {
+		if w <= 0 {
+			return fmt.Errorf("weight %d for connection %d must be positive", w, i)
+		}
+	}
+
+	p.weights = append([]int(nil), weights...)
+	p.currentWeights = make([]int, len(weights))
+	return nil
+}
+
+// nextChannel picks the next round-robin target using smooth weighted
+// round-robin, which interleaves heavier connections evenly
+func (p *Port[T]) nextChannel() chan *ip.IP[T] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.channels) == 0 {
+		return nil
+	}
+
+	total, best := 0, 0
+	for i, w := range p.weights {
+		p.currentWeights[i] += w
+		total += w
+		if p.currentWeights[i] > p.currentWeights[best] {
+			best = i
+		}
+	}
+	p.currentWeights[best] -= total
+	return p.channels[best]
 }
 
 func Connect[T any](port *Port[T], ch chan *ip.IP[T]) error {
@@ -79,65 +354,248 @@ func Connect[T any](port *Port[T], ch chan *ip.IP[T]) error {
 	port.mu.Lock()
 	defer port.mu.Unlock()
 
-	if port.maxConnections > 0 && len(port.channels) >= port.maxConnections {
-		return fmt.Errorf("maximum connections reached")
+	for _, existing := range port.channels {
+		if existing == ch {
+			return fmt.Errorf("%w: port %s", ErrAlreadyConnected, port.name)
+		}
+	}
+
+	if port.maxConnections != UnlimitedConnections && len(port.channels) >= port.maxConnections {
+		return fmt.Errorf("%w: port %s allows %d", ErrMaxConnections, port.name, port.maxConnections)
 	}
 
 	port.channels = append(port.channels, ch)
+	port.weights = append(port.weights, 1)
+	port.currentWeights = append(port.currentWeights, 0)
 	return nil
 }
 
+// Send delivers packet according to the port's send mode.
+//
+// A broadcast delivers to the connections one at a time, so it can fail
+// part way, for example when ctx is canceled while a slow connection's
+// buffer is full. Packets already delivered cannot be taken back; Send
+// then returns a *PartialSendError naming the connections that received
+// the packet, so the caller can reconcile them.
 func (p *Port[T]) Send(ctx context.Context, packet *ip.IP[T]) error {
+	p.mu.RLock()
+	policy := p.overflow
+	observers := p.observers
+	process := p.process
+	p.mu.RUnlock()
+
+	if process != "" {
+		if owner := packet.Owner(); owner != "" && owner != process {
+			return fmt.Errorf("%w: %s sent packet %s owned by %s", ErrOwnershipViolation, process, packet.ID(), owner)
+		}
+	}
+	for _, o := range observers {
+		o.fn(packet)
+	}
+
+	if p.SendMode() == SendModeRoundRobin {
+		ch := p.nextChannel()
+		if ch == nil {
+			return nil
+		}
+		_, err := p.deliver(ctx, ch, packet, policy)
+		return err
+	}
+
 	p.mu.RLock()
 	channels := make([]chan *ip.IP[T], len(p.channels))
 	copy(channels, p.channels)
 	p.mu.RUnlock()
 
-	for _, ch := range channels {
+	var delivered []int
+	for i, ch := range channels {
+		ok, err := p.deliver(ctx, ch, packet, policy)
+		if err != nil {
+			if len(delivered) == 0 {
+				return err
+			}
+			return &PartialSendError{Delivered: delivered, Connections: len(channels), Err: err}
+		}
+		if ok {
+			delivered = append(delivered, i)
+		}
+	}
+	return nil
+}
+
+// SendBatch sends packets in order, stopping at the first failure. It
+// returns how many packets were sent in full, so after a cancellation
+// packets[sent:] are known not to have been sent, while packets[sent] may
+// have reached some connections of a broadcast port but not all, in which
+// case err is a *PartialSendError.
+func (p *Port[T]) SendBatch(ctx context.Context, packets []*ip.IP[T]) (sent int, err error) {
+	for i, packet := range packets {
+		if err := p.Send(ctx, packet); err != nil {
+			return i, err
+		}
+	}
+	return len(packets), nil
+}
+
+// deliver sends packet on ch, applying the overflow policy when ch is
+// full. It reports whether ch received the packet rather than the policy
+// dropping it.
+func (p *Port[T]) deliver(ctx context.Context, ch chan *ip.IP[T], packet *ip.IP[T], policy OverflowPolicy) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	switch policy {
+	case OverflowDropNewest:
+		select {
+		case ch <- packet:
+			return true, nil
+		default:
+			p.dropped.Add(1)
+			return false, nil
+		}
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case ch <- packet:
+				return true, nil
+			default:
+			}
+			select {
+			case <-ch:
+				p.dropped.Add(1)
+			default:
+				// Nothing buffered to evict (e.g. an unbuffered channel
+				// without a reader), so the new packet is dropped instead
+				p.dropped.Add(1)
+				return false, nil
+			}
+		}
+
+	default:
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return false, ctx.Err()
 		case ch <- packet:
+			return true, nil
 		}
 	}
-	return nil
 }
 
+// AddInitial queues an initial information packet (IIP) carrying data.
+// Receive returns queued IIPs, once each and in order, before any packet
+// from the port's connections.
+func (p *Port[T]) AddInitial(data T) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.initial = append(p.initial, ip.NewIIP(data))
+}
+
+// Receive returns the next packet from the port's initial packets or any
+// of its connections. On a fan-in port a closed connection is dropped
+// from the set and the others are still read, so ErrStreamClosed is only
+// returned once every connected channel is closed and drained.
 func (p *Port[T]) Receive(ctx context.Context) (*ip.IP[T], error) {
-	p.mu.RLock()
-	channels := make([]chan *ip.IP[T], len(p.channels))
-	copy(channels, p.channels)
-	p.mu.RUnlock()
+	var (
+		value   reflect.Value
+		process string
+	)
+	for {
+		p.mu.Lock()
+		if len(p.initial) > 0 {
+			packet := p.initial[0]
+			p.initial = p.initial[1:]
+			p.mu.Unlock()
+			return packet, nil
+		}
+		if len(p.channels) == 0 {
+			p.mu.Unlock()
+			return nil, fmt.Errorf("no channels connected")
+		}
+		channels := make([]chan *ip.IP[T], 0, len(p.channels))
+		for _, ch := range p.channels {
+			if !p.closed[ch] {
+				channels = append(channels, ch)
+			}
+		}
+		process = p.process
+		p.mu.Unlock()
 
-	if len(channels) == 0 {
-		return nil, fmt.Errorf("no channels connected")
-	}
+		if len(channels) == 0 {
+			return nil, ErrStreamClosed
+		}
 
-	// Create cases for select
-	cases := make([]reflect.SelectCase, len(channels)+1)
-	cases[0] = reflect.SelectCase{
-		Dir:  reflect.SelectRecv,
-		Chan: reflect.ValueOf(ctx.Done()),
-	}
-	for i, ch := range channels {
-		cases[i+1] = reflect.SelectCase{
+		// Create cases for select
+		cases := make([]reflect.SelectCase, len(channels)+1)
+		cases[0] = reflect.SelectCase{
 			Dir:  reflect.SelectRecv,
-			Chan: reflect.ValueOf(ch),
+			Chan: reflect.ValueOf(ctx.Done()),
+		}
+		for i, ch := range channels {
+			cases[i+1] = reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(ch),
+			}
 		}
-	}
 
-	// Wait for data or context cancellation
-	chosen, value, ok := reflect.Select(cases)
-	if chosen == 0 { // Context done
-		return nil, ctx.Err()
-	}
-	if !ok {
-		return nil, fmt.Errorf("channel closed")
+		// Wait for data or context cancellation
+		chosen, received, ok := reflect.Select(cases)
+		if chosen == 0 { // Context done
+			return nil, ctx.Err()
+		}
+		if ok {
+			value = received
+			break
+		}
+
+		// A closed channel has nothing left to deliver; keep reading the
+		// others
+		p.mu.Lock()
+		if p.closed == nil {
+			p.closed = make(map[chan *ip.IP[T]]bool)
+		}
+		p.closed[channels[chosen-1]] = true
+		p.mu.Unlock()
 	}
 
 	packet, ok := value.Interface().(*ip.IP[T])
 	if !ok {
 		return nil, fmt.Errorf("invalid packet type")
 	}
+	if process != "" && !packet.IsImmutable() {
+		_ = packet.SetOwner(process)
+	}
 	return packet, nil
 }
+
+// Drain removes and returns every packet currently buffered in the port's
+// channels without blocking. It is only meaningful for buffered channels:
+// an unbuffered channel holds nothing to drain, and packets sent while
+// Drain runs may or may not be included.
+func (p *Port[T]) Drain(ctx context.Context) []*ip.IP[T] {
+	p.mu.RLock()
+	channels := make([]chan *ip.IP[T], len(p.channels))
+	copy(channels, p.channels)
+	p.mu.RUnlock()
+
+	var drained []*ip.IP[T]
+	for _, ch := range channels {
+	drain:
+		for {
+			if ctx.Err() != nil {
+				return drained
+			}
+			select {
+			case packet, ok := <-ch:
+				if !ok {
+					break drain
+				}
+				drained = append(drained, packet)
+			default:
+				break drain
+			}
+		}
+	}
+	return drained
+}
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -32,7 +33,7 @@ func TestPort(t *testing.T) {
 	t.Run("connection limits", func(t *testing.T) {
 		t.Run("input port limits", func(t *testing.T) {
 			port := NewInput[string]("test", "Test port", true)
-			port.SetMaxConnections(2)
+			require.NoError(t, port.SetMaxConnections(2))
 
 			ch1 := make(chan *ip.IP[string])
 			ch2 := make(chan *ip.IP[string])
@@ -51,7 +52,7 @@ func TestPort(t *testing.T) {
 		t.Run("output port limits", func(t *testing.T) {
 
 			port := NewOutput[string]("test", "Test port", true)
-			port.SetMaxConnections(1)
+			require.NoError(t, port.SetMaxConnections(SingleConnection))
 
 			ch1 := make(chan *ip.IP[string])
 
@@ -61,7 +62,34 @@ func TestPort(t *testing.T) {
 			require.NoError(t, err1)
 
 			err2 := Connect(port, ch2)
-			assert.Error(t, err2)
+			assert.ErrorIs(t, err2, ErrMaxConnections)
+		})
+
+		t.Run("unlimited", func(t *testing.T) {
+			port := NewInput[string]("test", "Test port", true)
+			assert.Equal(t, UnlimitedConnections, port.MaxConnections())
+
+			for i := 0; i < 100; i++ {
+				require.NoError(t, Connect(port, make(chan *ip.IP[string])))
+			}
+
+			require.NoError(t, port.SetMaxConnections(UnlimitedConnections))
+			assert.NoError(t, Connect(port, make(chan *ip.IP[string])))
+		})
+
+		t.Run("invalid limits", func(t *testing.T) {
+			port := NewOutput[string]("test", "Test port", true)
+
+			assert.ErrorIs(t, port.SetMaxConnections(-1), ErrInvalidMaxConnections)
+			assert.Equal(t, UnlimitedConnections, port.MaxConnections(), "rejected limit must not apply")
+
+			require.NoError(t, Connect(port, make(chan *ip.IP[string])))
+			require.NoError(t, Connect(port, make(chan *ip.IP[string])))
+			assert.ErrorIs(t, port.SetMaxConnections(SingleConnection), ErrInvalidMaxConnections)
+
+			require.NoError(t, port.SetMaxConnections(2))
+			assert.Equal(t, 2, port.MaxConnections())
+			assert.ErrorIs(t, Connect(port, make(chan *ip.IP[string])), ErrMaxConnections)
 		})
 	})
 
@@ -121,3 +149,420 @@ func TestPort(t *testing.T) {
 		})
 	})
 }
+
+func TestSendModes(t *testing.T) {
+	newPort := func(n int) (*Port[int], []chan *ip.IP[int]) {
+		port := NewOutput[int]("out", "Output port", true)
+		channels := make([]chan *ip.IP[int], n)
+		for i := range channels {
+			channels[i] = make(chan *ip.IP[int], 400)
+			require.NoError(t, Connect(port, channels[i]))
+		}
+		return port, channels
+	}
+
+	t.Run("broadcast by default", func(t *testing.T) {
+		port, channels := newPort(2)
+		assert.Equal(t, SendModeBroadcast, port.SendMode())
+
+		require.NoError(t, port.Send(context.Background(), ip.New(1)))
+		assert.Len(t, channels[0], 1)
+		assert.Len(t, channels[1], 1)
+	})
+
+	t.Run("round robin", func(t *testing.T) {
+		port, channels := newPort(2)
+		port.SetSendMode(SendModeRoundRobin)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, port.Send(context.Background(), ip.New(i)))
+		}
+		assert.Len(t, channels[0], 5)
+		assert.Len(t, channels[1], 5)
+	})
+
+	t.Run("weighted round robin", func(t *testing.T) {
+		port, channels := newPort(2)
+		port.SetSendMode(SendModeRoundRobin)
+		require.NoError(t, port.SetSendWeights([]int{3, 1}))
+
+		for i := 0; i < 400; i++ {
+			require.NoError(t, port.Send(context.Background(), ip.New(i)))
+		}
+		assert.Len(t, channels[0], 300)
+		assert.Len(t, channels[1], 100)
+	})
+
+	t.Run("weights follow new connections", func(t *testing.T) {
+		port, channels := newPort(2)
+		port.SetSendMode(SendModeRoundRobin)
+		require.NoError(t, port.SetSendWeights([]int{2, 1}))
+
+		extra := make(chan *ip.IP[int], 400)
+		require.NoError(t, Connect(port, extra))
+
+		for i := 0; i < 400; i++ {
+			require.NoError(t, port.Send(context.Background(), ip.New(i)))
+		}
+		assert.Len(t, channels[0], 200)
+		assert.Len(t, channels[1], 100)
+		assert.Len(t, extra, 100)
+	})
+
+	t.Run("invalid weights", func(t *testing.T) {
+		port, _ := newPort(2)
+		assert.Error(t, port.SetSendWeights([]int{1}))
+		assert.Error(t, port.SetSendWeights([]int{1, 0}))
+	})
+}
+
+func TestDrain(t *testing.T) {
+	t.Run("buffered channels", func(t *testing.T) {
+		port := NewInput[int]("in", "Input port", true)
+		ch1 := make(chan *ip.IP[int], 3)
+		ch2 := make(chan *ip.IP[int], 2)
+		require.NoError(t, Connect(port, ch1))
+		require.NoError(t, Connect(port, ch2))
+
+		for i := 0; i < 3; i++ {
+			ch1 <- ip.New(i)
+		}
+		ch2 <- ip.New(10)
+
+		drained := port.Drain(context.Background())
+		require.Len(t, drained, 4)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, i, drained[i].Data())
+		}
+		assert.Equal(t, 10, drained[3].Data())
+		assert.Empty(t, ch1)
+		assert.Empty(t, ch2)
+	})
+
+	t.Run("empty and unbuffered", func(t *testing.T) {
+		port := NewInput[int]("in", "Input port", true)
+		require.NoError(t, Connect(port, make(chan *ip.IP[int])))
+		assert.Empty(t, port.Drain(context.Background()))
+	})
+
+	t.Run("closed channel", func(t *testing.T) {
+		port := NewInput[int]("in", "Input port", true)
+		ch := make(chan *ip.IP[int], 2)
+		require.NoError(t, Connect(port, ch))
+		ch <- ip.New(1)
+		close(ch)
+
+		drained := port.Drain(context.Background())
+		require.Len(t, drained, 1)
+	})
+}
+
+func TestInitialPackets(t *testing.T) {
+	port := NewInput[string]("config", "Config port", true)
+	ch := make(chan *ip.IP[string], 1)
+	require.NoError(t, Connect(port, ch))
+
+	ch <- ip.New("regular")
+	port.AddInitial("first")
+	port.AddInitial("second")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for _, want := range []string{"first", "second"} {
+		packet, err := port.Receive(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, packet.Data())
+		assert.Equal(t, ip.TypeInitial, packet.Type())
+	}
+
+	packet, err := port.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "regular", packet.Data())
+	assert.Equal(t, ip.TypeNormal, packet.Type())
+}
+
+func TestOverflowPolicy(t *testing.T) {
+	newFullPort := func(policy OverflowPolicy) (*Port[int], chan *ip.IP[int]) {
+		port := NewOutput[int]("out", "Output port", true)
+		ch := make(chan *ip.IP[int], 2)
+		require.NoError(t, Connect(port, ch))
+		port.SetOverflowPolicy(policy)
+		for i := 1; i <= 2; i++ {
+			require.NoError(t, port.Send(context.Background(), ip.New(i)))
+		}
+		return port, ch
+	}
+	buffered := func(ch chan *ip.IP[int]) []int {
+		var data []int
+		for len(ch) > 0 {
+			data = append(data, (<-ch).Data())
+		}
+		return data
+	}
+
+	t.Run("block", func(t *testing.T) {
+		port, ch := newFullPort(OverflowBlock)
+		assert.Equal(t, OverflowBlock, port.OverflowPolicy())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, port.Send(ctx, ip.New(3)))
+		assert.Equal(t, uint64(0), port.Dropped())
+		assert.Equal(t, []int{1, 2}, buffered(ch))
+	})
+
+	t.Run("drop newest", func(t *testing.T) {
+		port, ch := newFullPort(OverflowDropNewest)
+
+		require.NoError(t, port.Send(context.Background(), ip.New(3)))
+		require.NoError(t, port.Send(context.Background(), ip.New(4)))
+		assert.Equal(t, uint64(2), port.Dropped())
+		assert.Equal(t, []int{1, 2}, buffered(ch))
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		port, ch := newFullPort(OverflowDropOldest)
+
+		require.NoError(t, port.Send(context.Background(), ip.New(3)))
+		require.NoError(t, port.Send(context.Background(), ip.New(4)))
+		assert.Equal(t, uint64(2), port.Dropped())
+		assert.Equal(t, []int{3, 4}, buffered(ch))
+	})
+
+	t.Run("drop oldest unbuffered", func(t *testing.T) {
+		port := NewOutput[int]("out", "Output port", true)
+		require.NoError(t, Connect(port, make(chan *ip.IP[int])))
+		port.SetOverflowPolicy(OverflowDropOldest)
+
+		require.NoError(t, port.Send(context.Background(), ip.New(1)))
+		assert.Equal(t, uint64(1), port.Dropped())
+	})
+}
+
+func TestConnected(t *testing.T) {
+	port := NewInput[int]("in", "Input port", true)
+	assert.False(t, port.Connected())
+	assert.Equal(t, 0, port.Connections())
+
+	port.AddInitial(1)
+	assert.True(t, port.Connected())
+
+	other := NewInput[int]("in", "Input port", true)
+	require.NoError(t, Connect(other, make(chan *ip.IP[int])))
+	assert.True(t, other.Connected())
+	assert.Equal(t, 1, other.Connections())
+}
+
+func TestSendBatch(t *testing.T) {
+	batch := func() []*ip.IP[string] {
+		return []*ip.IP[string]{ip.New("a"), ip.New("b"), ip.New("c")}
+	}
+
+	t.Run("full delivery", func(t *testing.T) {
+		port := NewOutput[string]("out", "Output port", true)
+		ch := make(chan *ip.IP[string], 3)
+		require.NoError(t, Connect(port, ch))
+
+		sent, err := port.SendBatch(context.Background(), batch())
+		require.NoError(t, err)
+		assert.Equal(t, 3, sent)
+		assert.Len(t, ch, 3)
+	})
+
+	t.Run("cancelled after the second packet", func(t *testing.T) {
+		port := NewOutput[string]("out", "Output port", true)
+		ch := make(chan *ip.IP[string])
+		require.NoError(t, Connect(port, ch))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		received := make(chan []string, 1)
+		go func() {
+			var data []string
+			for i := 0; i < 2; i++ {
+				data = append(data, (<-ch).Data())
+			}
+			cancel()
+			received <- data
+		}()
+
+		sent, err := port.SendBatch(ctx, batch())
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, 2, sent)
+		assert.Equal(t, []string{"a", "b"}, <-received)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		port := NewOutput[string]("out", "Output port", true)
+		sent, err := port.SendBatch(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, sent)
+	})
+}
+
+func TestDuplicateConnection(t *testing.T) {
+	port := NewOutput[int]("out", "Output port", true)
+	ch := make(chan *ip.IP[int], 2)
+
+	require.NoError(t, Connect(port, ch))
+	assert.ErrorIs(t, Connect(port, ch), ErrAlreadyConnected)
+	assert.Equal(t, 1, port.Connections())
+
+	require.NoError(t, port.Send(context.Background(), ip.New(1)))
+	assert.Len(t, ch, 1, "broadcast should deliver once per consumer")
+}
+
+func TestReceiveClosedStream(t *testing.T) {
+	port := NewInput[int]("in", "Input port", true)
+	ch := make(chan *ip.IP[int], 1)
+	require.NoError(t, Connect(port, ch))
+
+	ch <- ip.New(1)
+	close(ch)
+
+	// Buffered packets are still delivered before the end of the stream
+	packet, err := port.Receive(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, packet.Data())
+
+	_, err = port.Receive(context.Background())
+	assert.ErrorIs(t, err, ErrStreamClosed)
+}
+
+func TestReceiveFanInClosedUpstream(t *testing.T) {
+	port := NewInput[int]("in", "Input", true)
+	closed := make(chan *ip.IP[int])
+	open := make(chan *ip.IP[int], 10)
+	require.NoError(t, Connect(port, closed))
+	require.NoError(t, Connect(port, open))
+
+	close(closed)
+	for i := 0; i < 5; i++ {
+		open <- ip.New(i)
+	}
+
+	// The closed upstream ends its own stream, not the port's
+	ctx := context.Background()
+	var got []int
+	for i := 0; i < 5; i++ {
+		packet, err := port.Receive(ctx)
+		require.NoError(t, err)
+		got = append(got, packet.Data())
+	}
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, got)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err := port.Receive(timeoutCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the open upstream may still send")
+
+	open <- ip.New(5)
+	close(open)
+	packet, err := port.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, packet.Data())
+	_, err = port.Receive(ctx)
+	assert.ErrorIs(t, err, ErrStreamClosed)
+}
+
+func TestObserve(t *testing.T) {
+	port := NewOutput[string]("out", "Output", true)
+	ch := make(chan *ip.IP[string], 10)
+	require.NoError(t, Connect(port, ch))
+
+	var first, second []string
+	removeFirst := port.Observe(func(packet any) {
+		first = append(first, packet.(*ip.IP[string]).Data())
+	})
+	port.Observe(func(packet any) {
+		second = append(second, packet.(*ip.IP[string]).Data())
+	})
+
+	ctx := context.Background()
+	require.NoError(t, port.Send(ctx, ip.New("a")))
+	removeFirst()
+	require.NoError(t, port.Send(ctx, ip.New("b")))
+
+	assert.Equal(t, []string{"a"}, first)
+	assert.Equal(t, []string{"a", "b"}, second)
+	assert.Len(t, ch, 2)
+}
+
+func TestLen(t *testing.T) {
+	port := NewInput[int]("in", "Input", true)
+	first := make(chan *ip.IP[int], 5)
+	second := make(chan *ip.IP[int], 5)
+	require.NoError(t, Connect(port, first))
+	require.NoError(t, Connect(port, second))
+	assert.Equal(t, 0, port.Len())
+
+	first <- ip.New(1)
+	first <- ip.New(2)
+	second <- ip.New(3)
+	port.AddInitial(0)
+	assert.Equal(t, 4, port.Len())
+
+	_, err := port.Receive(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, port.Len())
+}
+
+func TestEnforceOwnership(t *testing.T) {
+	ctx := context.Background()
+	in := NewInput[string]("in", "Input", true)
+	out := NewOutput[string]("out", "Output", true)
+	ch := make(chan *ip.IP[string], 2)
+	require.NoError(t, Connect(in, ch))
+	require.NoError(t, Connect(out, make(chan *ip.IP[string], 3)))
+	in.EnforceOwnership("worker")
+	out.EnforceOwnership("worker")
+
+	ch <- ip.New("data")
+	packet, err := in.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "worker", packet.Owner())
+	assert.NoError(t, out.Send(ctx, packet))
+	assert.NoError(t, out.Send(ctx, ip.New("fresh")), "unowned packets may be sent")
+
+	require.NoError(t, packet.SetOwner("other"))
+	assert.ErrorIs(t, out.Send(ctx, packet), ErrOwnershipViolation)
+
+	out.EnforceOwnership("")
+	assert.NoError(t, out.Send(ctx, packet))
+}
+
+func TestPartialBroadcast(t *testing.T) {
+	port := NewOutput[string]("out", "Output", true)
+	fast := make(chan *ip.IP[string], 1)
+	slow := make(chan *ip.IP[string]) // nobody reads it
+	last := make(chan *ip.IP[string], 1)
+	require.NoError(t, Connect(port, fast))
+	require.NoError(t, Connect(port, slow))
+	require.NoError(t, Connect(port, last))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := port.Send(ctx, ip.New("data"))
+
+	var partial *PartialSendError
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, []int{0}, partial.Delivered)
+	assert.Equal(t, 3, partial.Connections)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, fast, 1)
+	assert.Len(t, last, 0)
+
+	t.Run("nothing delivered", func(t *testing.T) {
+		port := NewOutput[string]("out", "Output", true)
+		require.NoError(t, Connect(port, make(chan *ip.IP[string])))
+		require.NoError(t, Connect(port, make(chan *ip.IP[string], 1)))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := port.Send(ctx, ip.New("data"))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.False(t, errors.As(err, &partial))
+	})
+}
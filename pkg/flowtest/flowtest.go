@@ -0,0 +1,254 @@
+// Package flowtest runs networks in tests, feeding packets to named input
+// ports and collecting what arrives at named output ports.
+//
+//	out := flowtest.Collect[string]()
+//	flowtest.RunFlow(t, net, flowtest.Ports{
+//		"Mapper.in":  flowtest.Values("a", "b"),
+//		"Mapper.out": out,
+//	})
+//	assert.Equal(t, []string{"A", "B"}, out.Values())
+package flowtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+const (
+	// DefaultTimeout bounds how long RunFlow waits for a flow to go quiet
+	DefaultTimeout = 5 * time.Second
+	// DefaultIdle is how long a flow must produce no output to count as quiet
+	DefaultIdle = 50 * time.Millisecond
+)
+
+// Endpoint is an input or output attached to a port by RunFlow
+type Endpoint interface {
+	attach(port ports.AnyPort) error
+	// pending reports how many packets are still waiting to be consumed
+	pending() int
+	// received reports how many packets have been collected so far
+	received() int64
+	// finish is called once the network has stopped
+	finish()
+}
+
+// Ports maps "process.port" names to the endpoints attached to them
+type Ports map[string]Endpoint
+
+// Option customizes RunFlow
+type Option func(*options)
+
+type options struct {
+	timeout time.Duration
+	idle    time.Duration
+}
+
+// WithTimeout sets how long RunFlow waits before failing the test
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithIdle sets how long the flow must stay quiet before RunFlow stops it
+func WithIdle(idle time.Duration) Option {
+	return func(o *options) {
+		o.idle = idle
+	}
+}
+
+// RunFlow attaches the endpoints to their ports, runs the network until
+// every input has been consumed and no output has arrived for the idle
+// period, then stops it. The test fails if the network reports an error
+// or does not go quiet within the timeout.
+func RunFlow(t testing.TB, net *network.Network, endpoints Ports, opts ...Option) {
+	t.Helper()
+
+	o := options{timeout: DefaultTimeout, idle: DefaultIdle}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	for name, endpoint := range endpoints {
+		port, err := resolve(net, name)
+		if err != nil {
+			t.Fatalf("flowtest: %v", err)
+		}
+		if err := endpoint.attach(port); err != nil {
+			t.Fatalf("flowtest: %s: %v", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- net.Start(ctx)
+	}()
+
+	deadline := time.After(o.timeout)
+	ticker := time.NewTicker(o.idle / 5)
+	defer ticker.Stop()
+
+	lastCount, lastChange := int64(-1), time.Now()
+	for quiet := false; !quiet; {
+		select {
+		case err := <-errCh:
+			// Every process stopped on its own
+			for _, endpoint := range endpoints {
+				endpoint.finish()
+			}
+			if err != nil {
+				t.Fatalf("flowtest: network failed: %v", err)
+			}
+			return
+		case <-deadline:
+			t.Fatalf("flowtest: flow did not go quiet within %s", o.timeout)
+		case <-ticker.C:
+			pending, count := 0, int64(0)
+			for _, endpoint := range endpoints {
+				pending += endpoint.pending()
+				count += endpoint.received()
+			}
+			if pending > 0 || count != lastCount {
+				lastCount, lastChange = count, time.Now()
+				continue
+			}
+			quiet = time.Since(lastChange) >= o.idle
+		}
+	}
+
+	cancel()
+	err := <-errCh
+	for _, endpoint := range endpoints {
+		endpoint.finish()
+	}
+	if err != nil {
+		t.Fatalf("flowtest: network failed: %v", err)
+	}
+}
+
+// resolve finds the port named "process.port" in the network
+func resolve(net *network.Network, name string) (ports.AnyPort, error) {
+	processName, portName, ok := strings.Cut(name, ".")
+	if !ok {
+		return nil, fmt.Errorf("endpoint %q must be named process.port", name)
+	}
+
+	proc := net.GetProcess(processName)
+	if proc == nil {
+		return nil, fmt.Errorf("no process %q", processName)
+	}
+	resolver, ok := proc.(nodes.PortResolver)
+	if !ok {
+		return nil, fmt.Errorf("process %q does not expose named ports", processName)
+	}
+	port, ok := resolver.Port(portName)
+	if !ok {
+		return nil, fmt.Errorf("process %q has no port %q", processName, portName)
+	}
+	return port, nil
+}
+
+// Input feeds fixed values to an input port
+type Input[T any] struct {
+	values []T
+	ch     chan *ip.IP[T]
+}
+
+// Values creates an input feeding the given values in order
+func Values[T any](values ...T) *Input[T] {
+	return &Input[T]{values: values}
+}
+
+func (in *Input[T]) attach(port ports.AnyPort) error {
+	typed, ok := port.(*ports.Port[T])
+	if !ok {
+		return fmt.Errorf("port %s does not carry %T values", port.Name(), *new(T))
+	}
+
+	in.ch = make(chan *ip.IP[T], len(in.values))
+	for _, v := range in.values {
+		in.ch <- ip.New(v)
+	}
+	return ports.Connect(typed, in.ch)
+}
+
+func (in *Input[T]) pending() int {
+	return len(in.ch)
+}
+
+func (in *Input[T]) received() int64 {
+	return 0
+}
+
+func (in *Input[T]) finish() {}
+
+// Collector records the values arriving at an output port
+type Collector[T any] struct {
+	values []T
+	count  atomic.Int64
+	ch     chan *ip.IP[T]
+	done   chan struct{}
+	mu     sync.Mutex
+}
+
+// Collect creates an output collector
+func Collect[T any]() *Collector[T] {
+	return &Collector[T]{}
+}
+
+// Values returns the collected values in arrival order
+func (c *Collector[T]) Values() []T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]T(nil), c.values...)
+}
+
+func (c *Collector[T]) attach(port ports.AnyPort) error {
+	typed, ok := port.(*ports.Port[T])
+	if !ok {
+		return fmt.Errorf("port %s does not carry %T values", port.Name(), *new(T))
+	}
+
+	c.ch = make(chan *ip.IP[T], 64)
+	c.done = make(chan struct{})
+	if err := ports.Connect(typed, c.ch); err != nil {
+		return err
+	}
+	go func() {
+		defer close(c.done)
+		for packet := range c.ch {
+			c.mu.Lock()
+			c.values = append(c.values, packet.Data())
+			c.mu.Unlock()
+			c.count.Add(1)
+		}
+	}()
+	return nil
+}
+
+func (c *Collector[T]) pending() int {
+	return 0
+}
+
+func (c *Collector[T]) received() int64 {
+	return c.count.Load()
+}
+
+// finish collects any packets still buffered once the network has stopped
+func (c *Collector[T]) finish() {
+	close(c.ch)
+	<-c.done
+}
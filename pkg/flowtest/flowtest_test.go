@@ -0,0 +1,24 @@
+package flowtest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/flowtest"
+	"github.com/elleshadow/noPromises/pkg/nodes/transform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunFlowMapper(t *testing.T) {
+	net := network.New()
+	net.AddProcess(transform.NewMapper(strings.ToUpper))
+
+	out := flowtest.Collect[string]()
+	flowtest.RunFlow(t, net, flowtest.Ports{
+		"Mapper.in":  flowtest.Values("hello", "flow", "test"),
+		"Mapper.out": out,
+	})
+
+	assert.Equal(t, []string{"HELLO", "FLOW", "TEST"}, out.Values())
+}
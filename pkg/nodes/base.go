@@ -2,29 +2,185 @@ package nodes
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"sync"
 
 	"github.com/elleshadow/noPromises/pkg/core/ports"
 	"github.com/elleshadow/noPromises/pkg/core/process"
 )
 
+// Node is a process with a typed input and output port
+type Node[In, Out any] interface {
+	process.Process
+	Input() *ports.Port[In]
+	Output() *ports.Port[Out]
+}
+
+var (
+	// ErrPortNotFound is returned when a node has no port with the requested name
+	ErrPortNotFound = errors.New("port not found")
+	// ErrPortTypeMismatch is returned when a port's element type differs from the requested one
+	ErrPortTypeMismatch = errors.New("port type mismatch")
+	// ErrDuplicatePort is returned when adding a port whose name is already taken
+	ErrDuplicatePort = errors.New("duplicate port name")
+)
+
 // BaseNode provides common functionality for all nodes
 type BaseNode[In, Out any] struct {
 	process.BaseProcess
 	InPort  *ports.Port[In]
 	OutPort *ports.Port[Out]
 	Config  map[string]interface{}
+	ports   map[string]ports.AnyPort
+	order   []string
+	logger  *log.Logger
 	mu      sync.RWMutex
 }
 
-// NewBaseNode creates a new base node with the given name
-func NewBaseNode[In, Out any](name string) *BaseNode[In, Out] {
-	return &BaseNode[In, Out]{
+// Option configures a BaseNode
+type Option func(*options)
+
+type options struct {
+	inName      string
+	outName     string
+	optionalIn  bool
+	optionalOut bool
+	extra       []ports.AnyPort
+	logger      *log.Logger
+}
+
+// WithInputName names the primary input port (default "in")
+func WithInputName(name string) Option {
+	return func(o *options) {
+		o.inName = name
+	}
+}
+
+// WithOutputName names the primary output port (default "out")
+func WithOutputName(name string) Option {
+	return func(o *options) {
+		o.outName = name
+	}
+}
+
+// WithOptionalInput marks the primary input port as not required, for
+// sources whose input is unused or only one of several inputs
+func WithOptionalInput() Option {
+	return func(o *options) {
+		o.optionalIn = true
+	}
+}
+
+// WithOptionalOutput marks the primary output port as not required, for
+// sinks whose output is unused or only reports to listeners that want it
+func WithOptionalOutput() Option {
+	return func(o *options) {
+		o.optionalOut = true
+	}
+}
+
+// WithPorts adds extra named ports to the node
+func WithPorts(extra ...ports.AnyPort) Option {
+	return func(o *options) {
+		o.extra = append(o.extra, extra...)
+	}
+}
+
+// WithLogger sets the logger the node reports through (default log.Default())
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// NewBaseNode creates a new base node with the given name. It panics if
+// two ports share a name.
+func NewBaseNode[In, Out any](name string, opts ...Option) *BaseNode[In, Out] {
+	o := options{inName: "in", outName: "out", logger: log.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	n := &BaseNode[In, Out]{
 		BaseProcess: process.NewBaseProcess(name),
-		InPort:      ports.NewInput[In]("in", "Input port", true),
-		OutPort:     ports.NewOutput[Out]("out", "Output port", true),
+		InPort:      ports.NewInput[In](o.inName, "Input port", !o.optionalIn),
+		OutPort:     ports.NewOutput[Out](o.outName, "Output port", !o.optionalOut),
 		Config:      make(map[string]interface{}),
+		logger:      o.logger,
+	}
+
+	for _, p := range append([]ports.AnyPort{n.InPort, n.OutPort}, o.extra...) {
+		if err := n.AddPort(p); err != nil {
+			panic(fmt.Sprintf("node %s: %v", name, err))
+		}
 	}
+	return n
+}
+
+// AddPort registers an extra port so it can be resolved by name
+func (n *BaseNode[In, Out]) AddPort(p ports.AnyPort) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, exists := n.ports[p.Name()]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicatePort, p.Name())
+	}
+	if n.ports == nil {
+		n.ports = make(map[string]ports.AnyPort)
+	}
+	n.ports[p.Name()] = p
+	n.order = append(n.order, p.Name())
+	return nil
+}
+
+// Port returns the port with the given name
+func (n *BaseNode[In, Out]) Port(name string) (ports.AnyPort, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	p, exists := n.ports[name]
+	return p, exists
+}
+
+// Ports returns all ports in the order they were added
+func (n *BaseNode[In, Out]) Ports() []ports.AnyPort {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	result := make([]ports.AnyPort, 0, len(n.order))
+	for _, name := range n.order {
+		result = append(result, n.ports[name])
+	}
+	return result
+}
+
+// PortResolver is implemented by nodes whose ports can be looked up by name
+type PortResolver interface {
+	Port(name string) (ports.AnyPort, bool)
+}
+
+// LookupPort resolves a named port of node n with element type T
+func LookupPort[T any](n PortResolver, name string) (*ports.Port[T], error) {
+	p, exists := n.Port(name)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrPortNotFound, name)
+	}
+	typed, ok := p.(*ports.Port[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPortTypeMismatch, name)
+	}
+	return typed, nil
+}
+
+// Input returns the node's input port
+func (n *BaseNode[In, Out]) Input() *ports.Port[In] {
+	return n.InPort
+}
+
+// Output returns the node's output port
+func (n *BaseNode[In, Out]) Output() *ports.Port[Out] {
+	return n.OutPort
 }
 
 // Initialize prepares the node for execution
@@ -32,10 +188,41 @@ func (n *BaseNode[In, Out]) Initialize(ctx context.Context) error {
 	return n.BaseProcess.Initialize(ctx)
 }
 
-// Process implements the process.Process interface
+// Logger returns the node's logger
+func (n *BaseNode[In, Out]) Logger() *log.Logger {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.logger == nil {
+		return log.Default()
+	}
+	return n.logger
+}
+
+// SetLogger replaces the node's logger
+func (n *BaseNode[In, Out]) SetLogger(logger *log.Logger) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.logger = logger
+}
+
+// Process implements the process.Process interface. Nodes are expected to
+// override it; this default warns that the node does nothing and discards
+// its input so upstream producers are not blocked.
 func (n *BaseNode[In, Out]) Process(ctx context.Context) error {
-	<-ctx.Done()
-	return ctx.Err()
+	n.Logger().Printf("WARN node %s has no processing implementation; discarding its input", n.Name())
+
+	for {
+		if _, err := n.InPort.Receive(ctx); err != nil {
+			if errors.Is(err, ports.ErrStreamClosed) {
+				return nil
+			}
+			if ctx.Err() == nil {
+				// Nothing to drain (unconnected or closed input)
+				<-ctx.Done()
+			}
+			return ctx.Err()
+		}
+	}
 }
 
 // Shutdown cleans up node resources
@@ -1,9 +1,16 @@
 package nodes
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"log"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
 )
 
 func TestBaseNode(t *testing.T) {
@@ -66,3 +73,129 @@ func TestBaseNode(t *testing.T) {
 		}
 	})
 }
+
+func TestDefaultProcess(t *testing.T) {
+	var logBuf bytes.Buffer
+	node := NewBaseNode[int, int]("Unimplemented", WithLogger(log.New(&logBuf, "", 0)))
+
+	ch := make(chan *ip.IP[int])
+	if err := ports.Connect(node.InPort, ch); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- node.Process(ctx)
+	}()
+
+	// Upstream can keep sending on the unbuffered channel
+	for i := 0; i < 3; i++ {
+		select {
+		case ch <- ip.New(i):
+		case <-time.After(500 * time.Millisecond):
+			t.Fatal("upstream blocked by node without a Process implementation")
+		}
+	}
+
+	cancel()
+	if err := <-errCh; err != context.Canceled {
+		t.Errorf("Expected Canceled, got %v", err)
+	}
+	if !strings.Contains(logBuf.String(), "WARN node Unimplemented has no processing implementation") {
+		t.Errorf("Expected warning, got %q", logBuf.String())
+	}
+}
+
+func TestNamedPorts(t *testing.T) {
+	t.Run("default names", func(t *testing.T) {
+		node := NewBaseNode[string, int]("TestNode")
+
+		if _, ok := node.Port("in"); !ok {
+			t.Error("Expected port \"in\"")
+		}
+		if _, ok := node.Port("out"); !ok {
+			t.Error("Expected port \"out\"")
+		}
+	})
+
+	t.Run("custom and extra ports", func(t *testing.T) {
+		right := ports.NewInput[int]("right", "Right input", true)
+		node := NewBaseNode[int, int]("Merge",
+			WithInputName("left"),
+			WithPorts(right),
+		)
+
+		left, err := LookupPort[int](node, "left")
+		if err != nil {
+			t.Fatalf("Unexpected error resolving left: %v", err)
+		}
+		if left != node.InPort {
+			t.Error("Expected left to be the primary input port")
+		}
+
+		got, err := LookupPort[int](node, "right")
+		if err != nil {
+			t.Fatalf("Unexpected error resolving right: %v", err)
+		}
+		if got != right {
+			t.Error("Expected right to be the extra port")
+		}
+
+		out, err := LookupPort[int](node, "out")
+		if err != nil {
+			t.Fatalf("Unexpected error resolving out: %v", err)
+		}
+		if out != node.OutPort {
+			t.Error("Expected out to be the primary output port")
+		}
+
+		names := make([]string, 0)
+		for _, p := range node.Ports() {
+			names = append(names, p.Name())
+		}
+		if strings.Join(names, ",") != "left,out,right" {
+			t.Errorf("Expected ports left,out,right, got %v", names)
+		}
+	})
+
+	t.Run("lookup errors", func(t *testing.T) {
+		node := NewBaseNode[string, int]("TestNode")
+
+		if _, err := LookupPort[string](node, "missing"); !errors.Is(err, ErrPortNotFound) {
+			t.Errorf("Expected ErrPortNotFound, got %v", err)
+		}
+		if _, err := LookupPort[int](node, "in"); !errors.Is(err, ErrPortTypeMismatch) {
+			t.Errorf("Expected ErrPortTypeMismatch, got %v", err)
+		}
+		if err := node.AddPort(ports.NewOutput[int]("out", "", false)); !errors.Is(err, ErrDuplicatePort) {
+			t.Errorf("Expected ErrDuplicatePort, got %v", err)
+		}
+	})
+}
+
+func TestCallWithTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	got, err := CallWithTimeout(ctx, 0, func() int { return 1 })
+	if err != nil || got != 1 {
+		t.Errorf("Expected 1 without timeout, got %d, %v", got, err)
+	}
+
+	got, err = CallWithTimeout(ctx, time.Second, func() int { return 2 })
+	if err != nil || got != 2 {
+		t.Errorf("Expected 2 within timeout, got %d, %v", got, err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	_, err = CallWithTimeout(ctx, 10*time.Millisecond, func() int {
+		<-release
+		return 3
+	})
+	if !errors.Is(err, ErrProcessingTimeout) {
+		t.Errorf("Expected ErrProcessingTimeout, got %v", err)
+	}
+}
@@ -28,7 +28,7 @@ func (d *Delay[T]) Process(ctx context.Context) error {
 		default:
 			packet, err := d.InPort.Receive(ctx)
 			if err != nil {
-				return err
+				return nodes.EndOfStream(err)
 			}
 
 			select {
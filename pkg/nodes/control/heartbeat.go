@@ -0,0 +1,96 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// ErrHeartbeatMissed is sent by Watchdog when no heartbeat arrives in time
+var ErrHeartbeatMissed = errors.New("heartbeat missed")
+
+// Heartbeat emits the current time every Interval. Its input port is unused.
+type Heartbeat struct {
+	*nodes.BaseNode[time.Time, time.Time]
+	Interval time.Duration
+}
+
+func NewHeartbeat(interval time.Duration) *Heartbeat {
+	return &Heartbeat{
+		BaseNode: nodes.NewBaseNode[time.Time, time.Time]("Heartbeat", nodes.WithOptionalInput()),
+		Interval: interval,
+	}
+}
+
+func (h *Heartbeat) Process(ctx context.Context) error {
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			if err := h.OutPort.Send(ctx, ip.New(now)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Watchdog sends an ErrHeartbeatMissed alert when no heartbeat is received
+// within Timeout. It alerts once per stall and re-arms on the next heartbeat.
+// Alerts are also logged, so its output may be left unconnected.
+type Watchdog struct {
+	*nodes.BaseNode[time.Time, error]
+	Timeout time.Duration
+}
+
+func NewWatchdog(timeout time.Duration) *Watchdog {
+	return &Watchdog{
+		BaseNode: nodes.NewBaseNode[time.Time, error]("Watchdog", nodes.WithOptionalOutput()),
+		Timeout:  timeout,
+	}
+}
+
+func (w *Watchdog) Process(ctx context.Context) error {
+	last := time.Now()
+	alerted := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			recvCtx, cancel := context.WithTimeout(ctx, w.Timeout)
+			packet, err := w.InPort.Receive(recvCtx)
+			cancel()
+
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if !errors.Is(err, context.DeadlineExceeded) {
+					return err
+				}
+				if alerted {
+					continue
+				}
+				alerted = true
+				alert := fmt.Errorf("%w: none since %s", ErrHeartbeatMissed, last.Format(time.RFC3339Nano))
+				w.Logger().Printf("WARN %s: %v", w.Name(), alert)
+				if err := w.OutPort.Send(ctx, ip.New(alert)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			last = packet.Data()
+			alerted = false
+		}
+	}
+}
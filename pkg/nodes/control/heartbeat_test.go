@@ -0,0 +1,79 @@
+package control
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeatWatchdog(t *testing.T) {
+	heartbeat := NewHeartbeat(10 * time.Millisecond)
+	watchdog := NewWatchdog(50 * time.Millisecond)
+
+	beatCh := make(chan *ip.IP[time.Time], 1)
+	alertCh := make(chan *ip.IP[error], 10)
+
+	require.NoError(t, ports.Connect(heartbeat.OutPort, beatCh))
+	require.NoError(t, ports.Connect(watchdog.InPort, beatCh))
+	require.NoError(t, ports.Connect(watchdog.OutPort, alertCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	beatCtx, stopBeat := context.WithCancel(ctx)
+	beatErrCh := make(chan error, 1)
+	go func() {
+		beatErrCh <- heartbeat.Process(beatCtx)
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- watchdog.Process(ctx)
+	}()
+
+	// A steady heartbeat keeps the watchdog quiet
+	select {
+	case alert := <-alertCh:
+		t.Fatalf("unexpected alert: %v", alert.Data())
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Stopping the heartbeat fires the watchdog once
+	stopBeat()
+	assert.Equal(t, context.Canceled, <-beatErrCh)
+
+	select {
+	case alert := <-alertCh:
+		assert.ErrorIs(t, alert.Data(), ErrHeartbeatMissed)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for alert")
+	}
+
+	select {
+	case alert := <-alertCh:
+		t.Fatalf("repeated alert for the same stall: %v", alert.Data())
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	// A new heartbeat re-arms the watchdog
+	require.NoError(t, heartbeat.OutPort.Send(ctx, ip.New(time.Now())))
+	select {
+	case alert := <-alertCh:
+		assert.ErrorIs(t, alert.Data(), ErrHeartbeatMissed)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for second alert")
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+}
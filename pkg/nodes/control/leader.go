@@ -0,0 +1,225 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// LeaseStore grants a named lease to one holder at a time.
+//
+// A store shared by the replicas of a flow, backed by a database or other
+// shared service, lets LeaderGate run them as an active/standby pair.
+type LeaseStore interface {
+	// Acquire takes the lease for holder, or extends it when holder already
+	// has it, for ttl. It reports false while another holder's lease is
+	// still unexpired.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	// Release gives up the lease if holder has it
+	Release(ctx context.Context, name, holder string) error
+}
+
+// lease is one entry of a MemoryLeaseStore
+type lease struct {
+	holder  string
+	expires time.Time
+}
+
+// MemoryLeaseStore is a LeaseStore held in memory, for tests and for
+// replicas running in one process
+type MemoryLeaseStore struct {
+	leases map[string]lease
+	mu     sync.Mutex
+}
+
+// NewMemoryLeaseStore creates an empty in-memory lease store
+func NewMemoryLeaseStore() *MemoryLeaseStore {
+	return &MemoryLeaseStore{leases: make(map[string]lease)}
+}
+
+// Acquire implements LeaseStore
+func (s *MemoryLeaseStore) Acquire(_ context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if current, exists := s.leases[name]; exists && current.holder != holder && now.Before(current.expires) {
+		return false, nil
+	}
+	s.leases[name] = lease{holder: holder, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// Release implements LeaseStore
+func (s *MemoryLeaseStore) Release(_ context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, exists := s.leases[name]; exists && current.holder == holder {
+		delete(s.leases, name)
+	}
+	return nil
+}
+
+// Holder returns the current holder of a lease, or "" when it is free
+func (s *MemoryLeaseStore) Holder(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, exists := s.leases[name]; exists && time.Now().Before(current.expires) {
+		return current.holder
+	}
+	return ""
+}
+
+// LeaderGateMode decides what a LeaderGate does with packets while it is
+// not the leader
+type LeaderGateMode int
+
+const (
+	// LeaderGateDrop discards packets received on standby
+	LeaderGateDrop LeaderGateMode = iota
+	// LeaderGateBuffer keeps up to BufferSize packets received on standby,
+	// dropping the oldest when full, and forwards them on taking over
+	LeaderGateBuffer
+)
+
+// Defaults of a new LeaderGate
+const (
+	DefaultLeaseTTL         = 10 * time.Second
+	DefaultLeaderGateBuffer = 1000
+)
+
+// LeaderGate forwards packets only while its holder holds the lease, so
+// that of several replicas running the same flow only one processes.
+//
+// The lease is renewed every third of TTL. A renewal that fails or errors
+// puts the gate on standby at once; another replica can take over when the
+// lease expires. Stopping the gate releases the lease.
+type LeaderGate[T any] struct {
+	*nodes.BaseNode[T, T]
+	Store      LeaseStore
+	Lease      string
+	Holder     string
+	TTL        time.Duration
+	Mode       LeaderGateMode
+	BufferSize int
+	leader     atomic.Bool
+	dropped    atomic.Uint64
+}
+
+func NewLeaderGate[T any](store LeaseStore, lease, holder string) *LeaderGate[T] {
+	return &LeaderGate[T]{
+		BaseNode:   nodes.NewBaseNode[T, T]("LeaderGate"),
+		Store:      store,
+		Lease:      lease,
+		Holder:     holder,
+		TTL:        DefaultLeaseTTL,
+		Mode:       LeaderGateDrop,
+		BufferSize: DefaultLeaderGateBuffer,
+	}
+}
+
+// IsLeader reports whether the gate currently holds the lease
+func (g *LeaderGate[T]) IsLeader() bool {
+	return g.leader.Load()
+}
+
+// Dropped returns how many packets were discarded on standby
+func (g *LeaderGate[T]) Dropped() uint64 {
+	return g.dropped.Load()
+}
+
+// renew acquires or extends the lease and records the outcome
+func (g *LeaderGate[T]) renew(ctx context.Context) {
+	leader, err := g.Store.Acquire(ctx, g.Lease, g.Holder, g.ttl())
+	if err != nil {
+		if ctx.Err() == nil {
+			g.Logger().Printf("WARN %s could not renew lease %q: %v", g.Name(), g.Lease, err)
+		}
+		leader = false
+	}
+	g.leader.Store(leader)
+}
+
+func (g *LeaderGate[T]) ttl() time.Duration {
+	if g.TTL <= 0 {
+		return DefaultLeaseTTL
+	}
+	return g.TTL
+}
+
+func (g *LeaderGate[T]) Process(ctx context.Context) error {
+	defer func() {
+		g.leader.Store(false)
+		releaseCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := g.Store.Release(releaseCtx, g.Lease, g.Holder); err != nil {
+			g.Logger().Printf("WARN %s could not release lease %q: %v", g.Name(), g.Lease, err)
+		}
+	}()
+
+	interval := g.ttl() / 3
+	var buffered []*ip.IP[T]
+	flush := func() error {
+		for len(buffered) > 0 {
+			if err := g.OutPort.Send(ctx, buffered[0]); err != nil {
+				return err
+			}
+			buffered = buffered[1:]
+		}
+		return nil
+	}
+
+	g.renew(ctx)
+	nextRenewal := time.Now().Add(interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			recvCtx, cancel := context.WithDeadline(ctx, nextRenewal)
+			packet, err := g.InPort.Receive(recvCtx)
+			cancel()
+
+			if !time.Now().Before(nextRenewal) {
+				g.renew(ctx)
+				nextRenewal = time.Now().Add(interval)
+			}
+			if g.IsLeader() {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if errors.Is(err, context.DeadlineExceeded) {
+					continue
+				}
+				return nodes.EndOfStream(err)
+			}
+
+			switch {
+			case g.IsLeader():
+				if err := g.OutPort.Send(ctx, packet); err != nil {
+					return err
+				}
+			case g.Mode == LeaderGateBuffer && g.BufferSize > 0:
+				if len(buffered) >= g.BufferSize {
+					buffered = buffered[1:]
+					g.dropped.Add(1)
+				}
+				buffered = append(buffered, packet)
+			default:
+				g.dropped.Add(1)
+			}
+		}
+	}
+}
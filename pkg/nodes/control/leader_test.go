@@ -0,0 +1,141 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// partitionedStore fails the renewals of one holder while partitioned
+type partitionedStore struct {
+	*MemoryLeaseStore
+	holder      string
+	partitioned atomic.Bool
+}
+
+func (s *partitionedStore) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	if holder == s.holder && s.partitioned.Load() {
+		return false, errors.New("store unreachable")
+	}
+	return s.MemoryLeaseStore.Acquire(ctx, name, holder, ttl)
+}
+
+type gateHarness struct {
+	gate *LeaderGate[int]
+	in   chan *ip.IP[int]
+	out  chan *ip.IP[int]
+}
+
+func startGate(t *testing.T, ctx context.Context, store LeaseStore, holder string) *gateHarness {
+	h := &gateHarness{
+		gate: NewLeaderGate[int](store, "flow-1", holder),
+		in:   make(chan *ip.IP[int], 10),
+		out:  make(chan *ip.IP[int], 10),
+	}
+	h.gate.TTL = 90 * time.Millisecond
+	h.gate.Mode = LeaderGateBuffer
+	require.NoError(t, ports.Connect(h.gate.InPort, h.in))
+	require.NoError(t, ports.Connect(h.gate.OutPort, h.out))
+	go func() {
+		_ = h.gate.Process(ctx)
+	}()
+	return h
+}
+
+func TestLeaderGate(t *testing.T) {
+	store := &partitionedStore{MemoryLeaseStore: NewMemoryLeaseStore(), holder: "a"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	a := startGate(t, ctx, store, "a")
+	require.Eventually(t, a.gate.IsLeader, time.Second, 5*time.Millisecond)
+	b := startGate(t, ctx, store, "b")
+
+	// Only the leader forwards
+	a.in <- ip.New(1)
+	b.in <- ip.New(2)
+	select {
+	case packet := <-a.out:
+		assert.Equal(t, 1, packet.Data())
+	case <-time.After(time.Second):
+		t.Fatal("leader did not forward")
+	}
+	select {
+	case packet := <-b.out:
+		t.Fatalf("standby forwarded %d", packet.Data())
+	case <-time.After(150 * time.Millisecond):
+	}
+	assert.False(t, b.gate.IsLeader())
+	assert.Equal(t, "a", store.Holder("flow-1"))
+
+	// Once a can no longer renew, its lease expires and b takes over,
+	// forwarding what it buffered on standby
+	store.partitioned.Store(true)
+	require.Eventually(t, b.gate.IsLeader, time.Second, 5*time.Millisecond)
+	assert.False(t, a.gate.IsLeader())
+	assert.Equal(t, "b", store.Holder("flow-1"))
+
+	b.in <- ip.New(3)
+	for _, want := range []int{2, 3} {
+		select {
+		case packet := <-b.out:
+			assert.Equal(t, want, packet.Data())
+		case <-time.After(time.Second):
+			t.Fatalf("new leader did not forward %d", want)
+		}
+	}
+
+	a.in <- ip.New(4)
+	select {
+	case packet := <-a.out:
+		t.Fatalf("former leader forwarded %d", packet.Data())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestLeaderGateDropAndRelease(t *testing.T) {
+	store := NewMemoryLeaseStore()
+	ok, err := store.Acquire(context.Background(), "flow-1", "other", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	run := func(ctx context.Context, lease string) (*LeaderGate[int], chan *ip.IP[int], chan *ip.IP[int], chan error) {
+		gate := NewLeaderGate[int](store, lease, "me")
+		in := make(chan *ip.IP[int], 2)
+		out := make(chan *ip.IP[int], 2)
+		require.NoError(t, ports.Connect(gate.InPort, in))
+		require.NoError(t, ports.Connect(gate.OutPort, out))
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- gate.Process(ctx)
+		}()
+		return gate, in, out, errCh
+	}
+
+	// On standby packets are dropped by default
+	ctx, cancel := context.WithCancel(context.Background())
+	standby, in, out, errCh := run(ctx, "flow-1")
+	in <- ip.New(1)
+	in <- ip.New(2)
+	assert.Eventually(t, func() bool { return standby.Dropped() == 2 }, time.Second, 5*time.Millisecond)
+	assert.Empty(t, out)
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+	assert.Equal(t, "other", store.Holder("flow-1"), "stopping must not release another holder's lease")
+
+	// Stopping the leader releases its lease for the standby
+	ctx, cancel = context.WithCancel(context.Background())
+	leader, _, _, errCh := run(ctx, "flow-2")
+	require.Eventually(t, leader.IsLeader, time.Second, 5*time.Millisecond)
+	assert.Equal(t, "me", store.Holder("flow-2"))
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+	assert.Equal(t, "", store.Holder("flow-2"))
+}
@@ -0,0 +1,160 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// MetadataRequestID is the metadata key correlating a reply with its
+// request. Nodes between the two ends of a RequestReply must copy it from
+// each request to the reply they produce.
+const MetadataRequestID = "request_id"
+
+// DefaultRequestTimeout is how long a new RequestReply waits for a reply
+const DefaultRequestTimeout = 30 * time.Second
+
+var (
+	// ErrRequestTimeout is returned when no reply arrives within the timeout
+	ErrRequestTimeout = errors.New("request timed out")
+	// ErrRequestReplyStopped is returned to requests pending when the node stops
+	ErrRequestReplyStopped = errors.New("request/reply node stopped")
+)
+
+// Reply is the outcome of a request: the correlated response or an error
+type Reply[Resp any] struct {
+	Value Resp
+	Err   error
+}
+
+// RequestReply bridges synchronous callers, such as HTTP handlers, and a
+// flow. Each request is sent from OutPort tagged with a request id; Process
+// reads replies from InPort and hands each to the caller waiting on the
+// matching id. Replies nobody is waiting for, because they timed out or
+// carry no id, are dropped.
+type RequestReply[Req, Resp any] struct {
+	*nodes.BaseNode[Resp, Req]
+	Timeout time.Duration
+	pending map[string]chan Reply[Resp]
+	mu      sync.Mutex
+}
+
+func NewRequestReply[Req, Resp any]() *RequestReply[Req, Resp] {
+	return &RequestReply[Req, Resp]{
+		BaseNode: nodes.NewBaseNode[Resp, Req]("RequestReply"),
+		Timeout:  DefaultRequestTimeout,
+		pending:  make(map[string]chan Reply[Resp]),
+	}
+}
+
+// Submit sends req into the flow and returns a future delivering exactly
+// one Reply: the response, or ErrRequestTimeout, ctx's error or
+// ErrRequestReplyStopped.
+func (r *RequestReply[Req, Resp]) Submit(ctx context.Context, req Req) <-chan Reply[Resp] {
+	future := make(chan Reply[Resp], 1)
+
+	packet := ip.New(req)
+	id := packet.ID()
+	packet.SetMetadata(MetadataRequestID, id)
+
+	replies := make(chan Reply[Resp], 1)
+	r.mu.Lock()
+	r.pending[id] = replies
+	r.mu.Unlock()
+
+	go func() {
+		defer r.forget(id)
+
+		timeout := r.Timeout
+		if timeout <= 0 {
+			timeout = DefaultRequestTimeout
+		}
+		reqCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		fail := func(err error) {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				err = fmt.Errorf("%w after %s", ErrRequestTimeout, timeout)
+			}
+			future <- Reply[Resp]{Err: err}
+		}
+
+		if err := r.OutPort.Send(reqCtx, packet); err != nil {
+			fail(err)
+			return
+		}
+
+		select {
+		case reply := <-replies:
+			future <- reply
+		case <-reqCtx.Done():
+			fail(reqCtx.Err())
+		}
+	}()
+
+	return future
+}
+
+// Request sends req into the flow and waits for its reply
+func (r *RequestReply[Req, Resp]) Request(ctx context.Context, req Req) (Resp, error) {
+	reply := <-r.Submit(ctx, req)
+	return reply.Value, reply.Err
+}
+
+func (r *RequestReply[Req, Resp]) forget(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, id)
+}
+
+// deliver hands a reply to the request waiting for id, if any
+func (r *RequestReply[Req, Resp]) deliver(id string, reply Reply[Resp]) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replies, exists := r.pending[id]
+	if !exists {
+		return false
+	}
+	delete(r.pending, id)
+	replies <- reply
+	return true
+}
+
+// Process correlates replies with pending requests until the context is
+// done or the reply stream ends, then fails any requests still pending
+func (r *RequestReply[Req, Resp]) Process(ctx context.Context) error {
+	defer r.failPending()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := r.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			id, _ := packet.GetStringMeta(MetadataRequestID)
+			if !r.deliver(id, Reply[Resp]{Value: packet.Data()}) {
+				r.Logger().Printf("WARN %s dropped a reply for unknown request %q", r.Name(), id)
+			}
+		}
+	}
+}
+
+func (r *RequestReply[Req, Resp]) failPending() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, replies := range r.pending {
+		delete(r.pending, id)
+		replies <- Reply[Resp]{Err: ErrRequestReplyStopped}
+	}
+}
@@ -0,0 +1,113 @@
+package control
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echo replies to each request with its upper-cased text, keeping the
+// request's metadata
+func echo(ctx context.Context, requests <-chan *ip.IP[string], replies chan<- *ip.IP[string]) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case req := <-requests:
+			reply := ip.New(strings.ToUpper(req.Data()))
+			for k, v := range req.Metadata() {
+				reply.SetMetadata(k, v)
+			}
+			replies <- reply
+		}
+	}
+}
+
+func TestRequestReply(t *testing.T) {
+	rr := NewRequestReply[string, string]()
+	rr.Timeout = time.Second
+
+	requests := make(chan *ip.IP[string], 10)
+	replies := make(chan *ip.IP[string], 10)
+	require.NoError(t, ports.Connect(rr.OutPort, requests))
+	require.NoError(t, ports.Connect(rr.InPort, replies))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go echo(ctx, requests, replies)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- rr.Process(ctx)
+	}()
+
+	// Concurrent requests each get their own reply
+	words := []string{"alpha", "beta", "gamma"}
+	futures := make([]<-chan Reply[string], len(words))
+	for i, word := range words {
+		futures[i] = rr.Submit(ctx, word)
+	}
+	for i, future := range futures {
+		select {
+		case reply := <-future:
+			require.NoError(t, reply.Err)
+			assert.Equal(t, strings.ToUpper(words[i]), reply.Value)
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for reply")
+		}
+	}
+
+	resp, err := rr.Request(ctx, "delta")
+	require.NoError(t, err)
+	assert.Equal(t, "DELTA", resp)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+}
+
+func TestRequestReplyTimeout(t *testing.T) {
+	rr := NewRequestReply[string, string]()
+	rr.Timeout = 50 * time.Millisecond
+
+	requests := make(chan *ip.IP[string], 1)
+	replies := make(chan *ip.IP[string], 1)
+	require.NoError(t, ports.Connect(rr.OutPort, requests))
+	require.NoError(t, ports.Connect(rr.InPort, replies))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- rr.Process(ctx)
+	}()
+
+	// Nothing answers, so the request times out
+	_, err := rr.Request(ctx, "hello")
+	assert.ErrorIs(t, err, ErrRequestTimeout)
+
+	// A late reply is dropped rather than delivered to the next request
+	late := <-requests
+	reply := ip.New("late")
+	reply.SetMetadata(MetadataRequestID, late.ID())
+	replies <- reply
+
+	future := rr.Submit(ctx, "pending")
+	<-requests
+
+	// Stopping the node fails requests still waiting
+	close(replies)
+	require.NoError(t, <-errCh)
+	select {
+	case reply := <-future:
+		assert.ErrorIs(t, reply.Err, ErrRequestReplyStopped)
+	case <-time.After(time.Second):
+		t.Fatal("pending request was not failed")
+	}
+}
@@ -8,7 +8,8 @@ import (
 	"github.com/elleshadow/noPromises/pkg/nodes"
 )
 
-// Logger logs incoming data packets
+// Logger logs incoming data packets and forwards them. Its output may be
+// left unconnected when the logger ends a flow.
 type Logger[T any] struct {
 	*nodes.BaseNode[T, T]
 	LogPrefix string
@@ -17,7 +18,7 @@ type Logger[T any] struct {
 // NewLogger creates a new logger node
 func NewLogger[T any](prefix string) *Logger[T] {
 	return &Logger[T]{
-		BaseNode:  nodes.NewBaseNode[T, T]("Logger"),
+		BaseNode:  nodes.NewBaseNode[T, T]("Logger", nodes.WithOptionalOutput()),
 		LogPrefix: prefix,
 	}
 }
@@ -31,7 +32,7 @@ func (l *Logger[T]) Process(ctx context.Context) error {
 		default:
 			packet, err := l.InPort.Receive(ctx)
 			if err != nil {
-				return err
+				return nodes.EndOfStream(err)
 			}
 
 			log.Printf("%s: %v", l.LogPrefix, packet.Data())
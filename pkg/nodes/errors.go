@@ -0,0 +1,48 @@
+package nodes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+)
+
+// EndOfStream maps a Receive error to a node's return value: a closed input
+// stream (ports.ErrStreamClosed) is a normal end and yields nil, anything
+// else is returned as is
+func EndOfStream(err error) error {
+	if errors.Is(err, ports.ErrStreamClosed) {
+		return nil
+	}
+	return err
+}
+
+// PacketError is sent on a node's error port when a packet cannot be
+// processed. It carries the packet so a dead-letter sink can record it.
+type PacketError struct {
+	Node     string
+	PacketID string
+	Data     any
+	Metadata map[string]any
+	Err      error
+}
+
+// NewPacketError wraps err with the packet that caused it
+func NewPacketError[T any](node string, packet *ip.IP[T], err error) *PacketError {
+	return &PacketError{
+		Node:     node,
+		PacketID: packet.ID(),
+		Data:     packet.Data(),
+		Metadata: packet.Metadata(),
+		Err:      err,
+	}
+}
+
+func (e *PacketError) Error() string {
+	return fmt.Sprintf("%s: packet %s: %v", e.Node, e.PacketID, e.Err)
+}
+
+func (e *PacketError) Unwrap() error {
+	return e.Err
+}
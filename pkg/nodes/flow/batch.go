@@ -0,0 +1,153 @@
+package flow
+
+import (
+	"context"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// BracketsToBatch collects each bracketed substream into a single batch IP.
+//
+// Only the outermost brackets delimit a batch; nested brackets are dropped
+// and their contents flattened into the enclosing batch. Packets arriving
+// outside any brackets are emitted as batches of one. Metadata set on the
+// opening bracket is copied to the batch, and metadata set on the items is
+// kept under MetadataItems so Unbatch can restore it.
+//
+// Set a maximum nesting depth with Tracker.SetMaxDepth; exceeding it stops
+// the node with ip.ErrBracketTooDeep.
+type BracketsToBatch[T any] struct {
+	*nodes.BaseNode[T, []T]
+	Tracker *ip.BracketTracker
+}
+
+// NewBracketsToBatch creates a new brackets-to-batch node
+func NewBracketsToBatch[T any]() *BracketsToBatch[T] {
+	return &BracketsToBatch[T]{
+		BaseNode: nodes.NewBaseNode[T, []T]("BracketsToBatch"),
+		Tracker:  ip.NewBracketTracker(nil),
+	}
+}
+
+// Process implements the processing logic
+func (b *BracketsToBatch[T]) Process(ctx context.Context) error {
+	var (
+		batch     []T
+		metadata  map[string]any
+		itemMeta  []map[string]any
+		itemsMeta bool
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := b.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			switch packet.Type() {
+			case ip.TypeBracketOpen:
+				if b.Tracker.Depth() == 0 {
+					batch = make([]T, 0)
+					metadata = packet.Metadata()
+					itemMeta, itemsMeta = nil, false
+				}
+				if err := b.Tracker.OpenBracket(); err != nil {
+					return err
+				}
+
+			case ip.TypeBracketClose:
+				if err := b.Tracker.CloseBracket(); err != nil {
+					continue // unmatched close bracket
+				}
+				if b.Tracker.Depth() == 0 {
+					if itemsMeta {
+						metadata[MetadataItems] = itemMeta
+					}
+					if err := b.OutPort.Send(ctx, newBatchIP(batch, metadata)); err != nil {
+						return err
+					}
+					batch, metadata, itemMeta = nil, nil, nil
+				}
+
+			default:
+				if b.Tracker.Depth() == 0 {
+					if err := b.OutPort.Send(ctx, ip.New([]T{packet.Data()})); err != nil {
+						return err
+					}
+					continue
+				}
+				batch = append(batch, packet.Data())
+				meta := userMetadata(packet.Metadata())
+				if len(meta) > 0 {
+					itemsMeta = true
+				}
+				itemMeta = append(itemMeta, meta)
+			}
+		}
+	}
+}
+
+// newBatchIP creates a batch IP carrying the given metadata
+func newBatchIP[T any](batch []T, metadata map[string]any) *ip.IP[[]T] {
+	packet := ip.New(batch)
+	for k, v := range userMetadata(metadata) {
+		packet.SetMetadata(k, v)
+	}
+	return packet
+}
+
+// userMetadata returns metadata without the keys every IP is created with
+func userMetadata(metadata map[string]any) map[string]any {
+	result := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		if k == "created_at" {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// BatchToBrackets emits the elements of each batch IP as a bracketed substream
+type BatchToBrackets[T any] struct {
+	*nodes.BaseNode[[]T, T]
+}
+
+// NewBatchToBrackets creates a new batch-to-brackets node
+func NewBatchToBrackets[T any]() *BatchToBrackets[T] {
+	return &BatchToBrackets[T]{
+		BaseNode: nodes.NewBaseNode[[]T, T]("BatchToBrackets"),
+	}
+}
+
+// Process implements the processing logic
+func (b *BatchToBrackets[T]) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := b.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			if err := b.OutPort.Send(ctx, ip.NewOpenBracket[T]()); err != nil {
+				return err
+			}
+			for _, item := range packet.Data() {
+				if err := b.OutPort.Send(ctx, ip.New(item)); err != nil {
+					return err
+				}
+			}
+			if err := b.OutPort.Send(ctx, ip.NewCloseBracket[T]()); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package flow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBracketsBatchRoundTrip(t *testing.T) {
+	toBatch := NewBracketsToBatch[string]()
+	toBrackets := NewBatchToBrackets[string]()
+
+	// Wire toBatch -> toBrackets
+	inCh := make(chan *ip.IP[string], 5)
+	batchCh := make(chan *ip.IP[[]string], 1)
+	outCh := make(chan *ip.IP[string], 5)
+
+	require.NoError(t, ports.Connect(toBatch.InPort, inCh))
+	require.NoError(t, ports.Connect(toBatch.OutPort, batchCh))
+	require.NoError(t, ports.Connect(toBrackets.InPort, batchCh))
+	require.NoError(t, ports.Connect(toBrackets.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- toBatch.Process(ctx)
+	}()
+
+	open := ip.NewOpenBracket[string]()
+	open.SetMetadata("group", "letters")
+	input := []*ip.IP[string]{
+		open,
+		ip.New("a"),
+		ip.New("b"),
+		ip.New("c"),
+		ip.NewCloseBracket[string](),
+	}
+	for _, packet := range input {
+		require.NoError(t, toBatch.InPort.Send(ctx, packet))
+	}
+
+	// Verify the batch
+	var batch *ip.IP[[]string]
+	select {
+	case batch = <-batchCh:
+		assert.Equal(t, []string{"a", "b", "c"}, batch.Data())
+		group, ok := batch.GetMetadata("group")
+		assert.True(t, ok)
+		assert.Equal(t, "letters", group)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for batch")
+	}
+
+	// Feed the batch back through BatchToBrackets
+	go func() {
+		errCh <- toBrackets.Process(ctx)
+	}()
+	batchCh <- batch
+
+	expected := []struct {
+		typ  ip.Type
+		data string
+	}{
+		{ip.TypeBracketOpen, ""},
+		{ip.TypeNormal, "a"},
+		{ip.TypeNormal, "b"},
+		{ip.TypeNormal, "c"},
+		{ip.TypeBracketClose, ""},
+	}
+	for _, want := range expected {
+		select {
+		case packet := <-outCh:
+			assert.Equal(t, want.typ, packet.Type())
+			assert.Equal(t, want.data, packet.Data())
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for bracketed output")
+		}
+	}
+
+	cancel()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errCh:
+			assert.Equal(t, context.Canceled, err)
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for shutdown")
+		}
+	}
+}
+
+func TestBracketsToBatchNestingAndLoosePackets(t *testing.T) {
+	toBatch := NewBracketsToBatch[int]()
+
+	inCh := make(chan *ip.IP[int], 8)
+	outCh := make(chan *ip.IP[[]int], 2)
+	require.NoError(t, ports.Connect(toBatch.InPort, inCh))
+	require.NoError(t, ports.Connect(toBatch.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	go func() {
+		_ = toBatch.Process(ctx)
+	}()
+
+	for _, packet := range []*ip.IP[int]{
+		ip.New(7),
+		ip.NewOpenBracket[int](),
+		ip.New(1),
+		ip.NewOpenBracket[int](),
+		ip.New(2),
+		ip.NewCloseBracket[int](),
+		ip.New(3),
+		ip.NewCloseBracket[int](),
+	} {
+		require.NoError(t, toBatch.InPort.Send(ctx, packet))
+	}
+
+	for _, want := range [][]int{{7}, {1, 2, 3}} {
+		select {
+		case packet := <-outCh:
+			assert.Equal(t, want, packet.Data())
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for batch")
+		}
+	}
+}
+
+func TestBracketsToBatchMaxDepth(t *testing.T) {
+	toBatch := NewBracketsToBatch[int]()
+	toBatch.Tracker.SetMaxDepth(2)
+
+	inCh := make(chan *ip.IP[int], 3)
+	outCh := make(chan *ip.IP[[]int], 1)
+	require.NoError(t, ports.Connect(toBatch.InPort, inCh))
+	require.NoError(t, ports.Connect(toBatch.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- toBatch.Process(ctx)
+	}()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, toBatch.InPort.Send(ctx, ip.NewOpenBracket[int]()))
+	}
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, ip.ErrBracketTooDeep)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for depth error")
+	}
+}
@@ -0,0 +1,90 @@
+package flow
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGracefulStopEndsStreams(t *testing.T) {
+	filter := NewFilter(func(s string) bool { return s != "" })
+	upper := transform.NewMapper[string, string](strings.ToUpper)
+
+	src := make(chan *ip.IP[string], 10)
+	mid := make(chan *ip.IP[string], 10)
+	out := make(chan *ip.IP[string], 10)
+	require.NoError(t, ports.Connect(filter.InPort, src))
+	require.NoError(t, ports.Connect(filter.OutPort, mid))
+	require.NoError(t, ports.Connect(upper.InPort, mid))
+	require.NoError(t, ports.Connect(upper.OutPort, out))
+
+	net := network.New()
+	net.AddProcess(filter)
+	net.AddProcess(upper)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- net.Start(ctx) }()
+
+	src <- ip.New("a")
+	src <- ip.New("")
+	src <- ip.New("b")
+	for _, want := range []string{"A", "B"} {
+		select {
+		case packet := <-out:
+			assert.Equal(t, want, packet.Data())
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for output")
+		}
+	}
+
+	// A graceful stop closes the streams instead of cancelling the nodes
+	_, err := net.Stop(ctx)
+	require.NoError(t, err)
+	close(src)
+	close(mid)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "end of stream is not a failure")
+	case <-ctx.Done():
+		t.Fatal("network did not stop at the end of its streams")
+	}
+	assert.NoError(t, ctx.Err(), "nodes must exit on their own, not by cancellation")
+}
+
+func TestNodesReturnNilAtEndOfStream(t *testing.T) {
+	for name, node := range map[string]interface {
+		Process(context.Context) error
+	}{
+		"filter": NewFilter(func(string) bool { return true }),
+		"gate":   NewGate[string]("ok"),
+		"dedup":  NewTimeDedup(func(s string) string { return s }, time.Minute),
+		"demux":  NewDemux[string](),
+	} {
+		t.Run(name, func(t *testing.T) {
+			base := node.(interface {
+				Input() *ports.Port[string]
+				Output() *ports.Port[string]
+			})
+			in := make(chan *ip.IP[string])
+			require.NoError(t, ports.Connect(base.Input(), in))
+			require.NoError(t, ports.Connect(base.Output(), make(chan *ip.IP[string], 1)))
+			close(in)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			assert.NoError(t, node.Process(ctx))
+		})
+	}
+}
@@ -35,7 +35,7 @@ func (f *Filter[T]) Process(ctx context.Context) error {
 		default:
 			packet, err := f.InPort.Receive(ctx)
 			if err != nil {
-				return err
+				return nodes.EndOfStream(err)
 			}
 
 			if f.Predicate(packet.Data()) {
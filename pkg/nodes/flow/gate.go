@@ -0,0 +1,117 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// Gate forwards packets whose metadata value under MetaKey is truthy and
+// drops the rest. Forwarded packets keep their metadata.
+type Gate[T any] struct {
+	*nodes.BaseNode[T, T]
+	MetaKey string
+}
+
+// NewGate creates a new gate node
+func NewGate[T any](metaKey string) *Gate[T] {
+	return &Gate[T]{
+		BaseNode: nodes.NewBaseNode[T, T]("Gate"),
+		MetaKey:  metaKey,
+	}
+}
+
+// Process implements the processing logic
+func (g *Gate[T]) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := g.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			value, _ := packet.GetMetadata(g.MetaKey)
+			if !truthy(value) {
+				continue
+			}
+			if err := g.OutPort.Send(ctx, packet); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// truthy reports whether a metadata value counts as set: true booleans,
+// non-zero numbers, and strings that parse as true (or are non-empty and
+// not a boolean) are truthy; nil and zero values are not
+func truthy(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+		return v != ""
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return !rv.IsZero()
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return !rv.IsNil()
+	}
+	return true
+}
+
+// MetadataEnricher stamps metadata computed from each packet's data onto
+// the packet and forwards it with its payload unchanged
+type MetadataEnricher[T any] struct {
+	*nodes.BaseNode[T, T]
+	Enrich func(T) map[string]any
+}
+
+// NewMetadataEnricher creates a new metadata enricher node
+func NewMetadataEnricher[T any](enrich func(T) map[string]any) *MetadataEnricher[T] {
+	return &MetadataEnricher[T]{
+		BaseNode: nodes.NewBaseNode[T, T]("MetadataEnricher"),
+		Enrich:   enrich,
+	}
+}
+
+// Process implements the processing logic
+func (e *MetadataEnricher[T]) Process(ctx context.Context) error {
+	if e.Enrich == nil {
+		return fmt.Errorf("nil enrich function")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := e.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			for k, v := range e.Enrich(packet.Data()) {
+				packet.SetMetadata(k, v)
+			}
+			if err := e.OutPort.Send(ctx, packet); err != nil {
+				return err
+			}
+		}
+	}
+}
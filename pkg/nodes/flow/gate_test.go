@@ -0,0 +1,107 @@
+package flow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGate(t *testing.T) {
+	gate := NewGate[string]("approved")
+
+	inCh := make(chan *ip.IP[string], 10)
+	outCh := make(chan *ip.IP[string], 10)
+	require.NoError(t, ports.Connect(gate.InPort, inCh))
+	require.NoError(t, ports.Connect(gate.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- gate.Process(ctx)
+	}()
+
+	send := func(data string, value any, set bool) {
+		packet := ip.New(data)
+		if set {
+			packet.SetMetadata("approved", value)
+		}
+		require.NoError(t, gate.InPort.Send(ctx, packet))
+	}
+
+	send("bool true", true, true)
+	send("bool false", false, true)
+	send("missing", nil, false)
+	send("string true", "true", true)
+	send("string false", "false", true)
+	send("zero", 0, true)
+	send("one", 1, true)
+	send("nil", nil, true)
+
+	var passed []string
+	for len(passed) < 3 {
+		select {
+		case packet := <-outCh:
+			passed = append(passed, packet.Data())
+			v, _ := packet.GetMetadata("approved")
+			assert.NotNil(t, v, "metadata should be preserved")
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for output")
+		}
+	}
+	assert.Equal(t, []string{"bool true", "string true", "one"}, passed)
+
+	select {
+	case packet := <-outCh:
+		t.Fatalf("unexpected packet %q", packet.Data())
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+}
+
+func TestMetadataEnricherWithGate(t *testing.T) {
+	enricher := NewMetadataEnricher(func(n int) map[string]any {
+		return map[string]any{"even": n%2 == 0, "source": "counter"}
+	})
+	gate := NewGate[int]("even")
+
+	inCh := make(chan *ip.IP[int], 10)
+	midCh := make(chan *ip.IP[int], 10)
+	outCh := make(chan *ip.IP[int], 10)
+	require.NoError(t, ports.Connect(enricher.InPort, inCh))
+	require.NoError(t, ports.Connect(enricher.OutPort, midCh))
+	require.NoError(t, ports.Connect(gate.InPort, midCh))
+	require.NoError(t, ports.Connect(gate.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go func() {
+		_ = enricher.Process(ctx)
+	}()
+	go func() {
+		_ = gate.Process(ctx)
+	}()
+
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, enricher.InPort.Send(ctx, ip.New(i)))
+	}
+
+	for _, expected := range []int{2, 4} {
+		select {
+		case packet := <-outCh:
+			assert.Equal(t, expected, packet.Data())
+			source, _ := packet.GetMetadata("source")
+			assert.Equal(t, "counter", source)
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for output")
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// MetadataStream is the metadata key holding a packet's stream tag
+const MetadataStream = "stream"
+
+// Mux interleaves several logical streams onto one output.
+//
+// Each stream gets its own input port from Stream, and packets received on
+// it are stamped with the stream's tag under MetadataStream. Packets on the
+// node's InPort are expected to carry a tag already and are forwarded as
+// they are, so muxes can be chained.
+type Mux[T any] struct {
+	*nodes.BaseNode[T, T]
+	streams map[string]*ports.Port[T]
+	mu      sync.Mutex
+}
+
+// NewMux creates a new mux node
+func NewMux[T any]() *Mux[T] {
+	return &Mux[T]{
+		BaseNode: nodes.NewBaseNode[T, T]("Mux", nodes.WithOptionalInput()),
+		streams:  make(map[string]*ports.Port[T]),
+	}
+}
+
+// Stream returns the input port for the stream tagged tag, creating it on
+// first use
+func (m *Mux[T]) Stream(tag string) *ports.Port[T] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return streamPort(m.streams, tag, ports.NewInput[T])
+}
+
+// Streams returns the tags of the mux's streams in sorted order
+func (m *Mux[T]) Streams() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return streamTags(m.streams)
+}
+
+// Process implements the processing logic
+func (m *Mux[T]) Process(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	m.mu.Lock()
+	inputs := make(map[string]*ports.Port[T], len(m.streams))
+	for tag, port := range m.streams {
+		inputs[tag] = port
+	}
+	m.mu.Unlock()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	forward := func(tag string, port *ports.Port[T]) {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				packet, err := port.Receive(ctx)
+				if errors.Is(err, ports.ErrStreamClosed) {
+					return // this stream ended; the others carry on
+				}
+				if err == nil && tag != "" {
+					packet.SetMetadata(MetadataStream, tag)
+				}
+				if err == nil {
+					err = m.OutPort.Send(ctx, packet)
+				}
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+			}
+		}
+	}
+
+	started := 0
+	if m.InPort.Connected() {
+		wg.Add(1)
+		started++
+		go forward("", m.InPort)
+	}
+	for tag, port := range inputs {
+		if !port.Connected() {
+			continue
+		}
+		wg.Add(1)
+		started++
+		go forward(tag, port)
+	}
+
+	if started == 0 {
+		return fmt.Errorf("no streams connected")
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// Demux routes packets to per-stream outputs by the tag under
+// MetadataStream, reversing a Mux.
+//
+// Output ports are created on demand by Stream. Packets that are untagged,
+// or tagged for a stream without an output, go to the node's OutPort.
+type Demux[T any] struct {
+	*nodes.BaseNode[T, T]
+	streams map[string]*ports.Port[T]
+	mu      sync.Mutex
+}
+
+// NewDemux creates a new demux node
+func NewDemux[T any]() *Demux[T] {
+	return &Demux[T]{
+		BaseNode: nodes.NewBaseNode[T, T]("Demux", nodes.WithOptionalOutput()),
+		streams:  make(map[string]*ports.Port[T]),
+	}
+}
+
+// Stream returns the output port for the stream tagged tag, creating it on
+// first use
+func (d *Demux[T]) Stream(tag string) *ports.Port[T] {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return streamPort(d.streams, tag, ports.NewOutput[T])
+}
+
+// Streams returns the tags of the demux's streams in sorted order
+func (d *Demux[T]) Streams() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return streamTags(d.streams)
+}
+
+// Process implements the processing logic
+func (d *Demux[T]) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := d.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			if err := d.route(packet).Send(ctx, packet); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// route picks the output for a packet's stream tag
+func (d *Demux[T]) route(packet *ip.IP[T]) *ports.Port[T] {
+	if s, ok := packet.GetStringMeta(MetadataStream); ok {
+		d.mu.Lock()
+		port, exists := d.streams[s]
+		d.mu.Unlock()
+		if exists {
+			return port
+		}
+	}
+	return d.OutPort
+}
+
+// streamPort returns the port for tag, creating it with newPort if needed;
+// the caller must hold the streams lock
+func streamPort[T any](streams map[string]*ports.Port[T], tag string,
+	newPort func(name, description string, required bool) *ports.Port[T]) *ports.Port[T] {
+	if port, exists := streams[tag]; exists {
+		return port
+	}
+	port := newPort("stream:"+tag, "Packets of stream "+tag, false)
+	streams[tag] = port
+	return port
+}
+
+// streamTags lists the tags of streams in sorted order; the caller must
+// hold the streams lock
+func streamTags[T any](streams map[string]*ports.Port[T]) []string {
+	tags := make([]string, 0, len(streams))
+	for tag := range streams {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,107 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMuxDemux(t *testing.T) {
+	mux := NewMux[string]()
+	demux := NewDemux[string]()
+
+	aCh := make(chan *ip.IP[string], 10)
+	bCh := make(chan *ip.IP[string], 10)
+	require.NoError(t, ports.Connect(mux.Stream("a"), aCh))
+	require.NoError(t, ports.Connect(mux.Stream("b"), bCh))
+	assert.Equal(t, []string{"a", "b"}, mux.Streams())
+
+	// One connection carries both streams
+	wire := make(chan *ip.IP[string], 20)
+	require.NoError(t, ports.Connect(mux.OutPort, wire))
+	require.NoError(t, ports.Connect(demux.InPort, wire))
+
+	aOut := make(chan *ip.IP[string], 10)
+	bOut := make(chan *ip.IP[string], 10)
+	restOut := make(chan *ip.IP[string], 10)
+	require.NoError(t, ports.Connect(demux.Stream("a"), aOut))
+	require.NoError(t, ports.Connect(demux.Stream("b"), bOut))
+	require.NoError(t, ports.Connect(demux.OutPort, restOut))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	muxErr := make(chan error, 1)
+	demuxErr := make(chan error, 1)
+	go func() { muxErr <- mux.Process(ctx) }()
+	go func() { demuxErr <- demux.Process(ctx) }()
+
+	for i := 0; i < 5; i++ {
+		aCh <- ip.New(fmt.Sprintf("a%d", i))
+	}
+	for i := 0; i < 3; i++ {
+		bCh <- ip.New(fmt.Sprintf("b%d", i))
+	}
+
+	collect := func(ch chan *ip.IP[string], n int, tag string) []string {
+		var got []string
+		for len(got) < n {
+			select {
+			case packet := <-ch:
+				stream, _ := packet.GetMetadata(MetadataStream)
+				assert.Equal(t, tag, stream)
+				got = append(got, packet.Data())
+			case <-time.After(time.Second):
+				t.Fatalf("timeout waiting for stream %s", tag)
+			}
+		}
+		return got
+	}
+
+	// Per-stream order survives the interleaving
+	assert.Equal(t, []string{"a0", "a1", "a2", "a3", "a4"}, collect(aOut, 5, "a"))
+	assert.Equal(t, []string{"b0", "b1", "b2"}, collect(bOut, 3, "b"))
+
+	select {
+	case packet := <-restOut:
+		t.Fatalf("unexpected packet %q on the fallback output", packet.Data())
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-muxErr, context.Canceled)
+	assert.ErrorIs(t, <-demuxErr, context.Canceled)
+}
+
+func TestDemuxUnknownStream(t *testing.T) {
+	demux := NewDemux[string]()
+
+	inCh := make(chan *ip.IP[string], 10)
+	restOut := make(chan *ip.IP[string], 10)
+	require.NoError(t, ports.Connect(demux.InPort, inCh))
+	require.NoError(t, ports.Connect(demux.OutPort, restOut))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go demux.Process(ctx)
+
+	tagged := ip.New("tagged")
+	tagged.SetMetadata(MetadataStream, "unknown")
+	inCh <- tagged
+	inCh <- ip.New("untagged")
+
+	for _, want := range []string{"tagged", "untagged"} {
+		select {
+		case packet := <-restOut:
+			assert.Equal(t, want, packet.Data())
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for fallback output")
+		}
+	}
+}
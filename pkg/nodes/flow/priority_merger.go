@@ -0,0 +1,215 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// DefaultMaxSkips is how many times in a row a PriorityMerger passes over
+// a ready input in favor of higher-priority ones before serving it
+const DefaultMaxSkips = 16
+
+// PriorityInput names one input of a PriorityMerger. Inputs with a higher
+// Priority are drained first.
+type PriorityInput struct {
+	Name     string
+	Priority int
+}
+
+// PriorityMerger merges several inputs onto one output, forwarding the
+// packets of higher-priority inputs first whenever several inputs have
+// packets queued, as when one stream is latency sensitive. Inputs of equal
+// priority are served in the order they were given. The node's InPort,
+// when connected, is an input of priority 0.
+//
+// So that busy high-priority inputs cannot starve the others, a ready
+// input passed over MaxSkips times in a row is served next. A MaxSkips of
+// 0 gives strict priority. Priorities apply to packets queued in buffered
+// connections; the merger ends when every input's stream has ended.
+type PriorityMerger[T any] struct {
+	*nodes.BaseNode[T, T]
+	MaxSkips int
+	inputs   []priorityInput[T]
+}
+
+type priorityInput[T any] struct {
+	port     *ports.Port[T]
+	priority int
+}
+
+// priorityState is the merge state of one input during Process
+type priorityState[T any] struct {
+	port    *ports.Port[T]
+	held    *ip.IP[T]
+	done    bool
+	skipped int
+}
+
+// NewPriorityMerger creates a new priority merger with an input port named
+// after each input. Like nodes.NewBaseNode, it panics if two ports share a
+// name.
+func NewPriorityMerger[T any](inputs []PriorityInput) *PriorityMerger[T] {
+	m := &PriorityMerger[T]{MaxSkips: DefaultMaxSkips}
+	extra := make([]ports.AnyPort, len(inputs))
+	for i, input := range inputs {
+		port := ports.NewInput[T](input.Name, "Packets of priority input "+input.Name, false)
+		m.inputs = append(m.inputs, priorityInput[T]{port: port, priority: input.Priority})
+		extra[i] = port
+	}
+	m.BaseNode = nodes.NewBaseNode[T, T]("PriorityMerger", nodes.WithOptionalInput(), nodes.WithPorts(extra...))
+	return m
+}
+
+// Process implements the processing logic
+func (m *PriorityMerger[T]) Process(ctx context.Context) error {
+	inputs := append([]priorityInput[T](nil), m.inputs...)
+	inputs = append(inputs, priorityInput[T]{port: m.InPort})
+	sort.SliceStable(inputs, func(i, j int) bool {
+		return inputs[i].priority > inputs[j].priority
+	})
+
+	var states []*priorityState[T]
+	for _, input := range inputs {
+		if input.port.Connected() {
+			states = append(states, &priorityState[T]{port: input.port})
+		}
+	}
+	if len(states) == 0 {
+		return fmt.Errorf("no inputs connected")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			next := m.pick(states)
+			if next == nil {
+				if allDone(states) {
+					return nil
+				}
+				if err := waitReady(ctx, states); err != nil {
+					return err
+				}
+				continue
+			}
+
+			packet := next.held
+			next.held = nil
+			if packet == nil {
+				var err error
+				packet, err = next.port.Receive(ctx)
+				if errors.Is(err, ports.ErrStreamClosed) {
+					next.done = true
+					continue
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if err := m.OutPort.Send(ctx, packet); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// pick chooses the input to serve next among those with a packet ready:
+// the highest-priority one passed over MaxSkips times, otherwise the
+// highest-priority one. It returns nil when no input is ready.
+func (m *PriorityMerger[T]) pick(states []*priorityState[T]) *priorityState[T] {
+	var ready []*priorityState[T]
+	for _, s := range states {
+		if !s.done && (s.held != nil || s.port.Len() > 0) {
+			ready = append(ready, s)
+		}
+	}
+	if len(ready) == 0 {
+		return nil
+	}
+
+	next := ready[0]
+	if m.MaxSkips > 0 {
+		for _, s := range ready {
+			if s.skipped >= m.MaxSkips {
+				next = s
+				break
+			}
+		}
+	}
+	for _, s := range ready {
+		if s != next {
+			s.skipped++
+		}
+	}
+	next.skipped = 0
+	return next
+}
+
+func allDone[T any](states []*priorityState[T]) bool {
+	for _, s := range states {
+		if !s.done {
+			return false
+		}
+	}
+	return true
+}
+
+// waitReady blocks until some input receives a packet or ends. Every
+// waiting input receives concurrently, so more than one may end up
+// holding a packet; those are kept for pick.
+func waitReady[T any](ctx context.Context, states []*priorityState[T]) error {
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		state  *priorityState[T]
+		packet *ip.IP[T]
+		err    error
+	}
+	results := make(chan result, len(states))
+	var wg sync.WaitGroup
+	for _, s := range states {
+		if s.done || s.held != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(s *priorityState[T]) {
+			defer wg.Done()
+			packet, err := s.port.Receive(waitCtx)
+			results <- result{state: s, packet: packet, err: err}
+		}(s)
+	}
+
+	// The first result wakes the merger; the other receives are then
+	// cancelled, though each may still have taken a packet
+	all := []result{<-results}
+	cancel()
+	wg.Wait()
+	close(results)
+	for r := range results {
+		all = append(all, r)
+	}
+
+	var firstErr error
+	for _, r := range all {
+		r.state.held = r.packet
+		switch {
+		case r.err == nil:
+		case errors.Is(r.err, ports.ErrStreamClosed):
+			r.state.done = true
+		case errors.Is(r.err, context.Canceled) && ctx.Err() == nil:
+			// cancelled here once another input was ready
+		case firstErr == nil:
+			firstErr = r.err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,89 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityMerger(t *testing.T) {
+	const count = 5
+	run := func(t *testing.T, maxSkips int) []string {
+		merger := NewPriorityMerger[string]([]PriorityInput{
+			{Name: "low", Priority: 1},
+			{Name: "high", Priority: 10},
+		})
+		merger.MaxSkips = maxSkips
+
+		// Both inputs are full before the merger starts
+		for _, name := range []string{"low", "high"} {
+			port, err := nodes.LookupPort[string](merger, name)
+			require.NoError(t, err)
+			ch := make(chan *ip.IP[string], count)
+			for i := 0; i < count; i++ {
+				ch <- ip.New(fmt.Sprintf("%s%d", name[:1], i))
+			}
+			close(ch)
+			require.NoError(t, ports.Connect(port, ch))
+		}
+		out := make(chan *ip.IP[string], 2*count)
+		require.NoError(t, ports.Connect(merger.OutPort, out))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, merger.Process(ctx), "the merger ends with its inputs")
+
+		close(out)
+		var got []string
+		for packet := range out {
+			got = append(got, packet.Data())
+		}
+		return got
+	}
+
+	t.Run("high priority drains first", func(t *testing.T) {
+		got := run(t, 0)
+		assert.Equal(t, []string{"h0", "h1", "h2", "h3", "h4", "l0", "l1", "l2", "l3", "l4"}, got)
+	})
+
+	t.Run("low priority is not starved", func(t *testing.T) {
+		got := run(t, 2)
+		assert.Equal(t, "h0", got[0])
+		assert.Equal(t, []string{"h0", "h1", "l0", "h2", "h3", "l1", "h4", "l2", "l3", "l4"}, got)
+	})
+}
+
+func TestPriorityMergerWaitsForInput(t *testing.T) {
+	merger := NewPriorityMerger[string]([]PriorityInput{{Name: "a", Priority: 1}})
+	a := make(chan *ip.IP[string])
+	require.NoError(t, ports.Connect(merger.InPort, make(chan *ip.IP[string])))
+	port, err := nodes.LookupPort[string](merger, "a")
+	require.NoError(t, err)
+	require.NoError(t, ports.Connect(port, a))
+	out := make(chan *ip.IP[string], 1)
+	require.NoError(t, ports.Connect(merger.OutPort, out))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- merger.Process(ctx) }()
+
+	// Unbuffered inputs are only received once the merger is idle
+	a <- ip.New("late")
+	select {
+	case packet := <-out:
+		assert.Equal(t, "late", packet.Data())
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for packet")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,95 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// Reorder restores ascending sequence order after processing that
+// scrambles it, such as a ParallelMapper.
+//
+// The first packet received sets the expected sequence. Packets ahead of
+// it are buffered until the gap is filled. Once more than Window packets
+// are buffered the node stops waiting for the missing sequence, logs the
+// skip and resumes from the lowest buffered one. Packets older than the
+// expected sequence arrive too late to be ordered and are emitted at once.
+type Reorder[T any] struct {
+	*nodes.BaseNode[T, T]
+	Seq    func(T) int64
+	Window int
+}
+
+// NewReorder creates a new reorder node
+func NewReorder[T any](seqFn func(T) int64, window int) *Reorder[T] {
+	return &Reorder[T]{
+		BaseNode: nodes.NewBaseNode[T, T]("Reorder"),
+		Seq:      seqFn,
+		Window:   window,
+	}
+}
+
+// Process implements the processing logic
+func (r *Reorder[T]) Process(ctx context.Context) error {
+	if r.Seq == nil {
+		return fmt.Errorf("nil sequence function")
+	}
+
+	var (
+		pending []*ip.IP[T] // sorted by sequence
+		next    int64
+		started bool
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := r.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			seq := r.Seq(packet.Data())
+			if !started {
+				next, started = seq, true
+			}
+
+			if seq < next {
+				if err := r.OutPort.Send(ctx, packet); err != nil {
+					return err
+				}
+				continue
+			}
+
+			i := sort.Search(len(pending), func(i int) bool {
+				return r.Seq(pending[i].Data()) > seq
+			})
+			pending = append(pending, nil)
+			copy(pending[i+1:], pending[i:])
+			pending[i] = packet
+
+			// Give up on the gap once the window is full
+			if len(pending) > r.Window {
+				if first := r.Seq(pending[0].Data()); first > next {
+					log.Printf("%s: skipping sequence %d-%d", r.Name(), next, first-1)
+					next = first
+				}
+			}
+
+			// Emit everything that is now contiguous
+			for len(pending) > 0 && r.Seq(pending[0].Data()) <= next {
+				if err := r.OutPort.Send(ctx, pending[0]); err != nil {
+					return err
+				}
+				next = r.Seq(pending[0].Data()) + 1
+				pending = pending[1:]
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package flow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReorder(t *testing.T) {
+	identity := func(n int64) int64 { return n }
+
+	run := func(t *testing.T, window int, input []int64, want int) []int64 {
+		reorder := NewReorder(identity, window)
+
+		inCh := make(chan *ip.IP[int64], len(input))
+		outCh := make(chan *ip.IP[int64], len(input))
+		require.NoError(t, ports.Connect(reorder.InPort, inCh))
+		require.NoError(t, ports.Connect(reorder.OutPort, outCh))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		go func() {
+			_ = reorder.Process(ctx)
+		}()
+
+		for _, n := range input {
+			require.NoError(t, reorder.InPort.Send(ctx, ip.New(n)))
+		}
+
+		var got []int64
+		for len(got) < want {
+			select {
+			case packet := <-outCh:
+				got = append(got, packet.Data())
+			case <-time.After(time.Second):
+				t.Fatalf("timeout after %v", got)
+			}
+		}
+
+		select {
+		case packet := <-outCh:
+			t.Fatalf("unexpected packet %d", packet.Data())
+		case <-time.After(50 * time.Millisecond):
+		}
+		return got
+	}
+
+	t.Run("restores order", func(t *testing.T) {
+		assert.Equal(t, []int64{1, 2, 3}, run(t, 10, []int64{1, 3, 2}, 3))
+	})
+
+	t.Run("holds packets until the gap fills", func(t *testing.T) {
+		assert.Equal(t, []int64{1, 2, 3, 4, 5}, run(t, 10, []int64{1, 4, 5, 3, 2}, 5))
+	})
+
+	t.Run("skips a gap when the window is full", func(t *testing.T) {
+		// 2 never arrives; with a window of 2 the third buffered packet
+		// forces the skip
+		assert.Equal(t, []int64{1, 3, 4, 5}, run(t, 2, []int64{1, 3, 4, 5}, 4))
+	})
+
+	t.Run("late packets pass through", func(t *testing.T) {
+		assert.Equal(t, []int64{2, 1}, run(t, 10, []int64{2, 1}, 2))
+	})
+}
@@ -0,0 +1,116 @@
+package flow
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// SizeBatch groups packets into batches by their total estimated size,
+// for sinks such as bulk HTTP APIs that limit request size rather than
+// item count. Items are added until the next would take the batch past
+// MaxBytes, and a batch reaching MaxBytes exactly is sent at once. When
+// Timeout is set, a batch is also sent once its first item has waited
+// that long. The pending batch is sent when the input stream ends.
+//
+// An item larger than MaxBytes on its own is sent alone, with a warning,
+// rather than held back forever.
+type SizeBatch[T any] struct {
+	*nodes.BaseNode[T, []T]
+	MaxBytes int
+	SizeOf   func(T) int
+	Timeout  time.Duration
+}
+
+// NewSizeBatch creates a new size batch node
+func NewSizeBatch[T any](maxBytes int, sizeOf func(T) int, timeout time.Duration) *SizeBatch[T] {
+	return &SizeBatch[T]{
+		BaseNode: nodes.NewBaseNode[T, []T]("SizeBatch"),
+		MaxBytes: maxBytes,
+		SizeOf:   sizeOf,
+		Timeout:  timeout,
+	}
+}
+
+// Process implements the processing logic
+func (b *SizeBatch[T]) Process(ctx context.Context) error {
+	var (
+		batch    []T
+		size     int
+		deadline time.Time
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		packet := ip.New(batch)
+		batch, size = nil, 0
+		return b.OutPort.Send(ctx, packet)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			recvCtx, cancel := ctx, context.CancelFunc(func() {})
+			if b.Timeout > 0 && len(batch) > 0 {
+				recvCtx, cancel = context.WithDeadline(ctx, deadline)
+			}
+			packet, err := b.InPort.Receive(recvCtx)
+			cancel()
+
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if errors.Is(err, context.DeadlineExceeded) {
+					if err := flush(); err != nil {
+						return err
+					}
+					continue
+				}
+				if errors.Is(err, ports.ErrStreamClosed) {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+				return nodes.EndOfStream(err)
+			}
+
+			item := packet.Data()
+			itemSize := b.SizeOf(item)
+			if itemSize > b.MaxBytes {
+				b.Logger().Printf("WARN %s: item of %d bytes exceeds the %d byte limit, sending it alone",
+					b.Name(), itemSize, b.MaxBytes)
+				if err := flush(); err != nil {
+					return err
+				}
+				if err := b.OutPort.Send(ctx, ip.New([]T{item})); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if size+itemSize > b.MaxBytes {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			if len(batch) == 0 {
+				deadline = time.Now().Add(b.Timeout)
+			}
+			batch = append(batch, item)
+			size += itemSize
+			if size == b.MaxBytes {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
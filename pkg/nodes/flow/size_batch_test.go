@@ -0,0 +1,96 @@
+package flow
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runSizeBatch(t *testing.T, b *SizeBatch[string], input []string) [][]string {
+	inCh := make(chan *ip.IP[string], len(input))
+	outCh := make(chan *ip.IP[[]string], len(input))
+	require.NoError(t, ports.Connect(b.InPort, inCh))
+	require.NoError(t, ports.Connect(b.OutPort, outCh))
+	for _, item := range input {
+		inCh <- ip.New(item)
+	}
+	close(inCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, b.Process(ctx))
+	close(outCh)
+
+	var batches [][]string
+	for packet := range outCh {
+		batches = append(batches, packet.Data())
+	}
+	return batches
+}
+
+func byteLen(s string) int {
+	return len(s)
+}
+
+func TestSizeBatch(t *testing.T) {
+	t.Run("flushes when the limit is reached", func(t *testing.T) {
+		b := NewSizeBatch(10, byteLen, 0)
+		batches := runSizeBatch(t, b, []string{"aaaa", "bbbb", "cccc", "dd", "eeeeee", "f"})
+		assert.Equal(t, [][]string{
+			{"aaaa", "bbbb"}, // cccc would take the batch past 10
+			{"cccc", "dd"},   // as would eeeeee
+			{"eeeeee", "f"},  // sent when the stream ends
+		}, batches)
+	})
+
+	t.Run("exact fit flushes at once", func(t *testing.T) {
+		b := NewSizeBatch(6, byteLen, 0)
+		batches := runSizeBatch(t, b, []string{"abc", "def", "g"})
+		assert.Equal(t, [][]string{{"abc", "def"}, {"g"}}, batches)
+	})
+
+	t.Run("oversized item is sent alone", func(t *testing.T) {
+		var logs bytes.Buffer
+		b := NewSizeBatch(4, byteLen, 0)
+		b.SetLogger(log.New(&logs, "", 0))
+
+		batches := runSizeBatch(t, b, []string{"ab", strings.Repeat("x", 9), "cd"})
+		assert.Equal(t, [][]string{{"ab"}, {strings.Repeat("x", 9)}, {"cd"}}, batches)
+		assert.Contains(t, logs.String(), "WARN SizeBatch: item of 9 bytes exceeds the 4 byte limit")
+	})
+}
+
+func TestSizeBatchTimeout(t *testing.T) {
+	b := NewSizeBatch(100, byteLen, 20*time.Millisecond)
+	inCh := make(chan *ip.IP[string], 2)
+	outCh := make(chan *ip.IP[[]string], 2)
+	require.NoError(t, ports.Connect(b.InPort, inCh))
+	require.NoError(t, ports.Connect(b.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- b.Process(ctx)
+	}()
+
+	inCh <- ip.New("a")
+	inCh <- ip.New("b")
+	select {
+	case packet := <-outCh:
+		assert.Equal(t, []string{"a", "b"}, packet.Data())
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for partial batch")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-errCh, context.Canceled)
+}
@@ -0,0 +1,99 @@
+package flow
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// TimeDedup drops packets whose key was already seen within Window.
+//
+// The window starts when a key is first passed; duplicates arriving inside
+// it are dropped without extending it. Expired keys are evicted as packets
+// arrive, so memory is bounded by the number of distinct keys per window.
+type TimeDedup[T any] struct {
+	*nodes.BaseNode[T, T]
+	KeyFn  func(T) string
+	Window time.Duration
+
+	seen   map[string]time.Time // key -> expiry
+	expiry []dedupEntry         // keys in expiry order
+	now    func() time.Time
+}
+
+// dedupEntry is a key and when its window ends
+type dedupEntry struct {
+	key     string
+	expires time.Time
+}
+
+// NewTimeDedup creates a new time-windowed deduplication node
+func NewTimeDedup[T any](keyFn func(T) string, window time.Duration) *TimeDedup[T] {
+	return &TimeDedup[T]{
+		BaseNode: nodes.NewBaseNode[T, T]("TimeDedup"),
+		KeyFn:    keyFn,
+		Window:   window,
+		seen:     make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Seen reports whether key was passed within the window ending now,
+// recording it if not
+func (d *TimeDedup[T]) Seen(key string) bool {
+	now := d.now()
+	d.evict(now)
+
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+
+	expires := now.Add(d.Window)
+	d.seen[key] = expires
+	d.expiry = append(d.expiry, dedupEntry{key: key, expires: expires})
+	return false
+}
+
+// Len returns the number of keys currently remembered
+func (d *TimeDedup[T]) Len() int {
+	return len(d.seen)
+}
+
+// evict forgets keys whose window has ended
+func (d *TimeDedup[T]) evict(now time.Time) {
+	i := 0
+	for ; i < len(d.expiry) && !now.Before(d.expiry[i].expires); i++ {
+		delete(d.seen, d.expiry[i].key)
+	}
+	if i > 0 {
+		d.expiry = append(d.expiry[:0], d.expiry[i:]...)
+	}
+}
+
+// Process implements the processing logic
+func (d *TimeDedup[T]) Process(ctx context.Context) error {
+	if d.KeyFn == nil {
+		return fmt.Errorf("nil key function")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := d.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			if d.Seen(d.KeyFn(packet.Data())) {
+				continue
+			}
+			if err := d.OutPort.Send(ctx, packet); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package flow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeDedupWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	dedup := NewTimeDedup[string](func(s string) string { return s }, time.Minute)
+	dedup.now = func() time.Time { return now }
+
+	assert.False(t, dedup.Seen("a"))
+	assert.False(t, dedup.Seen("b"))
+
+	now = now.Add(30 * time.Second)
+	assert.True(t, dedup.Seen("a"), "duplicate within the window")
+
+	now = now.Add(30 * time.Second)
+	assert.False(t, dedup.Seen("a"), "same key after the window elapsed")
+	assert.Equal(t, 1, dedup.Len(), "expired key b should be evicted")
+}
+
+func TestTimeDedup(t *testing.T) {
+	type event struct {
+		ID  string
+		Seq int
+	}
+	dedup := NewTimeDedup[event](func(e event) string { return e.ID }, 50*time.Millisecond)
+
+	inCh := make(chan *ip.IP[event], 5)
+	outCh := make(chan *ip.IP[event], 5)
+	require.NoError(t, ports.Connect(dedup.InPort, inCh))
+	require.NoError(t, ports.Connect(dedup.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- dedup.Process(ctx)
+	}()
+
+	inCh <- ip.New(event{ID: "x", Seq: 1})
+	inCh <- ip.New(event{ID: "x", Seq: 2})
+	inCh <- ip.New(event{ID: "y", Seq: 3})
+
+	for _, want := range []int{1, 3} {
+		select {
+		case packet := <-outCh:
+			assert.Equal(t, want, packet.Data().Seq)
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for output")
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	inCh <- ip.New(event{ID: "x", Seq: 4})
+
+	select {
+	case packet := <-outCh:
+		assert.Equal(t, 4, packet.Data().Seq)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for output after the window")
+	}
+	assert.Empty(t, outCh)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+}
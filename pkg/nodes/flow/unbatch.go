@@ -0,0 +1,80 @@
+package flow
+
+import (
+	"context"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// MetadataItems is the batch metadata key holding per-item metadata, a
+// []map[string]any aligned with the batch elements
+const MetadataItems = "items"
+
+// Unbatch emits each element of a batch IP as its own IP.
+//
+// Metadata on the batch is copied to every item, and per-item metadata
+// recorded under MetadataItems is restored on top of it. With Brackets set,
+// each batch's items are wrapped in an open/close bracket pair carrying the
+// batch metadata.
+type Unbatch[T any] struct {
+	*nodes.BaseNode[[]T, T]
+	Brackets bool
+}
+
+// NewUnbatch creates a new unbatch node
+func NewUnbatch[T any]() *Unbatch[T] {
+	return &Unbatch[T]{
+		BaseNode: nodes.NewBaseNode[[]T, T]("Unbatch"),
+	}
+}
+
+// Process implements the processing logic
+func (u *Unbatch[T]) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := u.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			metadata := userMetadata(packet.Metadata())
+			itemMeta, _ := metadata[MetadataItems].([]map[string]any)
+			delete(metadata, MetadataItems)
+
+			if u.Brackets {
+				open := ip.NewOpenBracket[T]()
+				for k, v := range metadata {
+					open.SetMetadata(k, v)
+				}
+				if err := u.OutPort.Send(ctx, open); err != nil {
+					return err
+				}
+			}
+
+			for i, data := range packet.Data() {
+				item := ip.New(data)
+				for k, v := range metadata {
+					item.SetMetadata(k, v)
+				}
+				if i < len(itemMeta) {
+					for k, v := range itemMeta[i] {
+						item.SetMetadata(k, v)
+					}
+				}
+				if err := u.OutPort.Send(ctx, item); err != nil {
+					return err
+				}
+			}
+
+			if u.Brackets {
+				if err := u.OutPort.Send(ctx, ip.NewCloseBracket[T]()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
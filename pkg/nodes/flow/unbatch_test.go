@@ -0,0 +1,114 @@
+package flow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchUnbatchRoundTrip(t *testing.T) {
+	toBatch := NewBracketsToBatch[int]()
+	unbatch := NewUnbatch[int]()
+
+	inCh := make(chan *ip.IP[int], 10)
+	batchCh := make(chan *ip.IP[[]int], 1)
+	outCh := make(chan *ip.IP[int], 10)
+
+	require.NoError(t, ports.Connect(toBatch.InPort, inCh))
+	require.NoError(t, ports.Connect(toBatch.OutPort, batchCh))
+	require.NoError(t, ports.Connect(unbatch.InPort, batchCh))
+	require.NoError(t, ports.Connect(unbatch.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 2)
+	go func() {
+		errCh <- toBatch.Process(ctx)
+	}()
+	go func() {
+		errCh <- unbatch.Process(ctx)
+	}()
+
+	open := ip.NewOpenBracket[int]()
+	open.SetMetadata("source", "sensor")
+	require.NoError(t, toBatch.InPort.Send(ctx, open))
+	for i := 1; i <= 4; i++ {
+		item := ip.New(i)
+		if i == 2 {
+			item.SetMetadata("flag", "checked")
+		}
+		require.NoError(t, toBatch.InPort.Send(ctx, item))
+	}
+	require.NoError(t, toBatch.InPort.Send(ctx, ip.NewCloseBracket[int]()))
+
+	for want := 1; want <= 4; want++ {
+		select {
+		case packet := <-outCh:
+			assert.Equal(t, ip.TypeNormal, packet.Type())
+			assert.Equal(t, want, packet.Data())
+
+			source, _ := packet.GetMetadata("source")
+			assert.Equal(t, "sensor", source)
+			_, hasItems := packet.GetMetadata(MetadataItems)
+			assert.False(t, hasItems)
+
+			flag, ok := packet.GetMetadata("flag")
+			assert.Equal(t, want == 2, ok)
+			if ok {
+				assert.Equal(t, "checked", flag)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for item")
+		}
+	}
+
+	cancel()
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, context.Canceled, <-errCh)
+	}
+}
+
+func TestUnbatchBrackets(t *testing.T) {
+	unbatch := NewUnbatch[string]()
+	unbatch.Brackets = true
+
+	inCh := make(chan *ip.IP[[]string], 1)
+	outCh := make(chan *ip.IP[string], 10)
+	require.NoError(t, ports.Connect(unbatch.InPort, inCh))
+	require.NoError(t, ports.Connect(unbatch.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- unbatch.Process(ctx)
+	}()
+
+	batch := ip.New([]string{"x", "y"})
+	batch.SetMetadata("group", "letters")
+	inCh <- batch
+
+	wantTypes := []ip.Type{ip.TypeBracketOpen, ip.TypeNormal, ip.TypeNormal, ip.TypeBracketClose}
+	for i, want := range wantTypes {
+		select {
+		case packet := <-outCh:
+			assert.Equal(t, want, packet.Type())
+			if i == 0 {
+				group, _ := packet.GetMetadata("group")
+				assert.Equal(t, "letters", group)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for output")
+		}
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+}
@@ -0,0 +1,189 @@
+package io
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// AckStore records the ids of packets that have been fully processed.
+//
+// Together, an IdempotencyCheck in front of a pipeline and an AckSink at
+// its end approximate exactly-once processing: replays of acknowledged
+// packets are dropped, and a packet is only acknowledged once its handler
+// succeeded. A store shared between runs must be durable for this to hold
+// across restarts.
+type AckStore interface {
+	// Acked reports whether id has been acknowledged
+	Acked(ctx context.Context, id string) (bool, error)
+	// Ack records id as processed
+	Ack(ctx context.Context, id string) error
+}
+
+// MemoryAckStore is an AckStore held in memory, for tests and for
+// pipelines that only need deduplication within one process
+type MemoryAckStore struct {
+	acked map[string]struct{}
+	mu    sync.RWMutex
+}
+
+// NewMemoryAckStore creates an empty in-memory ack store
+func NewMemoryAckStore() *MemoryAckStore {
+	return &MemoryAckStore{acked: make(map[string]struct{})}
+}
+
+// Acked implements AckStore
+func (s *MemoryAckStore) Acked(_ context.Context, id string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.acked[id]
+	return ok, nil
+}
+
+// Ack implements AckStore
+func (s *MemoryAckStore) Ack(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.acked[id] = struct{}{}
+	return nil
+}
+
+// Len returns the number of acknowledged ids
+func (s *MemoryAckStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.acked)
+}
+
+// IdempotencyCheck drops packets whose id, computed by KeyFn, is already
+// acknowledged in Store and forwards the rest. Store errors are sent to
+// ErrPort and the packet is dropped.
+type IdempotencyCheck[T any] struct {
+	*nodes.BaseNode[T, T]
+	ErrPort *ports.Port[error]
+	KeyFn   func(T) string
+	Store   AckStore
+}
+
+// NewIdempotencyCheck creates a new idempotency check node
+func NewIdempotencyCheck[T any](keyFn func(T) string, store AckStore) *IdempotencyCheck[T] {
+	return &IdempotencyCheck[T]{
+		BaseNode: nodes.NewBaseNode[T, T]("IdempotencyCheck"),
+		ErrPort:  ports.NewOutput[error]("err", "Ack store errors", false),
+		KeyFn:    keyFn,
+		Store:    store,
+	}
+}
+
+// Process implements the processing logic
+func (c *IdempotencyCheck[T]) Process(ctx context.Context) error {
+	if c.KeyFn == nil || c.Store == nil {
+		return fmt.Errorf("idempotency check needs a key function and a store")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := c.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			acked, err := c.Store.Acked(ctx, c.KeyFn(packet.Data()))
+			if err != nil {
+				packetErr := nodes.NewPacketError(c.Name(), packet, err)
+				if err := c.ErrPort.Send(ctx, ip.New[error](packetErr)); err != nil {
+					return err
+				}
+				continue
+			}
+			if acked {
+				continue
+			}
+			if err := c.OutPort.Send(ctx, packet); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// AckSink processes packets with Handle and acknowledges each packet's id
+// in Store once Handle succeeds, emitting the id on OutPort. Ids already
+// acknowledged are skipped, so the sink is idempotent on its own as well.
+// Handler and store errors are sent to ErrPort and leave the packet
+// unacknowledged, so a replay retries it.
+type AckSink[T any] struct {
+	*nodes.BaseNode[T, string]
+	ErrPort *ports.Port[error]
+	KeyFn   func(T) string
+	Handle  func(context.Context, T) error
+	Store   AckStore
+}
+
+// NewAckSink creates a new ack-tracking sink node
+func NewAckSink[T any](keyFn func(T) string, handle func(context.Context, T) error, store AckStore) *AckSink[T] {
+	return &AckSink[T]{
+		BaseNode: nodes.NewBaseNode[T, string]("AckSink", nodes.WithOptionalOutput()),
+		ErrPort:  ports.NewOutput[error]("err", "Processing and ack errors", false),
+		KeyFn:    keyFn,
+		Handle:   handle,
+		Store:    store,
+	}
+}
+
+// Process implements the processing logic
+func (s *AckSink[T]) Process(ctx context.Context) error {
+	if s.KeyFn == nil || s.Handle == nil || s.Store == nil {
+		return fmt.Errorf("ack sink needs a key function, a handler and a store")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := s.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			id := s.KeyFn(packet.Data())
+			acked, err := s.process(ctx, id, packet.Data())
+			if err != nil {
+				packetErr := nodes.NewPacketError(s.Name(), packet, err)
+				if err := s.ErrPort.Send(ctx, ip.New[error](packetErr)); err != nil {
+					return err
+				}
+				continue
+			}
+			if !acked {
+				continue
+			}
+			if err := s.OutPort.Send(ctx, ip.New(id)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// process handles and acknowledges one packet, reporting whether it was
+// newly acknowledged
+func (s *AckSink[T]) process(ctx context.Context, id string, data T) (bool, error) {
+	acked, err := s.Store.Acked(ctx, id)
+	if err != nil || acked {
+		return false, err
+	}
+	if err := s.Handle(ctx, data); err != nil {
+		return false, err
+	}
+	if err := s.Store.Ack(ctx, id); err != nil {
+		return false, fmt.Errorf("ack %s: %w", id, err)
+	}
+	return true, nil
+}
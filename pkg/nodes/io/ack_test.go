@@ -0,0 +1,122 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type order struct {
+	ID     string
+	Amount int
+}
+
+func TestExactlyOnce(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	store := NewMemoryAckStore()
+	key := func(o order) string { return o.ID }
+
+	var (
+		mu        sync.Mutex
+		processed []string
+		failOnce  = true
+	)
+	handle := func(_ context.Context, o order) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if o.ID == "flaky" && failOnce {
+			failOnce = false
+			return errors.New("temporary failure")
+		}
+		processed = append(processed, o.ID)
+		return nil
+	}
+
+	check := NewIdempotencyCheck(key, store)
+	sink := NewAckSink(key, handle, store)
+
+	inCh := make(chan *ip.IP[order], 10)
+	mid := make(chan *ip.IP[order], 10)
+	ackCh := make(chan *ip.IP[string], 10)
+	errCh := make(chan *ip.IP[error], 10)
+	require.NoError(t, ports.Connect(check.InPort, inCh))
+	require.NoError(t, ports.Connect(check.OutPort, mid))
+	require.NoError(t, ports.Connect(sink.InPort, mid))
+	require.NoError(t, ports.Connect(sink.OutPort, ackCh))
+	require.NoError(t, ports.Connect(sink.ErrPort, errCh))
+
+	go check.Process(ctx)
+	go sink.Process(ctx)
+
+	receiveAck := func() string {
+		select {
+		case packet := <-ackCh:
+			return packet.Data()
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for ack")
+			return ""
+		}
+	}
+
+	inCh <- ip.New(order{ID: "a", Amount: 1})
+	assert.Equal(t, "a", receiveAck())
+
+	// A replay of an acknowledged packet is dropped
+	inCh <- ip.New(order{ID: "a", Amount: 1})
+	inCh <- ip.New(order{ID: "b", Amount: 2})
+	assert.Equal(t, "b", receiveAck())
+
+	// A failed packet is not acknowledged, so its replay is retried
+	inCh <- ip.New(order{ID: "flaky"})
+	select {
+	case packet := <-errCh:
+		assert.ErrorContains(t, packet.Data(), "temporary failure")
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for error")
+	}
+	inCh <- ip.New(order{ID: "flaky"})
+	assert.Equal(t, "flaky", receiveAck())
+
+	mu.Lock()
+	assert.Equal(t, []string{"a", "b", "flaky"}, processed)
+	mu.Unlock()
+	assert.Equal(t, 3, store.Len())
+}
+
+func TestAckSinkSkipsAcked(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	store := NewMemoryAckStore()
+	require.NoError(t, store.Ack(ctx, "done"))
+
+	calls := 0
+	sink := NewAckSink(func(s string) string { return s },
+		func(context.Context, string) error { calls++; return nil }, store)
+
+	inCh := make(chan *ip.IP[string], 2)
+	ackCh := make(chan *ip.IP[string], 2)
+	require.NoError(t, ports.Connect(sink.InPort, inCh))
+	require.NoError(t, ports.Connect(sink.OutPort, ackCh))
+	go sink.Process(ctx)
+
+	inCh <- ip.New("done")
+	inCh <- ip.New("new")
+
+	select {
+	case packet := <-ackCh:
+		assert.Equal(t, "new", packet.Data())
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for ack")
+	}
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,80 @@
+package io
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// BufferedWriter is a sink writing byte packets to an io.Writer through a
+// buffer.
+//
+// A flush signal (see ip.NewFlush) flushes the buffer immediately and
+// emits an acknowledgment carrying the total number of bytes written so
+// far, which downstream checkpointing can record. The buffer is also
+// flushed when the node stops.
+type BufferedWriter struct {
+	*nodes.BaseNode[[]byte, int64]
+	w       *bufio.Writer
+	written int64
+}
+
+// NewBufferedWriter creates a buffered writer node; size <= 0 uses the
+// bufio default
+func NewBufferedWriter(w io.Writer, size int) *BufferedWriter {
+	var bw *bufio.Writer
+	if w != nil {
+		bw = bufio.NewWriterSize(w, size)
+	}
+	return &BufferedWriter{
+		BaseNode: nodes.NewBaseNode[[]byte, int64]("BufferedWriter", nodes.WithOptionalOutput()),
+		w:        bw,
+	}
+}
+
+// Process implements the processing logic
+func (b *BufferedWriter) Process(ctx context.Context) error {
+	if b.w == nil {
+		return fmt.Errorf("nil writer")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return b.stop(ctx.Err())
+		default:
+			packet, err := b.InPort.Receive(ctx)
+			if err != nil {
+				return b.stop(nodes.EndOfStream(err))
+			}
+
+			if packet.IsFlush() {
+				if err := b.w.Flush(); err != nil {
+					return fmt.Errorf("flush failed: %w", err)
+				}
+				if err := b.OutPort.Send(ctx, ip.New(b.written)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			n, err := b.w.Write(packet.Data())
+			b.written += int64(n)
+			if err != nil {
+				return fmt.Errorf("write failed: %w", err)
+			}
+		}
+	}
+}
+
+// stop flushes buffered output before returning err
+func (b *BufferedWriter) stop(err error) error {
+	if flushErr := b.w.Flush(); flushErr != nil {
+		return fmt.Errorf("flush failed: %w", flushErr)
+	}
+	return err
+}
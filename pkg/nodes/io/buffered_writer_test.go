@@ -0,0 +1,80 @@
+package io
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestBufferedWriter(t *testing.T) {
+	var out syncBuffer
+	writer := NewBufferedWriter(&out, 4096)
+
+	inCh := make(chan *ip.IP[[]byte], 10)
+	ackCh := make(chan *ip.IP[int64], 10)
+	require.NoError(t, ports.Connect(writer.InPort, inCh))
+	require.NoError(t, ports.Connect(writer.OutPort, ackCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- writer.Process(ctx)
+	}()
+
+	require.NoError(t, writer.InPort.Send(ctx, ip.New([]byte("hello "))))
+	require.NoError(t, writer.InPort.Send(ctx, ip.New([]byte("world"))))
+
+	// Data stays buffered until flushed
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, out.String())
+
+	require.NoError(t, writer.InPort.Send(ctx, ip.NewFlush[[]byte]()))
+	select {
+	case ack := <-ackCh:
+		assert.Equal(t, int64(11), ack.Data())
+		assert.Equal(t, "hello world", out.String(), "flushed before acknowledging")
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for flush acknowledgment")
+	}
+
+	// Remaining data is flushed when the node stops
+	require.NoError(t, writer.InPort.Send(ctx, ip.New([]byte("!"))))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "hello world", out.String())
+
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+	assert.Equal(t, "hello world!", out.String())
+}
@@ -0,0 +1,58 @@
+package io
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+)
+
+// MetadataContentType is the metadata key carrying the content type of a
+// file's packets, set on the open bracket that starts them
+const MetadataContentType = "content_type"
+
+// sniffLen is how much of a file http.DetectContentType considers
+const sniffLen = 512
+
+// ContentTypeDetector returns the content type of a file from its name and
+// first bytes
+type ContentTypeDetector func(name string, head []byte) string
+
+// DetectContentType is the default ContentTypeDetector. The extension wins
+// when it is known; otherwise the content is sniffed, falling back to
+// application/octet-stream.
+func DetectContentType(name string, head []byte) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(head)
+}
+
+// NewFileBracket returns the open bracket starting the packets read from
+// the file at path, stamped with its content type so downstream nodes can
+// pick a codec. A nil detect uses DetectContentType.
+func NewFileBracket[T any](path string, detect ContentTypeDetector) (*ip.IP[T], error) {
+	if detect == nil {
+		detect = DetectContentType
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	bracket := ip.NewOpenBracket[T]()
+	bracket.SetMetadata(MetadataContentType, detect(path, head[:n]))
+	return bracket, nil
+}
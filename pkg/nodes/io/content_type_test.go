@@ -0,0 +1,53 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFileBracket(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, content, 0644))
+		return path
+	}
+
+	contentType := func(path string, detect ContentTypeDetector) string {
+		bracket, err := NewFileBracket[[]byte](path, detect)
+		require.NoError(t, err)
+		assert.Equal(t, ip.TypeBracketOpen, bracket.Type())
+		value, ok := bracket.GetMetadata(MetadataContentType)
+		require.True(t, ok)
+		return value.(string)
+	}
+
+	jsonFile := write("records.json", []byte(`{"id": 1}`))
+	assert.Equal(t, "application/json", contentType(jsonFile, nil))
+
+	// Without a known extension the content is sniffed
+	assert.Equal(t, "application/octet-stream", contentType(write("blob", []byte{0x00, 0x01, 0xfe, 0xff}), nil))
+	assert.Equal(t, "image/png", contentType(write("picture", []byte("\x89PNG\r\n\x1a\n rest")), nil))
+	assert.Equal(t, "text/plain; charset=utf-8", contentType(write("notes", []byte("plain text")), nil))
+	assert.Equal(t, "application/octet-stream", contentType(write("empty", nil), func(_ string, head []byte) string {
+		assert.Empty(t, head)
+		return "application/octet-stream"
+	}))
+
+	// A custom detector replaces the default
+	assert.Equal(t, "application/x-ndjson", contentType(jsonFile, func(name string, _ []byte) string {
+		if strings.HasSuffix(name, ".json") {
+			return "application/x-ndjson"
+		}
+		return ""
+	}))
+
+	_, err := NewFileBracket[[]byte](filepath.Join(dir, "missing"), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// DefaultDeadLetterCapacity is how many dead letters a sink keeps by default
+const DefaultDeadLetterCapacity = 1000
+
+// DeadLetter is a packet that could not be processed
+type DeadLetter struct {
+	Node     string         `json:"node,omitempty"`
+	PacketID string         `json:"packet_id,omitempty"`
+	Data     any            `json:"data,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	Error    string         `json:"error"`
+	Time     time.Time      `json:"time"`
+}
+
+// DeadLetterSink records failed packets for inspection.
+//
+// Connect the error ports of other nodes to its input by convention.
+// Errors that are nodes.PacketError keep the failed packet's data and
+// metadata. The sink keeps the newest Capacity letters. Record, when set,
+// is also called for every letter, e.g. to persist it.
+type DeadLetterSink struct {
+	*nodes.BaseNode[error, DeadLetter]
+	Capacity int
+	Record   func(DeadLetter)
+	letters  []DeadLetter
+	mu       sync.RWMutex
+}
+
+// NewDeadLetterSink creates a new dead-letter sink node
+func NewDeadLetterSink() *DeadLetterSink {
+	return &DeadLetterSink{
+		BaseNode: nodes.NewBaseNode[error, DeadLetter]("DeadLetterSink", nodes.WithOptionalOutput()),
+		Capacity: DefaultDeadLetterCapacity,
+	}
+}
+
+// Letters returns the recorded dead letters, oldest first
+func (s *DeadLetterSink) Letters() []DeadLetter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]DeadLetter(nil), s.letters...)
+}
+
+// Process implements the processing logic
+func (s *DeadLetterSink) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := s.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			letter := newDeadLetter(packet.Data())
+			s.add(letter)
+			if s.Record != nil {
+				s.Record(letter)
+			}
+		}
+	}
+}
+
+func (s *DeadLetterSink) add(letter DeadLetter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.letters = append(s.letters, letter)
+	if s.Capacity > 0 && len(s.letters) > s.Capacity {
+		s.letters = s.letters[len(s.letters)-s.Capacity:]
+	}
+}
+
+func newDeadLetter(err error) DeadLetter {
+	letter := DeadLetter{Time: time.Now()}
+	if err == nil {
+		letter.Error = "unknown error"
+		return letter
+	}
+
+	letter.Error = err.Error()
+	var packetErr *nodes.PacketError
+	if errors.As(err, &packetErr) {
+		letter.Node = packetErr.Node
+		letter.PacketID = packetErr.PacketID
+		letter.Data = packetErr.Data
+		letter.Metadata = packetErr.Metadata
+		letter.Error = packetErr.Err.Error()
+	}
+	return letter
+}
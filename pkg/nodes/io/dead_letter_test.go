@@ -0,0 +1,100 @@
+package io
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes/transform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterSink(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	t.Run("records failed packets from an error port", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		mapper := transform.NewMapper[string, string](func(s string) string {
+			if s == "poison" {
+				<-release
+			}
+			return strings.ToUpper(s)
+		})
+		mapper.Timeout = 20 * time.Millisecond
+		sink := NewDeadLetterSink()
+
+		inCh := make(chan *ip.IP[string], 1)
+		outCh := make(chan *ip.IP[string], 1)
+		errCh := make(chan *ip.IP[error], 1)
+		require.NoError(t, ports.Connect(mapper.InPort, inCh))
+		require.NoError(t, ports.Connect(mapper.OutPort, outCh))
+		require.NoError(t, ports.Connect(mapper.ErrPort, errCh))
+		require.NoError(t, ports.Connect(sink.InPort, errCh))
+
+		recorded := make(chan DeadLetter, 1)
+		sink.Record = func(letter DeadLetter) { recorded <- letter }
+
+		go func() {
+			_ = mapper.Process(ctx)
+		}()
+		go func() {
+			_ = sink.Process(ctx)
+		}()
+
+		packet := ip.New("poison")
+		packet.SetMetadata("source", "test")
+		require.NoError(t, mapper.InPort.Send(ctx, packet))
+
+		select {
+		case letter := <-recorded:
+			assert.Equal(t, "Mapper", letter.Node)
+			assert.Equal(t, packet.ID(), letter.PacketID)
+			assert.Equal(t, "poison", letter.Data)
+			assert.Equal(t, "test", letter.Metadata["source"])
+			assert.Equal(t, "packet processing timed out", letter.Error)
+			assert.False(t, letter.Time.IsZero())
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for dead letter")
+		}
+		require.Len(t, sink.Letters(), 1)
+	})
+
+	t.Run("plain errors and capacity", func(t *testing.T) {
+		sink := NewDeadLetterSink()
+		sink.Capacity = 2
+
+		errCh := make(chan *ip.IP[error], 3)
+		require.NoError(t, ports.Connect(sink.InPort, errCh))
+
+		recorded := make(chan DeadLetter, 3)
+		sink.Record = func(letter DeadLetter) { recorded <- letter }
+		go func() {
+			_ = sink.Process(ctx)
+		}()
+
+		for _, msg := range []string{"one", "two", "three"} {
+			require.NoError(t, sink.InPort.Send(ctx, ip.New(errors.New(msg))))
+		}
+		for i := 0; i < 3; i++ {
+			select {
+			case <-recorded:
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for dead letter")
+			}
+		}
+
+		letters := sink.Letters()
+		require.Len(t, letters, 2)
+		assert.Equal(t, "two", letters[0].Error)
+		assert.Equal(t, "three", letters[1].Error)
+		assert.Empty(t, letters[1].PacketID)
+	})
+}
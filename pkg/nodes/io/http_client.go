@@ -2,28 +2,90 @@ package io
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
 	"github.com/elleshadow/noPromises/pkg/nodes"
 )
 
-// HTTPClient makes HTTP requests and forwards the responses
+// DefaultMaxResponseSize is the response body limit of a new HTTPClient
+const DefaultMaxResponseSize int64 = 10 << 20 // 10 MiB
+
+// Retry defaults of a new HTTPClient
+const (
+	DefaultMaxAttempts    = 3
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+	DefaultMaxRetryDelay  = 10 * time.Second
+)
+
+// ErrPacketTooLarge is reported when input read by an IO node exceeds its size limit
+var ErrPacketTooLarge = errors.New("packet too large")
+
+// HTTPClient makes HTTP requests and forwards the responses.
+//
+// Response bodies larger than MaxResponseSize are not forwarded; an
+// ErrPacketTooLarge error is sent to ErrPort instead. A MaxResponseSize
+// of 0 disables the limit.
+//
+// Requests are GETs, which are idempotent, so connection errors and 5xx
+// or 429 responses are retried up to MaxAttempts attempts in all. Retries
+// back off exponentially from RetryBaseDelay with jitter, capped at
+// MaxRetryDelay, and wait for a Retry-After header instead when the server
+// sends one. Other responses are never retried. When the attempts run out
+// the last outcome stands: a connection error fails the node and an error
+// response is forwarded like any other.
+//
+// When Resource names a resource limited in network.Limits.Resources,
+// each attempt holds one of its slots, shared with the other nodes of the
+// network calling the same resource. Slots are not held between retries.
 type HTTPClient struct {
 	*nodes.BaseNode[string, []byte]
-	client *http.Client
+	ErrPort         *ports.Port[error]
+	MaxResponseSize int64
+	MaxAttempts     int
+	RetryBaseDelay  time.Duration
+	MaxRetryDelay   time.Duration
+	Resource        string
+	client          *http.Client
 }
 
 // NewHTTPClient creates a new HTTP client node
 func NewHTTPClient() *HTTPClient {
 	return &HTTPClient{
-		BaseNode: nodes.NewBaseNode[string, []byte]("HTTPClient"),
-		client:   &http.Client{},
+		BaseNode:        nodes.NewBaseNode[string, []byte]("HTTPClient"),
+		ErrPort:         ports.NewOutput[error]("err", "Request errors", false),
+		MaxResponseSize: DefaultMaxResponseSize,
+		MaxAttempts:     DefaultMaxAttempts,
+		RetryBaseDelay:  DefaultRetryBaseDelay,
+		MaxRetryDelay:   DefaultMaxRetryDelay,
+		client:          &http.Client{},
 	}
 }
 
+// readLimited reads all of r, failing with ErrPacketTooLarge once more
+// than max bytes have been read. A max of 0 means no limit.
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	if max <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrPacketTooLarge, max)
+	}
+	return data, nil
+}
+
 // Process implements the processing logic
 func (h *HTTPClient) Process(ctx context.Context) error {
 	if h.client == nil {
@@ -40,27 +102,25 @@ func (h *HTTPClient) Process(ctx context.Context) error {
 				if err == context.Canceled || err == context.DeadlineExceeded {
 					return err
 				}
+				if errors.Is(err, ports.ErrStreamClosed) {
+					return nil
+				}
 				return fmt.Errorf("receive failed: %w", err)
 			}
 
-			req, err := http.NewRequestWithContext(ctx, "GET", packet.Data(), nil)
-			if err != nil {
-				return fmt.Errorf("failed to create request: %w", err)
+			body, err := h.get(ctx, packet.Data())
+			if ctx.Err() != nil {
+				return ctx.Err()
 			}
-
-			resp, err := h.client.Do(req)
-			if err != nil {
-				// Check if the error is due to context cancellation
-				if ctx.Err() != nil {
-					return ctx.Err()
+			if errors.Is(err, ErrPacketTooLarge) {
+				packetErr := nodes.NewPacketError(h.Name(), packet, err)
+				if err := h.ErrPort.Send(ctx, ip.New[error](packetErr)); err != nil {
+					return err
 				}
-				return fmt.Errorf("request failed: %w", err)
+				continue
 			}
-			defer resp.Body.Close()
-
-			body, err := io.ReadAll(resp.Body)
 			if err != nil {
-				return fmt.Errorf("failed to read response: %w", err)
+				return err
 			}
 
 			select {
@@ -77,3 +137,115 @@ func (h *HTTPClient) Process(ctx context.Context) error {
 		}
 	}
 }
+
+// get fetches url, retrying transient failures
+func (h *HTTPClient) get(ctx context.Context, url string) ([]byte, error) {
+	attempts := h.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	sem := network.ResourceFromContext(ctx, h.Resource)
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		if err := sem.Acquire(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := h.client.Do(req)
+		if err != nil {
+			sem.Release()
+			if ctx.Err() != nil || attempt >= attempts {
+				return nil, fmt.Errorf("request failed: %w", err)
+			}
+			if err := sleepContext(ctx, h.retryDelay(attempt, nil)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if retryableStatus(resp.StatusCode) && attempt < attempts {
+			// Drain a little so the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+			sem.Release()
+			if err := sleepContext(ctx, h.retryDelay(attempt, resp)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		body, err := readLimited(resp.Body, h.MaxResponseSize)
+		resp.Body.Close()
+		sem.Release()
+		if err != nil && !errors.Is(err, ErrPacketTooLarge) {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return body, err
+	}
+}
+
+// retryableStatus reports whether a response status is worth retrying
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryDelay returns how long to wait after a failed attempt: the
+// response's Retry-After when present, otherwise exponential backoff with
+// jitter. Either way it is capped at MaxRetryDelay.
+func (h *HTTPClient) retryDelay(attempt int, resp *http.Response) time.Duration {
+	max := h.MaxRetryDelay
+	if max <= 0 {
+		max = DefaultMaxRetryDelay
+	}
+
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if delay > max {
+				return max
+			}
+			return delay
+		}
+	}
+
+	delay := h.RetryBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+	// Equal jitter: wait between half and all of the backoff
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an
+// HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
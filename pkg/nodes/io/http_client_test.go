@@ -1,13 +1,16 @@
 package io
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/network"
 	"github.com/elleshadow/noPromises/pkg/core/ports"
 	"github.com/elleshadow/noPromises/pkg/nodes"
 	"github.com/stretchr/testify/assert"
@@ -111,3 +114,209 @@ func TestHTTPClientCancellation(t *testing.T) {
 		t.Fatal("timeout waiting for cancellation")
 	}
 }
+
+func TestHTTPClientMaxResponseSize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large" {
+			w.Write(bytes.Repeat([]byte("x"), 1<<20))
+			return
+		}
+		w.Write([]byte("small"))
+	}))
+	defer ts.Close()
+
+	client := NewHTTPClient()
+	client.MaxResponseSize = 1024
+
+	inCh := make(chan *ip.IP[string], 2)
+	outCh := make(chan *ip.IP[[]byte], 2)
+	errOutCh := make(chan *ip.IP[error], 2)
+	require.NoError(t, ports.Connect(client.InPort, inCh))
+	require.NoError(t, ports.Connect(client.OutPort, outCh))
+	require.NoError(t, ports.Connect(client.ErrPort, errOutCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.Process(ctx)
+	}()
+
+	require.NoError(t, client.InPort.Send(ctx, ip.New(ts.URL+"/large")))
+	select {
+	case packet := <-errOutCh:
+		assert.ErrorIs(t, packet.Data(), ErrPacketTooLarge)
+	case <-outCh:
+		t.Fatal("oversized response must not be forwarded")
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for error")
+	}
+
+	// The node keeps serving requests within the limit
+	require.NoError(t, client.InPort.Send(ctx, ip.New(ts.URL+"/small")))
+	select {
+	case packet := <-outCh:
+		assert.Equal(t, []byte("small"), packet.Data())
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for response")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-errCh, context.Canceled)
+}
+
+// fetchOnce runs client over a single URL and returns the forwarded body
+func fetchOnce(t *testing.T, client *HTTPClient, url string) []byte {
+	t.Helper()
+
+	inCh := make(chan *ip.IP[string], 1)
+	outCh := make(chan *ip.IP[[]byte], 1)
+	require.NoError(t, ports.Connect(client.InPort, inCh))
+	require.NoError(t, ports.Connect(client.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.Process(ctx)
+	}()
+	require.NoError(t, client.InPort.Send(ctx, ip.New(url)))
+
+	select {
+	case packet := <-outCh:
+		return packet.Data()
+	case err := <-errCh:
+		t.Fatalf("client stopped: %v", err)
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for response")
+	}
+	return nil
+}
+
+func TestHTTPClientRetry(t *testing.T) {
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if attempts.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := NewHTTPClient()
+	client.RetryBaseDelay = time.Millisecond
+
+	assert.Equal(t, []byte("ok"), fetchOnce(t, client, ts.URL))
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestHTTPClientNoRetryOnClientError(t *testing.T) {
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing"))
+	}))
+	defer ts.Close()
+
+	client := NewHTTPClient()
+	client.RetryBaseDelay = time.Millisecond
+
+	assert.Equal(t, []byte("missing"), fetchOnce(t, client, ts.URL))
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestHTTPClientRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := NewHTTPClient()
+	client.RetryBaseDelay = time.Millisecond
+
+	start := time.Now()
+	assert.Equal(t, []byte("ok"), fetchOnce(t, client, ts.URL))
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	delay, ok := parseRetryAfter("3", now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, delay)
+
+	delay, ok = parseRetryAfter(now.Add(5*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, delay)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
+
+func TestHTTPClientSharedResourceLimit(t *testing.T) {
+	var inFlight, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	net := network.New()
+	net.SetLimits(network.Limits{Resources: map[string]int{"api": 1}})
+
+	const requests = 4
+	var inputs []chan *ip.IP[string]
+	out := make(chan *ip.IP[[]byte], 2*requests)
+	for _, name := range []string{"first", "second"} {
+		client := NewHTTPClient()
+		client.BaseNode = nodes.NewBaseNode[string, []byte](name)
+		client.Resource = "api"
+		in := make(chan *ip.IP[string], requests)
+		require.NoError(t, ports.Connect(client.InPort, in))
+		require.NoError(t, ports.Connect(client.OutPort, out))
+		net.AddProcess(client)
+		inputs = append(inputs, in)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- net.Start(ctx) }()
+
+	for _, in := range inputs {
+		for i := 0; i < requests; i++ {
+			in <- ip.New(ts.URL)
+		}
+		close(in)
+	}
+	for i := 0; i < 2*requests; i++ {
+		select {
+		case packet := <-out:
+			assert.Equal(t, []byte("ok"), packet.Data())
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for responses")
+		}
+	}
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&peak), "calls to a resource limited to 1 must never overlap")
+}
@@ -0,0 +1,257 @@
+package io
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+	"github.com/gorilla/websocket"
+)
+
+// RemoteCodec selects how packets are encoded on a remote connection. It
+// is negotiated as the WebSocket subprotocol.
+type RemoteCodec string
+
+const (
+	// RemoteCodecJSON sends packets as JSON text messages. Connections
+	// that negotiate no codec use it.
+	RemoteCodecJSON RemoteCodec = "nop.json"
+	// RemoteCodecBinary sends packets as length-framed binary messages
+	// (see ip.EncodeFrame), carrying []byte payloads without base64
+	RemoteCodecBinary RemoteCodec = "nop.binary"
+)
+
+// RemoteNode proxies packets to a RemoteWorker over WebSocket.
+//
+// Each packet received on InPort is encoded with Codec and sent to the
+// worker; every packet the worker sends back is emitted on OutPort. A
+// worker that does not support Codec falls back to JSON.
+type RemoteNode[In, Out any] struct {
+	*nodes.BaseNode[In, Out]
+	URL    string
+	Codec  RemoteCodec
+	dialer *websocket.Dialer
+}
+
+// NewRemoteNode creates a new remote node connecting to the worker at url
+func NewRemoteNode[In, Out any](url string) *RemoteNode[In, Out] {
+	return &RemoteNode[In, Out]{
+		BaseNode: nodes.NewBaseNode[In, Out]("RemoteNode"),
+		URL:      url,
+		Codec:    RemoteCodecJSON,
+		dialer:   websocket.DefaultDialer,
+	}
+}
+
+// Process implements the processing logic
+func (r *RemoteNode[In, Out]) Process(ctx context.Context) error {
+	var header http.Header
+	if r.Codec != "" {
+		header = http.Header{"Sec-WebSocket-Protocol": {string(r.Codec)}}
+	}
+	conn, _, err := r.dialer.DialContext(ctx, r.URL, header)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("dial failed: %w", err)
+	}
+
+	return runConnection(ctx, conn, r.InPort, r.OutPort)
+}
+
+// RemoteWorker hosts a local node for RemoteNode clients.
+//
+// Every WebSocket connection gets its own node instance from the factory,
+// so clients never observe each other's packets.
+type RemoteWorker[In, Out any] struct {
+	factory  func() nodes.Node[In, Out]
+	upgrader websocket.Upgrader
+}
+
+// NewRemoteWorker creates a new worker hosting nodes built by factory
+func NewRemoteWorker[In, Out any](factory func() nodes.Node[In, Out]) *RemoteWorker[In, Out] {
+	return &RemoteWorker[In, Out]{
+		factory: factory,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{string(RemoteCodecBinary), string(RemoteCodecJSON)},
+		},
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (w *RemoteWorker[In, Out]) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	conn, err := w.upgrader.Upgrade(rw, r, nil)
+	if err != nil {
+		log.Printf("Error upgrading remote worker connection: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	node := w.factory()
+	inCh := make(chan *ip.IP[In])
+	outCh := make(chan *ip.IP[Out])
+
+	// The worker side feeds the hosted node's input and drains its output
+	toNode := ports.NewOutput[In]("remote", "Packets from the remote client", true)
+	fromNode := ports.NewInput[Out]("remote", "Packets for the remote client", true)
+	for _, err := range []error{
+		ports.Connect(node.Input(), inCh),
+		ports.Connect(toNode, inCh),
+		ports.Connect(node.Output(), outCh),
+		ports.Connect(fromNode, outCh),
+	} {
+		if err != nil {
+			log.Printf("Error wiring remote worker node: %v", err)
+			conn.Close()
+			return
+		}
+	}
+
+	if err := node.Initialize(ctx); err != nil {
+		log.Printf("Error initializing remote worker node: %v", err)
+		conn.Close()
+		return
+	}
+	defer func() {
+		if err := node.Shutdown(context.Background()); err != nil {
+			log.Printf("Error shutting down remote worker node: %v", err)
+		}
+	}()
+
+	go func() {
+		// A panicking node ends its connection, not the worker process
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Remote worker node %s panicked: %v\n%s", node.Name(), r, debug.Stack())
+				cancel()
+			}
+		}()
+		if err := node.Process(ctx); err != nil && err != context.Canceled {
+			log.Printf("Remote worker node %s failed: %v", node.Name(), err)
+			cancel()
+		}
+	}()
+
+	if err := runConnection(ctx, conn, fromNode, toNode); err != nil && err != context.Canceled {
+		log.Printf("Remote worker connection closed: %v", err)
+	}
+}
+
+// connectionCodec returns the codec a connection negotiated
+func connectionCodec(conn *websocket.Conn) RemoteCodec {
+	if RemoteCodec(conn.Subprotocol()) == RemoteCodecBinary {
+		return RemoteCodecBinary
+	}
+	return RemoteCodecJSON
+}
+
+// runConnection pumps packets from in to the connection and from the
+// connection to out until either side fails or ctx is done.
+func runConnection[Send, Recv any](ctx context.Context, conn *websocket.Conn,
+	in *ports.Port[Send], out *ports.Port[Recv]) error {
+	codec := connectionCodec(conn)
+	parent := ctx
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	// Closing the connection unblocks the read loop
+	go func() {
+		<-ctx.Done()
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(time.Second))
+		conn.Close()
+	}()
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- readPackets(ctx, conn, codec, out)
+		cancel()
+	}()
+
+	writeErr := writePackets(ctx, conn, codec, in)
+	cancel()
+	rerr := <-readErr
+
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+	if rerr != nil {
+		return rerr
+	}
+	return writeErr
+}
+
+func writePackets[T any](ctx context.Context, conn *websocket.Conn, codec RemoteCodec, in *ports.Port[T]) error {
+	messageType := websocket.TextMessage
+	if codec == RemoteCodecBinary {
+		messageType = websocket.BinaryMessage
+	}
+
+	for {
+		packet, err := in.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, ports.ErrStreamClosed) {
+				return nil
+			}
+			return fmt.Errorf("receive failed: %w", err)
+		}
+
+		var data []byte
+		if codec == RemoteCodecBinary {
+			data, err = ip.EncodeFrame(packet)
+		} else {
+			data, err = json.Marshal(packet)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to encode packet: %w", err)
+		}
+
+		if err := conn.WriteMessage(messageType, data); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("write failed: %w", err)
+		}
+	}
+}
+
+func readPackets[T any](ctx context.Context, conn *websocket.Conn, codec RemoteCodec, out *ports.Port[T]) error {
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil || websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return nil
+			}
+			return fmt.Errorf("read failed: %w", err)
+		}
+
+		var packet *ip.IP[T]
+		if codec == RemoteCodecBinary && messageType == websocket.BinaryMessage {
+			packet, err = ip.DecodeFrame[T](data)
+		} else {
+			packet, err = ip.Decode[T](data)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode packet: %w", err)
+		}
+
+		if err := out.Send(ctx, packet); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("send failed: %w", err)
+		}
+	}
+}
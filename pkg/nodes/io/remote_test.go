@@ -0,0 +1,167 @@
+package io
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+	"github.com/elleshadow/noPromises/pkg/nodes/transform"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteNode(t *testing.T) {
+	// Host an uppercasing mapper behind a remote worker
+	worker := NewRemoteWorker(func() nodes.Node[string, string] {
+		return transform.NewMapper[string, string](strings.ToUpper)
+	})
+	ts := httptest.NewServer(worker)
+	defer ts.Close()
+
+	remote := NewRemoteNode[string, string]("ws" + strings.TrimPrefix(ts.URL, "http"))
+
+	// Create test channels
+	inCh := make(chan *ip.IP[string], 1)
+	outCh := make(chan *ip.IP[string], 1)
+
+	// Connect ports
+	require.NoError(t, ports.Connect(remote.InPort, inCh))
+	require.NoError(t, ports.Connect(remote.OutPort, outCh))
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Start processing in goroutine
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- remote.Process(ctx)
+	}()
+
+	for _, word := range []string{"hello", "remote"} {
+		require.NoError(t, remote.InPort.Send(ctx, ip.New(word)))
+
+		select {
+		case packet := <-outCh:
+			assert.Equal(t, strings.ToUpper(word), packet.Data())
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for remote response to %q", word)
+		}
+	}
+
+	// Verify clean shutdown
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+}
+
+func TestRemoteWorkerNodePanic(t *testing.T) {
+	// The hosted node panics outside the network's recover; the worker
+	// must drop the connection instead of crashing
+	worker := NewRemoteWorker(func() nodes.Node[string, string] {
+		return transform.NewMapper[string, string](func(s string) string {
+			panic("hosted node exploded")
+		})
+	})
+	ts := httptest.NewServer(worker)
+	defer ts.Close()
+
+	remote := NewRemoteNode[string, string]("ws" + strings.TrimPrefix(ts.URL, "http"))
+	inCh := make(chan *ip.IP[string], 1)
+	outCh := make(chan *ip.IP[string], 1)
+	require.NoError(t, ports.Connect(remote.InPort, inCh))
+	require.NoError(t, ports.Connect(remote.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- remote.Process(ctx)
+	}()
+	require.NoError(t, remote.InPort.Send(ctx, ip.New("boom")))
+
+	select {
+	case <-errCh:
+		assert.NoError(t, ctx.Err(), "the worker should close the connection")
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the connection to close")
+	}
+	assert.Empty(t, outCh)
+}
+
+func TestRemoteNodeDialFailure(t *testing.T) {
+	remote := NewRemoteNode[string, string]("ws://127.0.0.1:1/unreachable")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := remote.Process(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dial failed")
+}
+
+func TestRemoteNodeBinaryCodec(t *testing.T) {
+	worker := NewRemoteWorker(func() nodes.Node[[]byte, []byte] {
+		return transform.NewMapper[[]byte, []byte](func(b []byte) []byte { return b })
+	})
+	ts := httptest.NewServer(worker)
+	defer ts.Close()
+	url := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	// The worker agrees to the binary codec when asked, and to nothing
+	// otherwise
+	for codec, want := range map[RemoteCodec]string{RemoteCodecBinary: "nop.binary", "": ""} {
+		var header http.Header
+		if codec != "" {
+			header = http.Header{"Sec-WebSocket-Protocol": {string(codec)}}
+		}
+		conn, _, err := websocket.DefaultDialer.Dial(url, header)
+		require.NoError(t, err)
+		assert.Equal(t, want, conn.Subprotocol())
+		conn.Close()
+	}
+
+	remote := NewRemoteNode[[]byte, []byte](url)
+	remote.Codec = RemoteCodecBinary
+
+	inCh := make(chan *ip.IP[[]byte], 1)
+	outCh := make(chan *ip.IP[[]byte], 1)
+	require.NoError(t, ports.Connect(remote.InPort, inCh))
+	require.NoError(t, ports.Connect(remote.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- remote.Process(ctx)
+	}()
+
+	payload := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(payload)
+	require.NoError(t, remote.InPort.Send(ctx, ip.New(payload)))
+
+	select {
+	case reply := <-outCh:
+		assert.True(t, bytes.Equal(payload, reply.Data()), "payload should arrive byte for byte")
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for remote response")
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+}
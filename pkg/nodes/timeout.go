@@ -0,0 +1,56 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// ErrProcessingTimeout is returned when handling a single packet takes
+// longer than the node's processing timeout
+var ErrProcessingTimeout = errors.New("packet processing timed out")
+
+// ErrProcessingPanicked is returned when handling a single packet panics
+// in a goroutine the network cannot recover
+var ErrProcessingPanicked = errors.New("packet processing panicked")
+
+// CallWithTimeout runs fn, giving up after timeout or when ctx is done.
+// A timeout <= 0 runs fn directly. fn cannot be interrupted: on timeout
+// it keeps running in the background and its result is discarded. A panic
+// in fn is returned as an error wrapping ErrProcessingPanicked.
+func CallWithTimeout[T any](ctx context.Context, timeout time.Duration, fn func() T) (T, error) {
+	if timeout <= 0 {
+		return fn(), nil
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("packet processing panicked: %v\n%s", r, debug.Stack())
+				resultCh <- result{err: fmt.Errorf("%w: %v", ErrProcessingPanicked, r)}
+			}
+		}()
+		resultCh <- result{value: fn()}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var zero T
+	select {
+	case r := <-resultCh:
+		return r.value, r.err
+	case <-timer.C:
+		return zero, ErrProcessingTimeout
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,138 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// FieldMapTag is the struct tag on output fields naming the input field to copy from
+const FieldMapTag = "map"
+
+var (
+	// ErrFieldNotFound is reported when a mapped source field does not exist
+	ErrFieldNotFound = errors.New("source field not found")
+	// ErrFieldTypeMismatch is reported when a source field cannot be assigned to its target
+	ErrFieldTypeMismatch = errors.New("field type mismatch")
+	// ErrNotStruct is reported when the input or output type is not a struct
+	ErrNotStruct = errors.New("field mapping requires struct types")
+)
+
+// FieldMapper reshapes structs by copying fields from In to Out.
+//
+// Output fields are populated from the input field named by their `map` tag,
+// or from the input field with the same name when untagged. Entries in
+// Mapping (output field -> input field) take precedence over tags. A tag of
+// "-" skips the field. Packets that cannot be mapped are sent to ErrPort.
+type FieldMapper[In, Out any] struct {
+	*nodes.BaseNode[In, Out]
+	ErrPort *ports.Port[error]
+	Mapping map[string]string
+}
+
+// NewFieldMapper creates a new field mapper node
+func NewFieldMapper[In, Out any]() *FieldMapper[In, Out] {
+	return &FieldMapper[In, Out]{
+		BaseNode: nodes.NewBaseNode[In, Out]("FieldMapper"),
+		ErrPort:  ports.NewOutput[error]("err", "Mapping errors", false),
+		Mapping:  make(map[string]string),
+	}
+}
+
+// Map copies the mapped fields of in into a new Out value
+func (m *FieldMapper[In, Out]) Map(in In) (Out, error) {
+	var out Out
+
+	src := reflect.ValueOf(in)
+	for src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			return out, fmt.Errorf("%w: nil input", ErrNotStruct)
+		}
+		src = src.Elem()
+	}
+	if src.Kind() != reflect.Struct {
+		return out, fmt.Errorf("%w: input is %s", ErrNotStruct, src.Kind())
+	}
+
+	dst := reflect.ValueOf(&out).Elem()
+	if dst.Kind() == reflect.Ptr {
+		dst.Set(reflect.New(dst.Type().Elem()))
+		dst = dst.Elem()
+	}
+	if dst.Kind() != reflect.Struct {
+		return out, fmt.Errorf("%w: output is %s", ErrNotStruct, dst.Kind())
+	}
+
+	dstType := dst.Type()
+	for i := 0; i < dstType.NumField(); i++ {
+		field := dstType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		source := m.sourceField(field)
+		if source == "" {
+			continue
+		}
+
+		value := src.FieldByName(source)
+		if !value.IsValid() {
+			return out, fmt.Errorf("%w: %s (for %s)", ErrFieldNotFound, source, field.Name)
+		}
+		if !value.Type().AssignableTo(field.Type) {
+			return out, fmt.Errorf("%w: cannot assign %s (%s) to %s (%s)",
+				ErrFieldTypeMismatch, source, value.Type(), field.Name, field.Type)
+		}
+		dst.Field(i).Set(value)
+	}
+
+	return out, nil
+}
+
+// sourceField resolves the input field name for an output field
+func (m *FieldMapper[In, Out]) sourceField(field reflect.StructField) string {
+	if source, ok := m.Mapping[field.Name]; ok {
+		return source
+	}
+	tag, ok := field.Tag.Lookup(FieldMapTag)
+	if !ok {
+		return field.Name
+	}
+	if tag == "-" {
+		return ""
+	}
+	return tag
+}
+
+// Process implements the processing logic
+func (m *FieldMapper[In, Out]) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := m.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			result, err := m.Map(packet.Data())
+			if err != nil {
+				packetErr := nodes.NewPacketError(m.Name(), packet, err)
+				if err := m.ErrPort.Send(ctx, ip.New[error](packetErr)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := m.OutPort.Send(ctx, ip.New(result)); err != nil {
+				return err
+			}
+		}
+	}
+}
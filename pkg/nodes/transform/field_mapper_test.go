@@ -0,0 +1,133 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sourceRecord struct {
+	A string
+	B int
+}
+
+type targetRecord struct {
+	X string `map:"A"`
+	Y int    `map:"B"`
+}
+
+func TestFieldMapper(t *testing.T) {
+	mapper := NewFieldMapper[sourceRecord, targetRecord]()
+
+	// Create test channels
+	inCh := make(chan *ip.IP[sourceRecord], 1)
+	outCh := make(chan *ip.IP[targetRecord], 1)
+
+	// Connect ports
+	require.NoError(t, ports.Connect(mapper.InPort, inCh))
+	require.NoError(t, ports.Connect(mapper.OutPort, outCh))
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// Start processing in goroutine
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- mapper.Process(ctx)
+	}()
+
+	require.NoError(t, mapper.InPort.Send(ctx, ip.New(sourceRecord{A: "hello", B: 42})))
+
+	select {
+	case packet := <-outCh:
+		assert.Equal(t, targetRecord{X: "hello", Y: 42}, packet.Data())
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for output")
+	}
+
+	// Verify clean shutdown
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+}
+
+func TestFieldMapperMapping(t *testing.T) {
+	type renamed struct {
+		X string
+		Y int
+	}
+
+	mapper := NewFieldMapper[sourceRecord, *renamed]()
+	mapper.Mapping["X"] = "A"
+	mapper.Mapping["Y"] = "B"
+
+	out, err := mapper.Map(sourceRecord{A: "a", B: 1})
+	require.NoError(t, err)
+	assert.Equal(t, &renamed{X: "a", Y: 1}, out)
+}
+
+func TestFieldMapperErrors(t *testing.T) {
+	t.Run("missing source field", func(t *testing.T) {
+		type target struct {
+			Z string `map:"C"`
+		}
+		_, err := NewFieldMapper[sourceRecord, target]().Map(sourceRecord{})
+		assert.ErrorIs(t, err, ErrFieldNotFound)
+		assert.Contains(t, err.Error(), "C")
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		type target struct {
+			X int `map:"A"`
+		}
+		_, err := NewFieldMapper[sourceRecord, target]().Map(sourceRecord{})
+		assert.ErrorIs(t, err, ErrFieldTypeMismatch)
+	})
+
+	t.Run("non-struct input", func(t *testing.T) {
+		_, err := NewFieldMapper[string, targetRecord]().Map("invalid")
+		assert.ErrorIs(t, err, ErrNotStruct)
+	})
+
+	t.Run("routed to error port", func(t *testing.T) {
+		type target struct {
+			X int `map:"A"`
+		}
+		mapper := NewFieldMapper[sourceRecord, target]()
+
+		inCh := make(chan *ip.IP[sourceRecord], 1)
+		outCh := make(chan *ip.IP[target], 1)
+		errOutCh := make(chan *ip.IP[error], 1)
+		require.NoError(t, ports.Connect(mapper.InPort, inCh))
+		require.NoError(t, ports.Connect(mapper.OutPort, outCh))
+		require.NoError(t, ports.Connect(mapper.ErrPort, errOutCh))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		go func() {
+			_ = mapper.Process(ctx)
+		}()
+
+		require.NoError(t, mapper.InPort.Send(ctx, ip.New(sourceRecord{A: "a"})))
+
+		select {
+		case packet := <-errOutCh:
+			assert.ErrorIs(t, packet.Data(), ErrFieldTypeMismatch)
+		case <-outCh:
+			t.Fatal("unexpected output for unmappable packet")
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for error")
+		}
+	})
+}
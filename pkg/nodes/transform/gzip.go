@@ -0,0 +1,246 @@
+package transform
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// gzipChunkSize is the size of decompressed chunks emitted by GzipDecoder
+const gzipChunkSize = 32 * 1024
+
+// GzipEncoder compresses byte streams.
+//
+// A bracketed substream is compressed as one gzip stream: each data packet
+// is fed to a streaming compressor, compressed bytes are emitted as they
+// become available, and the stream is finalized on the close bracket.
+// Packets outside brackets are compressed individually.
+type GzipEncoder struct {
+	*nodes.BaseNode[[]byte, []byte]
+}
+
+// NewGzipEncoder creates a new gzip encoder node
+func NewGzipEncoder() *GzipEncoder {
+	return &GzipEncoder{
+		BaseNode: nodes.NewBaseNode[[]byte, []byte]("GzipEncoder"),
+	}
+}
+
+// Process implements the processing logic
+func (e *GzipEncoder) Process(ctx context.Context) error {
+	var (
+		buf bytes.Buffer
+		zw  *gzip.Writer
+	)
+
+	// emit sends any compressed bytes accumulated so far
+	emit := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		chunk := append([]byte(nil), buf.Bytes()...)
+		buf.Reset()
+		return e.OutPort.Send(ctx, ip.New(chunk))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := e.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			switch packet.Type() {
+			case ip.TypeBracketOpen:
+				if zw == nil {
+					zw = gzip.NewWriter(&buf)
+				}
+				if err := e.OutPort.Send(ctx, packet); err != nil {
+					return err
+				}
+
+			case ip.TypeBracketClose:
+				if zw != nil {
+					if err := zw.Close(); err != nil {
+						return fmt.Errorf("failed to finish gzip stream: %w", err)
+					}
+					zw = nil
+					if err := emit(); err != nil {
+						return err
+					}
+				}
+				if err := e.OutPort.Send(ctx, packet); err != nil {
+					return err
+				}
+
+			default:
+				if zw != nil {
+					if _, err := zw.Write(packet.Data()); err != nil {
+						return fmt.Errorf("failed to compress: %w", err)
+					}
+					if err := emit(); err != nil {
+						return err
+					}
+					continue
+				}
+
+				single := gzip.NewWriter(&buf)
+				if _, err := single.Write(packet.Data()); err != nil {
+					return fmt.Errorf("failed to compress: %w", err)
+				}
+				if err := single.Close(); err != nil {
+					return fmt.Errorf("failed to compress: %w", err)
+				}
+				if err := emit(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// GzipDecoder decompresses byte streams produced by GzipEncoder.
+//
+// Chunks of a bracketed substream are fed to a single streaming
+// decompressor, so a gzip stream may be split across any number of
+// packets. Packets outside brackets must each hold a complete gzip stream.
+// Corrupt input is reported on ErrPort.
+type GzipDecoder struct {
+	*nodes.BaseNode[[]byte, []byte]
+	ErrPort *ports.Port[error]
+}
+
+// NewGzipDecoder creates a new gzip decoder node
+func NewGzipDecoder() *GzipDecoder {
+	return &GzipDecoder{
+		BaseNode: nodes.NewBaseNode[[]byte, []byte]("GzipDecoder"),
+		ErrPort:  ports.NewOutput[error]("err", "Decompression errors", false),
+	}
+}
+
+// gzipStream is an in-progress bracketed decompression
+type gzipStream struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+// Process implements the processing logic
+func (d *GzipDecoder) Process(ctx context.Context) error {
+	var stream *gzipStream
+
+	for {
+		select {
+		case <-ctx.Done():
+			if stream != nil {
+				stream.pw.CloseWithError(ctx.Err())
+			}
+			return ctx.Err()
+		default:
+			packet, err := d.InPort.Receive(ctx)
+			if err != nil {
+				if stream != nil {
+					stream.pw.CloseWithError(err)
+				}
+				return nodes.EndOfStream(err)
+			}
+
+			switch packet.Type() {
+			case ip.TypeBracketOpen:
+				if err := d.OutPort.Send(ctx, packet); err != nil {
+					return err
+				}
+				if stream == nil {
+					stream = d.startStream(ctx)
+				}
+
+			case ip.TypeBracketClose:
+				if stream != nil {
+					stream.pw.Close()
+					err := <-stream.done
+					stream = nil
+					if err := d.reportError(ctx, err); err != nil {
+						return err
+					}
+				}
+				if err := d.OutPort.Send(ctx, packet); err != nil {
+					return err
+				}
+
+			default:
+				if stream != nil {
+					// A failed decoder closes the pipe; the error is
+					// reported once the substream ends
+					_, _ = stream.pw.Write(packet.Data())
+					continue
+				}
+
+				err := d.decode(ctx, bytes.NewReader(packet.Data()))
+				if err := d.reportError(ctx, err); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// startStream starts a decompressor reading from a pipe fed by Process
+func (d *GzipDecoder) startStream(ctx context.Context) *gzipStream {
+	pr, pw := io.Pipe()
+	stream := &gzipStream{pw: pw, done: make(chan error, 1)}
+
+	go func() {
+		err := d.decode(ctx, pr)
+		// Unblock any pending writes if decoding stopped early
+		pr.CloseWithError(err)
+		stream.done <- err
+	}()
+
+	return stream
+}
+
+// decode decompresses r, emitting the output in chunks
+func (d *GzipDecoder) decode(ctx context.Context, r io.Reader) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("invalid gzip stream: %w", err)
+	}
+	defer zr.Close()
+
+	buf := make([]byte, gzipChunkSize)
+	for {
+		n, err := zr.Read(buf)
+		if n > 0 {
+			chunk := append([]byte(nil), buf[:n]...)
+			if err := d.OutPort.Send(ctx, ip.New(chunk)); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid gzip stream: %w", err)
+		}
+	}
+}
+
+// reportError routes a decoding error to ErrPort, stopping the node only
+// when the context is done
+func (d *GzipDecoder) reportError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return d.ErrPort.Send(ctx, ip.New(err))
+}
@@ -0,0 +1,160 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gzipPipeline wires an encoder into a decoder and returns the input and
+// output channels plus the decoder's error channel
+func gzipPipeline(ctx context.Context, t *testing.T) (*GzipEncoder, chan *ip.IP[[]byte], chan *ip.IP[error]) {
+	encoder := NewGzipEncoder()
+	decoder := NewGzipDecoder()
+
+	inCh := make(chan *ip.IP[[]byte], 10)
+	midCh := make(chan *ip.IP[[]byte], 10)
+	outCh := make(chan *ip.IP[[]byte], 100)
+	errCh := make(chan *ip.IP[error], 10)
+
+	require.NoError(t, ports.Connect(encoder.InPort, inCh))
+	require.NoError(t, ports.Connect(encoder.OutPort, midCh))
+	require.NoError(t, ports.Connect(decoder.InPort, midCh))
+	require.NoError(t, ports.Connect(decoder.OutPort, outCh))
+	require.NoError(t, ports.Connect(decoder.ErrPort, errCh))
+
+	go func() {
+		_ = encoder.Process(ctx)
+	}()
+	go func() {
+		_ = decoder.Process(ctx)
+	}()
+
+	return encoder, outCh, errCh
+}
+
+func TestGzipRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	encoder, outCh, _ := gzipPipeline(ctx, t)
+
+	chunks := []string{
+		strings.Repeat("hello ", 1000),
+		strings.Repeat("world ", 1000),
+		"the end",
+	}
+
+	require.NoError(t, encoder.InPort.Send(ctx, ip.NewOpenBracket[[]byte]()))
+	for _, chunk := range chunks {
+		require.NoError(t, encoder.InPort.Send(ctx, ip.New([]byte(chunk))))
+	}
+	require.NoError(t, encoder.InPort.Send(ctx, ip.NewCloseBracket[[]byte]()))
+
+	var got bytes.Buffer
+	select {
+	case packet := <-outCh:
+		require.Equal(t, ip.TypeBracketOpen, packet.Type())
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for open bracket")
+	}
+	for {
+		select {
+		case packet := <-outCh:
+			if packet.Type() == ip.TypeBracketClose {
+				assert.Equal(t, strings.Join(chunks, ""), got.String())
+				return
+			}
+			got.Write(packet.Data())
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for decompressed output")
+		}
+	}
+}
+
+func TestGzipSinglePacket(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	encoder, outCh, _ := gzipPipeline(ctx, t)
+	require.NoError(t, encoder.InPort.Send(ctx, ip.New([]byte("payload"))))
+
+	select {
+	case packet := <-outCh:
+		assert.Equal(t, []byte("payload"), packet.Data())
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for output")
+	}
+}
+
+func TestGzipDecoderCorruptInput(t *testing.T) {
+	decoder := NewGzipDecoder()
+
+	inCh := make(chan *ip.IP[[]byte], 5)
+	outCh := make(chan *ip.IP[[]byte], 5)
+	errOutCh := make(chan *ip.IP[error], 5)
+	require.NoError(t, ports.Connect(decoder.InPort, inCh))
+	require.NoError(t, ports.Connect(decoder.OutPort, outCh))
+	require.NoError(t, ports.Connect(decoder.ErrPort, errOutCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- decoder.Process(ctx)
+	}()
+
+	t.Run("single packet", func(t *testing.T) {
+		require.NoError(t, decoder.InPort.Send(ctx, ip.New([]byte("not gzip"))))
+
+		select {
+		case packet := <-errOutCh:
+			assert.Contains(t, packet.Data().Error(), "invalid gzip stream")
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for error")
+		}
+	})
+
+	t.Run("substream", func(t *testing.T) {
+		require.NoError(t, decoder.InPort.Send(ctx, ip.NewOpenBracket[[]byte]()))
+		require.NoError(t, decoder.InPort.Send(ctx, ip.New([]byte("corrupt"))))
+		require.NoError(t, decoder.InPort.Send(ctx, ip.New([]byte("more"))))
+		require.NoError(t, decoder.InPort.Send(ctx, ip.NewCloseBracket[[]byte]()))
+
+		select {
+		case packet := <-errOutCh:
+			assert.Contains(t, packet.Data().Error(), "invalid gzip stream")
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for error")
+		}
+
+		// Brackets still pass through so downstream framing stays balanced
+		var types []ip.Type
+		for len(types) < 2 {
+			select {
+			case packet := <-outCh:
+				types = append(types, packet.Type())
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for brackets")
+			}
+		}
+		assert.Equal(t, []ip.Type{ip.TypeBracketOpen, ip.TypeBracketClose}, types)
+	})
+
+	// The decoder keeps running after corrupt input
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+}
@@ -0,0 +1,234 @@
+package transform
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// Defaults of a new HTTPEnricher
+const (
+	DefaultEnrichCacheSize             = 1000
+	DefaultEnrichCacheTTL              = 5 * time.Minute
+	DefaultEnrichMaxResponseSize int64 = 10 << 20 // 10 MiB
+)
+
+// ErrLookupFailed is reported when an enrichment lookup does not succeed
+var ErrLookupFailed = errors.New("lookup failed")
+
+// HTTPEnricher looks up each packet with a GET request to the URL built
+// by URLFn and emits the decoded response.
+//
+// Successful responses are cached by URL in an LRU cache of CacheSize
+// entries that expire after CacheTTL, so repeated keys do not hit the
+// service again. Failed lookups, non-2xx responses and bodies larger than
+// MaxResponseSize are reported on ErrPort and never cached.
+//
+// When Resource names a resource limited in network.Limits.Resources,
+// each lookup request holds one of its slots; cache hits do not.
+type HTTPEnricher[In, Out any] struct {
+	*nodes.BaseNode[In, Out]
+	ErrPort         *ports.Port[error]
+	URLFn           func(In) string
+	Decode          func([]byte) Out
+	Client          *http.Client
+	CacheSize       int
+	CacheTTL        time.Duration
+	MaxResponseSize int64
+	Resource        string
+
+	cache *lruCache[Out]
+	once  sync.Once
+	now   func() time.Time
+}
+
+// NewHTTPEnricher creates a new HTTP enrichment node
+func NewHTTPEnricher[In, Out any](urlFn func(In) string, decode func([]byte) Out) *HTTPEnricher[In, Out] {
+	return &HTTPEnricher[In, Out]{
+		BaseNode:        nodes.NewBaseNode[In, Out]("HTTPEnricher"),
+		ErrPort:         ports.NewOutput[error]("err", "Lookup errors", false),
+		URLFn:           urlFn,
+		Decode:          decode,
+		Client:          http.DefaultClient,
+		CacheSize:       DefaultEnrichCacheSize,
+		CacheTTL:        DefaultEnrichCacheTTL,
+		MaxResponseSize: DefaultEnrichMaxResponseSize,
+		now:             time.Now,
+	}
+}
+
+// Lookup returns the decoded response for in, from the cache when possible
+func (e *HTTPEnricher[In, Out]) Lookup(ctx context.Context, in In) (Out, error) {
+	e.once.Do(func() {
+		e.cache = newLRUCache[Out](e.CacheSize)
+	})
+
+	url := e.URLFn(in)
+	now := e.now()
+	if out, ok := e.cache.get(url, now); ok {
+		return out, nil
+	}
+
+	var zero Out
+	body, err := e.fetch(ctx, url)
+	if err != nil {
+		return zero, err
+	}
+
+	out := e.Decode(body)
+	if e.CacheTTL > 0 {
+		e.cache.put(url, out, now.Add(e.CacheTTL))
+	}
+	return out, nil
+}
+
+// fetch GETs url and returns the response body
+func (e *HTTPEnricher[In, Out]) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLookupFailed, err)
+	}
+
+	sem := network.ResourceFromContext(ctx, e.Resource)
+	if err := sem.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer sem.Release()
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLookupFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%w: %s returned %s", ErrLookupFailed, url, resp.Status)
+	}
+
+	reader := io.Reader(resp.Body)
+	if e.MaxResponseSize > 0 {
+		reader = io.LimitReader(resp.Body, e.MaxResponseSize+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLookupFailed, err)
+	}
+	if e.MaxResponseSize > 0 && int64(len(body)) > e.MaxResponseSize {
+		return nil, fmt.Errorf("%w: response exceeds %d bytes", ErrLookupFailed, e.MaxResponseSize)
+	}
+	return body, nil
+}
+
+// Process implements the processing logic
+func (e *HTTPEnricher[In, Out]) Process(ctx context.Context) error {
+	if e.URLFn == nil || e.Decode == nil {
+		return fmt.Errorf("nil URL or decode function")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := e.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			out, err := e.Lookup(ctx, packet.Data())
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				packetErr := nodes.NewPacketError(e.Name(), packet, err)
+				if err := e.ErrPort.Send(ctx, ip.New[error](packetErr)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := e.OutPort.Send(ctx, ip.New(out)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// lruCache is a size-bounded cache evicting the least recently used entry,
+// with per-entry expiry
+type lruCache[V any] struct {
+	size    int
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+	mu      sync.Mutex
+}
+
+type lruEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+func newLRUCache[V any](size int) *lruCache[V] {
+	return &lruCache[V]{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache[V]) get(key string, now time.Time) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry := elem.Value.(*lruEntry[V])
+	if !now.Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *lruCache[V]) put(key string, value V, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size <= 0 {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &lruEntry[V]{key: key, value: value, expires: expires}
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+func (c *lruCache[V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,119 @@
+package transform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLookupServer serves "name:<id>" for /users/<id> and 404 for unknown ids
+func newLookupServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		id := strings.TrimPrefix(r.URL.Path, "/users/")
+		if id == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("name:" + id))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestHTTPEnricher(t *testing.T) {
+	srv, calls := newLookupServer(t)
+
+	enricher := NewHTTPEnricher[string, string](
+		func(id string) string { return srv.URL + "/users/" + id },
+		func(body []byte) string { return string(body) },
+	)
+
+	inCh := make(chan *ip.IP[string], 3)
+	outCh := make(chan *ip.IP[string], 3)
+	errOutCh := make(chan *ip.IP[error], 1)
+	require.NoError(t, ports.Connect(enricher.InPort, inCh))
+	require.NoError(t, ports.Connect(enricher.OutPort, outCh))
+	require.NoError(t, ports.Connect(enricher.ErrPort, errOutCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- enricher.Process(ctx)
+	}()
+
+	inCh <- ip.New("42")
+	inCh <- ip.New("42")
+	inCh <- ip.New("missing")
+
+	for i := 0; i < 2; i++ {
+		select {
+		case packet := <-outCh:
+			assert.Equal(t, "name:42", packet.Data())
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for output")
+		}
+	}
+
+	select {
+	case packet := <-errOutCh:
+		assert.ErrorIs(t, packet.Data(), ErrLookupFailed)
+		var packetErr *nodes.PacketError
+		require.ErrorAs(t, packet.Data(), &packetErr)
+		assert.Equal(t, "missing", packetErr.Data)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for error")
+	}
+
+	assert.Equal(t, int32(2), calls.Load(), "second lookup of 42 should hit the cache")
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+}
+
+func TestHTTPEnricherCache(t *testing.T) {
+	srv, calls := newLookupServer(t)
+
+	now := time.Unix(0, 0)
+	enricher := NewHTTPEnricher[string, string](
+		func(id string) string { return srv.URL + "/users/" + id },
+		func(body []byte) string { return string(body) },
+	)
+	enricher.CacheSize = 2
+	enricher.CacheTTL = time.Minute
+	enricher.now = func() time.Time { return now }
+
+	lookup := func(id string) {
+		_, err := enricher.Lookup(context.Background(), id)
+		require.NoError(t, err)
+	}
+
+	lookup("a")
+	lookup("b")
+	lookup("a") // hit; b is now least recently used
+	assert.Equal(t, int32(2), calls.Load())
+
+	lookup("c") // evicts b
+	assert.Equal(t, 2, enricher.cache.len())
+	lookup("a")
+	assert.Equal(t, int32(3), calls.Load())
+	lookup("b")
+	assert.Equal(t, int32(4), calls.Load())
+
+	now = now.Add(time.Minute)
+	lookup("b") // expired
+	assert.Equal(t, int32(5), calls.Load())
+}
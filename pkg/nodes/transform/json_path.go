@@ -0,0 +1,164 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+var (
+	// ErrInvalidPath is returned for a malformed JSONPath expression
+	ErrInvalidPath = errors.New("invalid JSON path")
+	// ErrPathNotFound is reported when a document has no value at the path
+	ErrPathNotFound = errors.New("JSON path not found")
+)
+
+// pathStep is one field name or array index of a compiled path
+type pathStep struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// JSONPath extracts the value at a JSONPath expression from each input
+// document. Input may be raw JSON ([]byte or string) or already decoded
+// values (map[string]any, []any). Documents without a value at the path
+// are reported on ErrPort.
+//
+// The supported subset is a root "$" followed by ".field", "['field']"
+// and "[index]" steps, e.g. "$.user.name" or "$.items[0]['id']".
+type JSONPath[In any] struct {
+	*nodes.BaseNode[In, any]
+	ErrPort *ports.Port[error]
+	Path    string
+	steps   []pathStep
+}
+
+// NewJSONPath compiles path and creates a new JSON path node
+func NewJSONPath[In any](path string) (*JSONPath[In], error) {
+	steps, err := compilePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONPath[In]{
+		BaseNode: nodes.NewBaseNode[In, any]("JSONPath"),
+		ErrPort:  ports.NewOutput[error]("err", "Extraction errors", false),
+		Path:     path,
+		steps:    steps,
+	}, nil
+}
+
+// compilePath parses a JSONPath expression into steps
+func compilePath(path string) ([]pathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("%w %q: must start with $", ErrInvalidPath, path)
+	}
+
+	var steps []pathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("%w %q: empty field name", ErrInvalidPath, path)
+			}
+			steps = append(steps, pathStep{key: key})
+			rest = rest[end+1:]
+
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("%w %q: unclosed [", ErrInvalidPath, path)
+			}
+			inner := rest[1:end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, pathStep{key: inner[1 : len(inner)-1]})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("%w %q: bad index [%s]", ErrInvalidPath, path, inner)
+				}
+				steps = append(steps, pathStep{index: index, isIdx: true})
+			}
+			rest = rest[end+1:]
+
+		default:
+			return nil, fmt.Errorf("%w %q: unexpected %q", ErrInvalidPath, path, rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// Extract returns the value at the path in doc
+func (j *JSONPath[In]) Extract(doc In) (any, error) {
+	var value any = doc
+	switch raw := value.(type) {
+	case []byte:
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case string:
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	}
+
+	for _, step := range j.steps {
+		if step.isIdx {
+			list, ok := value.([]any)
+			if !ok || step.index >= len(list) {
+				return nil, fmt.Errorf("%w: %s", ErrPathNotFound, j.Path)
+			}
+			value = list[step.index]
+			continue
+		}
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, j.Path)
+		}
+		if value, ok = obj[step.key]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, j.Path)
+		}
+	}
+	return value, nil
+}
+
+// Process implements the processing logic
+func (j *JSONPath[In]) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := j.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			value, err := j.Extract(packet.Data())
+			if err != nil {
+				packetErr := nodes.NewPacketError(j.Name(), packet, err)
+				if err := j.ErrPort.Send(ctx, ip.New[error](packetErr)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := j.OutPort.Send(ctx, ip.New(value)); err != nil {
+				return err
+			}
+		}
+	}
+}
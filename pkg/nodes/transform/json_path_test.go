@@ -0,0 +1,110 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleDocument = `{
+	"user": {"name": "ada", "roles": ["admin", "dev"]},
+	"items": [{"id": 7, "tags": {"the key": true}}]
+}`
+
+func TestCompilePath(t *testing.T) {
+	for _, path := range []string{"$", "$.user.name", "$.items[0]['id']", `$["user"].roles[1]`} {
+		_, err := NewJSONPath[[]byte](path)
+		assert.NoError(t, err, path)
+	}
+
+	for _, path := range []string{"", "user.name", "$.", "$..name", "$.items[", "$.items[-1]", "$.items[x]", "$user"} {
+		_, err := NewJSONPath[[]byte](path)
+		assert.ErrorIs(t, err, ErrInvalidPath, path)
+	}
+}
+
+func TestJSONPathExtract(t *testing.T) {
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"$.user.name", "ada"},
+		{"$.user.roles[1]", "dev"},
+		{"$.items[0].id", float64(7)},
+		{"$.items[0].tags['the key']", true},
+	}
+	for _, tt := range tests {
+		extractor, err := NewJSONPath[[]byte](tt.path)
+		require.NoError(t, err)
+
+		got, err := extractor.Extract([]byte(sampleDocument))
+		require.NoError(t, err, tt.path)
+		assert.Equal(t, tt.want, got, tt.path)
+	}
+
+	t.Run("decoded input", func(t *testing.T) {
+		extractor, err := NewJSONPath[map[string]any]("$.user.name")
+		require.NoError(t, err)
+
+		got, err := extractor.Extract(map[string]any{
+			"user": map[string]any{"name": "grace"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "grace", got)
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		for _, path := range []string{"$.user.email", "$.user.roles[5]", "$.user.name.first", "$.items.id"} {
+			extractor, err := NewJSONPath[[]byte](path)
+			require.NoError(t, err)
+
+			_, err = extractor.Extract([]byte(sampleDocument))
+			assert.ErrorIs(t, err, ErrPathNotFound, path)
+		}
+	})
+}
+
+func TestJSONPath(t *testing.T) {
+	extractor, err := NewJSONPath[[]byte]("$.user.name")
+	require.NoError(t, err)
+
+	inCh := make(chan *ip.IP[[]byte], 2)
+	outCh := make(chan *ip.IP[any], 2)
+	errOutCh := make(chan *ip.IP[error], 2)
+	require.NoError(t, ports.Connect(extractor.InPort, inCh))
+	require.NoError(t, ports.Connect(extractor.OutPort, outCh))
+	require.NoError(t, ports.Connect(extractor.ErrPort, errOutCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- extractor.Process(ctx)
+	}()
+
+	require.NoError(t, extractor.InPort.Send(ctx, ip.New([]byte(sampleDocument))))
+	require.NoError(t, extractor.InPort.Send(ctx, ip.New([]byte(`{"user":{}}`))))
+
+	select {
+	case packet := <-outCh:
+		assert.Equal(t, "ada", packet.Data())
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for output")
+	}
+
+	select {
+	case packet := <-errOutCh:
+		assert.ErrorIs(t, packet.Data(), ErrPathNotFound)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for error")
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+}
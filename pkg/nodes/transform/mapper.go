@@ -3,19 +3,30 @@ package transform
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
 	"github.com/elleshadow/noPromises/pkg/nodes"
 )
 
+// Mapper applies Transform to each packet.
+//
+// When Timeout is set, a packet whose transform runs longer is abandoned
+// and a nodes.ErrProcessingTimeout error is sent to ErrPort, so one hung
+// packet does not stall the flow.
 type Mapper[In, Out any] struct {
 	*nodes.BaseNode[In, Out]
 	Transform func(In) Out
+	Timeout   time.Duration
+	ErrPort   *ports.Port[error]
 }
 
 func NewMapper[In, Out any](transform func(In) Out) *Mapper[In, Out] {
 	return &Mapper[In, Out]{
 		BaseNode:  nodes.NewBaseNode[In, Out]("Mapper"),
 		Transform: transform,
+		ErrPort:   ports.NewOutput[error]("err", "Processing errors", false),
 	}
 }
 
@@ -31,10 +42,24 @@ func (m *Mapper[In, Out]) Process(ctx context.Context) error {
 		default:
 			packet, err := m.InPort.Receive(ctx)
 			if err != nil {
-				return err
+				return nodes.EndOfStream(err)
+			}
+
+			data := packet.Data()
+			result, err := nodes.CallWithTimeout(ctx, m.Timeout, func() Out {
+				return m.Transform(data)
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				packetErr := nodes.NewPacketError(m.Name(), packet, err)
+				if err := m.ErrPort.Send(ctx, ip.New[error](packetErr)); err != nil {
+					return err
+				}
+				continue
 			}
 
-			result := m.Transform(packet.Data())
 			if err := m.OutPort.Send(ctx, ip.New(result)); err != nil {
 				return err
 			}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/elleshadow/noPromises/pkg/core/ip"
 	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -74,3 +75,114 @@ func TestMapperNilTransform(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "nil transform")
 }
+
+func TestMapperTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	mapper := NewMapper[string, string](func(s string) string {
+		if s == "hang" {
+			<-release
+		}
+		return strings.ToUpper(s)
+	})
+	mapper.Timeout = 50 * time.Millisecond
+
+	inCh := make(chan *ip.IP[string], 3)
+	outCh := make(chan *ip.IP[string], 3)
+	errOutCh := make(chan *ip.IP[error], 3)
+	require.NoError(t, ports.Connect(mapper.InPort, inCh))
+	require.NoError(t, ports.Connect(mapper.OutPort, outCh))
+	require.NoError(t, ports.Connect(mapper.ErrPort, errOutCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- mapper.Process(ctx)
+	}()
+
+	hung := ip.New("hang")
+	require.NoError(t, mapper.InPort.Send(ctx, ip.New("a")))
+	require.NoError(t, mapper.InPort.Send(ctx, hung))
+	require.NoError(t, mapper.InPort.Send(ctx, ip.New("b")))
+
+	var results []string
+	for len(results) < 2 {
+		select {
+		case packet := <-outCh:
+			results = append(results, packet.Data())
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for output")
+		}
+	}
+	assert.Equal(t, []string{"A", "B"}, results)
+
+	select {
+	case packet := <-errOutCh:
+		assert.ErrorIs(t, packet.Data(), nodes.ErrProcessingTimeout)
+		assert.Contains(t, packet.Data().Error(), hung.ID())
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for timeout error")
+	}
+	assert.Empty(t, errOutCh, "only the hung packet should time out")
+
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+}
+
+func TestMapperTimeoutPanic(t *testing.T) {
+	mapper := NewMapper[string, string](func(s string) string {
+		if s == "boom" {
+			panic("transform exploded")
+		}
+		return strings.ToUpper(s)
+	})
+	mapper.Timeout = time.Second
+
+	inCh := make(chan *ip.IP[string], 2)
+	outCh := make(chan *ip.IP[string], 2)
+	errOutCh := make(chan *ip.IP[error], 2)
+	require.NoError(t, ports.Connect(mapper.InPort, inCh))
+	require.NoError(t, ports.Connect(mapper.OutPort, outCh))
+	require.NoError(t, ports.Connect(mapper.ErrPort, errOutCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- mapper.Process(ctx)
+	}()
+
+	require.NoError(t, mapper.InPort.Send(ctx, ip.New("boom")))
+	require.NoError(t, mapper.InPort.Send(ctx, ip.New("a")))
+
+	select {
+	case packet := <-errOutCh:
+		assert.ErrorIs(t, packet.Data(), nodes.ErrProcessingPanicked)
+		assert.Contains(t, packet.Data().Error(), "transform exploded")
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for panic error")
+	}
+	select {
+	case packet := <-outCh:
+		assert.Equal(t, "A", packet.Data(), "the mapper should keep going after a panic")
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for output")
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+}
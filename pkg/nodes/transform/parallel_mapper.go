@@ -0,0 +1,209 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// DefaultScaleInterval is how often an autoscaling ParallelMapper may
+// change its worker count
+const DefaultScaleInterval = 100 * time.Millisecond
+
+// errRetired ends a worker removed when scaling down
+var errRetired = errors.New("worker retired")
+
+// ParallelMapperStats is a snapshot of a ParallelMapper's activity
+type ParallelMapperStats struct {
+	Workers   int
+	Processed uint64
+}
+
+// ParallelMapper applies Transform using several worker goroutines. Output
+// order is not preserved.
+//
+// With MaxWorkers above Workers the mapper autoscales: it starts with
+// Workers workers and, at most once per ScaleInterval, adds one while the
+// input queue is non-empty, up to MaxWorkers, or retires one while the
+// queue is empty and a worker sits idle, down to Workers. Queue depth is
+// only visible on buffered input channels.
+//
+// Each worker holds a slot of the network's semaphore while it runs, so a
+// flow capped by network.Limits.MaxGoroutines never runs more workers than
+// the cap; workers beyond it wait for a slot instead of starting.
+type ParallelMapper[In, Out any] struct {
+	*nodes.BaseNode[In, Out]
+	Transform     func(In) Out
+	Workers       int
+	MaxWorkers    int
+	ScaleInterval time.Duration
+	running       atomic.Int64
+	busy          atomic.Int64
+	processed     atomic.Uint64
+}
+
+func NewParallelMapper[In, Out any](workers int, transform func(In) Out) *ParallelMapper[In, Out] {
+	return &ParallelMapper[In, Out]{
+		BaseNode:      nodes.NewBaseNode[In, Out]("ParallelMapper"),
+		Transform:     transform,
+		Workers:       workers,
+		ScaleInterval: DefaultScaleInterval,
+	}
+}
+
+// Stats returns the current worker count and how many packets were mapped
+func (m *ParallelMapper[In, Out]) Stats() ParallelMapperStats {
+	return ParallelMapperStats{
+		Workers:   int(m.running.Load()),
+		Processed: m.processed.Load(),
+	}
+}
+
+func (m *ParallelMapper[In, Out]) Process(ctx context.Context) error {
+	if m.Transform == nil {
+		return fmt.Errorf("nil transform function")
+	}
+	minWorkers := m.Workers
+	if minWorkers < 1 {
+		minWorkers = 1
+	}
+	maxWorkers := m.MaxWorkers
+	if maxWorkers < minWorkers {
+		maxWorkers = minWorkers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := network.SemaphoreFromContext(ctx)
+	exits := make(chan error)
+	var wg sync.WaitGroup
+	// retire holds a cancel per worker; cancelling one retires that worker
+	var retire []context.CancelFunc
+
+	start := func() {
+		workerCtx, retireWorker := context.WithCancel(ctx)
+		retire = append(retire, retireWorker)
+		m.running.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer m.running.Add(-1)
+			if err := sem.Acquire(workerCtx); err != nil {
+				if ctx.Err() == nil {
+					err = errRetired
+				}
+				exits <- err
+				return
+			}
+			defer sem.Release()
+			exits <- m.work(ctx, workerCtx)
+		}()
+	}
+	stop := func(err error) error {
+		cancel()
+		go func() {
+			wg.Wait()
+			close(exits)
+		}()
+		for range exits {
+		}
+		return err
+	}
+
+	for i := 0; i < minWorkers; i++ {
+		start()
+	}
+
+	var tick <-chan time.Time
+	if maxWorkers > minWorkers {
+		interval := m.ScaleInterval
+		if interval <= 0 {
+			interval = DefaultScaleInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	live, retiring := minWorkers, 0
+	ending := false
+	for live > 0 {
+		select {
+		case err := <-exits:
+			live--
+			switch {
+			case errors.Is(err, errRetired):
+				retiring--
+			case err != nil:
+				// The first worker to fail ends the node
+				return stop(err)
+			default:
+				// Workers stopping cleanly at the end of the input stream
+				// let the others finish their packets
+				ending = true
+			}
+		case <-tick:
+			if ending {
+				continue
+			}
+			active := live - retiring
+			switch {
+			case m.InPort.Len() > 0 && active < maxWorkers:
+				start()
+				live++
+			case m.InPort.Len() == 0 && active > minWorkers && int(m.busy.Load()) < active:
+				retire[len(retire)-1]()
+				retire = retire[:len(retire)-1]
+				retiring++
+			}
+		}
+	}
+	return nil
+}
+
+// work receives, transforms and sends packets until an error occurs or
+// workerCtx retires it. A packet already received is always sent. A panic
+// in the transform is returned as a *network.PanicError.
+func (m *ParallelMapper[In, Out]) work(ctx, workerCtx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			log.Printf("process %s panicked: %v\n%s", m.Name(), r, stack)
+			err = &network.PanicError{Process: m.Name(), Value: r, Stack: stack}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := m.InPort.Receive(workerCtx)
+			if err != nil {
+				if workerCtx.Err() != nil && ctx.Err() == nil {
+					return errRetired
+				}
+				return nodes.EndOfStream(err)
+			}
+
+			m.busy.Add(1)
+			result := m.Transform(packet.Data())
+			err = m.OutPort.Send(ctx, ip.New(result))
+			m.busy.Add(-1)
+			if err != nil {
+				return err
+			}
+			m.processed.Add(1)
+		}
+	}
+}
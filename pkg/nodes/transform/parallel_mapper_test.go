@@ -0,0 +1,167 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelMapper(t *testing.T) {
+	var running, peak int32
+	double := func(n int) int {
+		cur := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if cur <= old || atomic.CompareAndSwapInt32(&peak, old, cur) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return n * 2
+	}
+
+	run := func(t *testing.T, ctx context.Context, workers int) ([]int, int32) {
+		atomic.StoreInt32(&peak, 0)
+		mapper := NewParallelMapper(workers, double)
+
+		inCh := make(chan *ip.IP[int], 20)
+		outCh := make(chan *ip.IP[int], 20)
+		require.NoError(t, ports.Connect(mapper.InPort, inCh))
+		require.NoError(t, ports.Connect(mapper.OutPort, outCh))
+
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- mapper.Process(ctx)
+		}()
+
+		for i := 1; i <= 10; i++ {
+			require.NoError(t, mapper.InPort.Send(ctx, ip.New(i)))
+		}
+
+		results := make([]int, 0, 10)
+		for len(results) < 10 {
+			select {
+			case packet := <-outCh:
+				results = append(results, packet.Data())
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for output")
+			}
+		}
+		sort.Ints(results)
+
+		cancel()
+		select {
+		case err := <-errCh:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for shutdown")
+		}
+		return results, atomic.LoadInt32(&peak)
+	}
+
+	expected := []int{2, 4, 6, 8, 10, 12, 14, 16, 18, 20}
+
+	t.Run("unlimited", func(t *testing.T) {
+		results, peak := run(t, context.Background(), 4)
+		assert.Equal(t, expected, results)
+		assert.Greater(t, peak, int32(2))
+	})
+
+	t.Run("capped by network limits", func(t *testing.T) {
+		ctx := network.WithLimits(context.Background(), network.Limits{MaxGoroutines: 2})
+		results, peak := run(t, ctx, 4)
+		assert.Equal(t, expected, results)
+		assert.LessOrEqual(t, peak, int32(2))
+	})
+}
+
+func TestParallelMapperPanic(t *testing.T) {
+	mapper := NewParallelMapper(2, func(n int) int {
+		if n == 3 {
+			panic("worker exploded")
+		}
+		return n * 2
+	})
+
+	inCh := make(chan *ip.IP[int], 5)
+	outCh := make(chan *ip.IP[int], 5)
+	require.NoError(t, ports.Connect(mapper.InPort, inCh))
+	require.NoError(t, ports.Connect(mapper.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, mapper.InPort.Send(ctx, ip.New(i)))
+	}
+
+	err := mapper.Process(ctx)
+	var panicErr *network.PanicError
+	require.True(t, errors.As(err, &panicErr), "expected a PanicError, got %v", err)
+	assert.Equal(t, "ParallelMapper", panicErr.Process)
+	assert.Equal(t, "worker exploded", panicErr.Value)
+}
+
+func TestParallelMapperAutoscale(t *testing.T) {
+	mapper := NewParallelMapper(1, func(n int) int {
+		time.Sleep(10 * time.Millisecond)
+		return n
+	})
+	mapper.MaxWorkers = 4
+	mapper.ScaleInterval = 10 * time.Millisecond
+
+	inCh := make(chan *ip.IP[int], 200)
+	outCh := make(chan *ip.IP[int], 200)
+	require.NoError(t, ports.Connect(mapper.InPort, inCh))
+	require.NoError(t, ports.Connect(mapper.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- mapper.Process(ctx)
+	}()
+
+	// A burst queues far more input than one worker keeps up with
+	for i := 0; i < 200; i++ {
+		inCh <- ip.New(i)
+	}
+	assert.Eventually(t, func() bool {
+		return mapper.Stats().Workers == 4
+	}, 2*time.Second, 5*time.Millisecond, "workers should scale up to the max")
+
+	for i := 0; i < 200; i++ {
+		select {
+		case <-outCh:
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for output")
+		}
+	}
+	assert.Eventually(t, func() bool {
+		return mapper.Stats().Workers == 1
+	}, 2*time.Second, 5*time.Millisecond, "workers should scale down once idle")
+	assert.Equal(t, uint64(200), mapper.Stats().Processed)
+
+	cancel()
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+	assert.Equal(t, 0, mapper.Stats().Workers)
+}
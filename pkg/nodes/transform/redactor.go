@@ -0,0 +1,179 @@
+package transform
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// Redacted is what FullMask replaces values with
+const Redacted = "[REDACTED]"
+
+// FullMask replaces a value with Redacted
+func FullMask(string) string {
+	return Redacted
+}
+
+// HashMask replaces a value with a short hash of it, so redacted values
+// can still be correlated without being revealed
+func HashMask(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// Redactor masks named fields of struct and map payloads before they reach
+// logs or sinks.
+//
+// Struct fields match by Go name or by their json tag name; map entries
+// match by key. String values are replaced with Mask(value); map entries
+// holding other values are replaced with Mask of their formatted value,
+// while non-string struct fields are left alone. Fields that do not exist
+// are skipped, and other payloads pass through unchanged. Payloads are
+// copied, never modified in place. A nil Mask uses FullMask.
+type Redactor[T any] struct {
+	*nodes.BaseNode[T, T]
+	Fields []string
+	Mask   func(string) string
+}
+
+// NewRedactor creates a new redactor node
+func NewRedactor[T any](fields []string, mask func(string) string) *Redactor[T] {
+	return &Redactor[T]{
+		BaseNode: nodes.NewBaseNode[T, T]("Redactor"),
+		Fields:   fields,
+		Mask:     mask,
+	}
+}
+
+// Redact returns a copy of v with the named fields masked
+func (r *Redactor[T]) Redact(v T) T {
+	mask := r.Mask
+	if mask == nil {
+		mask = FullMask
+	}
+	fields := make(map[string]bool, len(r.Fields))
+	for _, field := range r.Fields {
+		fields[field] = true
+	}
+
+	value := reflect.ValueOf(&v).Elem()
+	if value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return v
+		}
+		value = value.Elem()
+	}
+	if redacted, ok := redactValue(value, fields, mask); ok {
+		reflect.ValueOf(&v).Elem().Set(redacted)
+	}
+	return v
+}
+
+// redactValue returns a masked copy of a struct, struct pointer or
+// string-keyed map, reporting false for any other kind of value
+func redactValue(value reflect.Value, fields map[string]bool, mask func(string) string) (reflect.Value, bool) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() || value.Elem().Kind() != reflect.Struct {
+			return value, false
+		}
+		copied := reflect.New(value.Elem().Type())
+		copied.Elem().Set(redactStruct(value.Elem(), fields, mask))
+		return copied, true
+
+	case reflect.Struct:
+		return redactStruct(value, fields, mask), true
+
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String || value.IsNil() {
+			return value, false
+		}
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			entry := iter.Value()
+			if fields[iter.Key().String()] {
+				if masked, ok := maskValue(entry, value.Type().Elem(), mask); ok {
+					entry = masked
+				}
+			}
+			copied.SetMapIndex(iter.Key(), entry)
+		}
+		return copied, true
+	}
+	return value, false
+}
+
+// redactStruct returns a copy of a struct with its named string fields masked
+func redactStruct(value reflect.Value, fields map[string]bool, mask func(string) string) reflect.Value {
+	copied := reflect.New(value.Type()).Elem()
+	copied.Set(value)
+
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() || !(fields[field.Name] || fields[jsonName(field)]) {
+			continue
+		}
+		if field.Type.Kind() == reflect.String {
+			copied.Field(i).SetString(mask(copied.Field(i).String()))
+		}
+	}
+	return copied
+}
+
+// maskValue masks a map entry, which must stay assignable to elemType
+func maskValue(entry reflect.Value, elemType reflect.Type, mask func(string) string) (reflect.Value, bool) {
+	switch {
+	case elemType.Kind() == reflect.String:
+		return reflect.ValueOf(mask(entry.String())).Convert(elemType), true
+	case elemType.Kind() == reflect.Interface && reflect.TypeOf("").AssignableTo(elemType):
+		if entry.IsNil() {
+			return entry, false
+		}
+		inner := entry.Elem()
+		if inner.Kind() == reflect.String {
+			return reflect.ValueOf(mask(inner.String())), true
+		}
+		return reflect.ValueOf(mask(fmt.Sprint(inner.Interface()))), true
+	}
+	return entry, false
+}
+
+// jsonName returns the name a field has in its json tag, if any
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// Process implements the processing logic
+func (r *Redactor[T]) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := r.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			redacted := ip.New(r.Redact(packet.Data()))
+			for k, v := range packet.Metadata() {
+				redacted.SetMetadata(k, v)
+			}
+			if err := r.OutPort.Send(ctx, redacted); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type customer struct {
+	Name  string
+	Email string `json:"email_address"`
+	Age   int
+}
+
+func TestRedactor(t *testing.T) {
+	redactor := NewRedactor[customer]([]string{"email_address", "Phone"}, HashMask)
+
+	inCh := make(chan *ip.IP[customer], 1)
+	outCh := make(chan *ip.IP[customer], 1)
+	require.NoError(t, ports.Connect(redactor.InPort, inCh))
+	require.NoError(t, ports.Connect(redactor.OutPort, outCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go redactor.Process(ctx)
+
+	packet := ip.New(customer{Name: "Ada", Email: "ada@example.com", Age: 36})
+	packet.SetMetadata("source", "signup")
+	inCh <- packet
+
+	select {
+	case out := <-outCh:
+		got := out.Data()
+		assert.Equal(t, HashMask("ada@example.com"), got.Email)
+		assert.NotContains(t, got.Email, "ada@")
+		assert.Equal(t, "Ada", got.Name)
+		assert.Equal(t, 36, got.Age)
+		source, _ := out.GetMetadata("source")
+		assert.Equal(t, "signup", source)
+	case <-ctx.Done():
+		t.Fatal("timeout waiting for output")
+	}
+
+	assert.Equal(t, "ada@example.com", packet.Data().Email, "input must not be modified")
+}
+
+func TestRedact(t *testing.T) {
+	t.Run("map", func(t *testing.T) {
+		r := NewRedactor[map[string]any]([]string{"email", "ssn", "missing"}, nil)
+		in := map[string]any{"email": "ada@example.com", "ssn": 123456789, "name": "Ada"}
+
+		got := r.Redact(in)
+		assert.Equal(t, map[string]any{"email": Redacted, "ssn": Redacted, "name": "Ada"}, got)
+		assert.Equal(t, "ada@example.com", in["email"], "input must not be modified")
+	})
+
+	t.Run("struct pointer", func(t *testing.T) {
+		r := NewRedactor[*customer]([]string{"Email"}, FullMask)
+		in := &customer{Name: "Ada", Email: "ada@example.com"}
+
+		got := r.Redact(in)
+		assert.Equal(t, Redacted, got.Email)
+		assert.Equal(t, "ada@example.com", in.Email)
+	})
+
+	t.Run("interface payload", func(t *testing.T) {
+		r := NewRedactor[any]([]string{"email"}, FullMask)
+		got := r.Redact(map[string]string{"email": "ada@example.com"})
+		assert.Equal(t, map[string]string{"email": Redacted}, got)
+	})
+
+	t.Run("other payloads pass through", func(t *testing.T) {
+		r := NewRedactor[string]([]string{"email"}, FullMask)
+		assert.Equal(t, "ada@example.com", r.Redact("ada@example.com"))
+	})
+
+	t.Run("hash mask is stable", func(t *testing.T) {
+		assert.Equal(t, HashMask("x"), HashMask("x"))
+		assert.NotEqual(t, HashMask("x"), HashMask("y"))
+	})
+}
@@ -0,0 +1,98 @@
+package transform
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// RegexExtractor matches each input line against a regular expression and
+// emits the named capture groups listed in Fields. Lines that do not match
+// are sent unchanged to RejectPort.
+type RegexExtractor struct {
+	*nodes.BaseNode[string, map[string]string]
+	RejectPort *ports.Port[string]
+	Fields     []string
+	pattern    *regexp.Regexp
+}
+
+// NewRegexExtractor compiles pattern and creates a new regex extractor
+// node. An empty fields list extracts every named group; otherwise each
+// field must name a group in the pattern.
+func NewRegexExtractor(pattern string, fields []string) (*RegexExtractor, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	groups := make(map[string]bool)
+	var named []string
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			groups[name] = true
+			named = append(named, name)
+		}
+	}
+
+	if len(fields) == 0 {
+		fields = named
+	}
+	for _, field := range fields {
+		if !groups[field] {
+			return nil, fmt.Errorf("pattern has no capture group named %q", field)
+		}
+	}
+
+	return &RegexExtractor{
+		BaseNode:   nodes.NewBaseNode[string, map[string]string]("RegexExtractor"),
+		RejectPort: ports.NewOutput[string]("reject", "Lines not matching the pattern", false),
+		Fields:     fields,
+		pattern:    re,
+	}, nil
+}
+
+// Extract returns the configured groups captured from line, or false if
+// line does not match
+func (e *RegexExtractor) Extract(line string) (map[string]string, bool) {
+	match := e.pattern.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+
+	result := make(map[string]string, len(e.Fields))
+	for _, field := range e.Fields {
+		result[field] = match[e.pattern.SubexpIndex(field)]
+	}
+	return result, true
+}
+
+// Process implements the processing logic
+func (e *RegexExtractor) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := e.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			result, ok := e.Extract(packet.Data())
+			if !ok {
+				if err := e.RejectPort.Send(ctx, ip.New(packet.Data())); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := e.OutPort.Send(ctx, ip.New(result)); err != nil {
+				return err
+			}
+		}
+	}
+}
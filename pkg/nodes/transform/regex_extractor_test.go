@@ -0,0 +1,72 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const logPattern = `^(?P<level>[A-Z]+) (?P<time>\S+) (?P<msg>.*)$`
+
+func TestNewRegexExtractor(t *testing.T) {
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := NewRegexExtractor(`(unclosed`, nil)
+		assert.ErrorContains(t, err, "invalid pattern")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := NewRegexExtractor(logPattern, []string{"level", "host"})
+		assert.ErrorContains(t, err, `"host"`)
+	})
+
+	t.Run("all named groups by default", func(t *testing.T) {
+		extractor, err := NewRegexExtractor(logPattern, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"level", "time", "msg"}, extractor.Fields)
+	})
+}
+
+func TestRegexExtractor(t *testing.T) {
+	extractor, err := NewRegexExtractor(logPattern, []string{"level", "msg"})
+	require.NoError(t, err)
+
+	inCh := make(chan *ip.IP[string], 2)
+	outCh := make(chan *ip.IP[map[string]string], 2)
+	rejectCh := make(chan *ip.IP[string], 2)
+	require.NoError(t, ports.Connect(extractor.InPort, inCh))
+	require.NoError(t, ports.Connect(extractor.OutPort, outCh))
+	require.NoError(t, ports.Connect(extractor.RejectPort, rejectCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- extractor.Process(ctx)
+	}()
+
+	require.NoError(t, extractor.InPort.Send(ctx, ip.New("ERROR 12:00:01 disk full")))
+	require.NoError(t, extractor.InPort.Send(ctx, ip.New("not a log line")))
+
+	select {
+	case packet := <-outCh:
+		assert.Equal(t, map[string]string{"level": "ERROR", "msg": "disk full"}, packet.Data())
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for output")
+	}
+
+	select {
+	case packet := <-rejectCh:
+		assert.Equal(t, "not a log line", packet.Data())
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for reject")
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+}
@@ -0,0 +1,392 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+)
+
+// MetadataSchemaErrors is the metadata key holding the validation errors
+// ([]string) of a packet sent to a SchemaValidator's RejectPort
+const MetadataSchemaErrors = "schema_errors"
+
+// ErrInvalidSchema is returned for a schema that cannot be compiled
+var ErrInvalidSchema = errors.New("invalid JSON schema")
+
+// schema is a compiled JSON Schema
+type schema struct {
+	types                []string
+	enum                 []any
+	constant             *any
+	properties           map[string]*schema
+	required             []string
+	additionalProperties *schema
+	noAdditional         bool
+	items                *schema
+	minItems, maxItems   *int
+	minimum, maximum     *float64
+	exclusiveMin         *float64
+	exclusiveMax         *float64
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+}
+
+// SchemaValidator checks each packet's payload against a JSON Schema.
+// Valid packets are forwarded unchanged; invalid ones go to RejectPort
+// with their validation errors under MetadataSchemaErrors. Payloads may be
+// raw JSON ([]byte or string) or any value that marshals to JSON.
+//
+// The supported keywords are type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, minLength, maxLength and pattern.
+// Other keywords are ignored.
+type SchemaValidator[T any] struct {
+	*nodes.BaseNode[T, T]
+	RejectPort *ports.Port[T]
+	schema     *schema
+}
+
+// NewSchemaValidator compiles schema and creates a new schema validator node
+func NewSchemaValidator[T any](schema []byte) (*SchemaValidator[T], error) {
+	var raw any
+	if err := json.Unmarshal(schema, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	compiled, err := compileSchema(raw, "#")
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaValidator[T]{
+		BaseNode:   nodes.NewBaseNode[T, T]("SchemaValidator"),
+		RejectPort: ports.NewOutput[T]("reject", "Packets failing validation", false),
+		schema:     compiled,
+	}, nil
+}
+
+// compileSchema compiles the schema at the given location
+func compileSchema(raw any, at string) (*schema, error) {
+	// true and false are the schemas accepting everything and nothing
+	if b, ok := raw.(bool); ok {
+		if b {
+			return &schema{}, nil
+		}
+		return &schema{enum: []any{}}, nil
+	}
+	obj, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w at %s: must be an object or boolean", ErrInvalidSchema, at)
+	}
+	invalid := func(keyword, reason string) error {
+		return fmt.Errorf("%w at %s: %s %s", ErrInvalidSchema, at, keyword, reason)
+	}
+
+	s := &schema{}
+	switch t := obj["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []any:
+		for _, name := range t {
+			name, ok := name.(string)
+			if !ok {
+				return nil, invalid("type", "must list type names")
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return nil, invalid("type", "must be a name or list of names")
+	}
+	for _, name := range s.types {
+		switch name {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return nil, invalid("type", fmt.Sprintf("has unknown type %q", name))
+		}
+	}
+
+	if enum, exists := obj["enum"]; exists {
+		values, ok := enum.([]any)
+		if !ok {
+			return nil, invalid("enum", "must be an array")
+		}
+		s.enum = values
+	}
+	if constant, exists := obj["const"]; exists {
+		s.constant = &constant
+	}
+
+	if props, exists := obj["properties"]; exists {
+		propMap, ok := props.(map[string]any)
+		if !ok {
+			return nil, invalid("properties", "must be an object")
+		}
+		s.properties = make(map[string]*schema, len(propMap))
+		for name, sub := range propMap {
+			compiled, err := compileSchema(sub, at+"/properties/"+name)
+			if err != nil {
+				return nil, err
+			}
+			s.properties[name] = compiled
+		}
+	}
+	if required, exists := obj["required"]; exists {
+		names, ok := required.([]any)
+		if !ok {
+			return nil, invalid("required", "must be an array")
+		}
+		for _, name := range names {
+			name, ok := name.(string)
+			if !ok {
+				return nil, invalid("required", "must list property names")
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	switch additional := obj["additionalProperties"].(type) {
+	case nil:
+	case bool:
+		s.noAdditional = !additional
+	default:
+		compiled, err := compileSchema(additional, at+"/additionalProperties")
+		if err != nil {
+			return nil, err
+		}
+		s.additionalProperties = compiled
+	}
+	if items, exists := obj["items"]; exists {
+		compiled, err := compileSchema(items, at+"/items")
+		if err != nil {
+			return nil, err
+		}
+		s.items = compiled
+	}
+
+	var err error
+	intKeyword := func(keyword string) *int {
+		value, exists := obj[keyword]
+		if !exists || err != nil {
+			return nil
+		}
+		n, ok := value.(float64)
+		if !ok || n < 0 || n != float64(int(n)) {
+			err = invalid(keyword, "must be a non-negative integer")
+			return nil
+		}
+		i := int(n)
+		return &i
+	}
+	numKeyword := func(keyword string) *float64 {
+		value, exists := obj[keyword]
+		if !exists || err != nil {
+			return nil
+		}
+		n, ok := value.(float64)
+		if !ok {
+			err = invalid(keyword, "must be a number")
+			return nil
+		}
+		return &n
+	}
+	s.minItems, s.maxItems = intKeyword("minItems"), intKeyword("maxItems")
+	s.minLength, s.maxLength = intKeyword("minLength"), intKeyword("maxLength")
+	s.minimum, s.maximum = numKeyword("minimum"), numKeyword("maximum")
+	s.exclusiveMin, s.exclusiveMax = numKeyword("exclusiveMinimum"), numKeyword("exclusiveMaximum")
+	if err != nil {
+		return nil, err
+	}
+
+	if pattern, exists := obj["pattern"]; exists {
+		expr, ok := pattern.(string)
+		if !ok {
+			return nil, invalid("pattern", "must be a string")
+		}
+		if s.pattern, err = regexp.Compile(expr); err != nil {
+			return nil, invalid("pattern", err.Error())
+		}
+	}
+	return s, nil
+}
+
+// Validate checks doc against the schema, returning one message per
+// violation, each prefixed with the JSON path of the offending value.
+// A payload that is not valid JSON fails with a single message.
+func (v *SchemaValidator[T]) Validate(doc T) []string {
+	var value any
+	var err error
+	switch raw := any(doc).(type) {
+	case []byte:
+		err = json.Unmarshal(raw, &value)
+	case string:
+		err = json.Unmarshal([]byte(raw), &value)
+	default:
+		// Round trip other values so structs and Go numbers compare as JSON
+		var data []byte
+		if data, err = json.Marshal(raw); err == nil {
+			err = json.Unmarshal(data, &value)
+		}
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("$: invalid JSON: %v", err)}
+	}
+
+	var errs []string
+	v.schema.validate(value, "$", &errs)
+	return errs
+}
+
+func (s *schema) validate(value any, path string, errs *[]string) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(s.types) > 0 && !matchesType(value, s.types) {
+		fail("expected %s, got %s", strings.Join(s.types, " or "), jsonType(value))
+		return
+	}
+	if s.enum != nil && !containsValue(s.enum, value) {
+		fail("value is not one of the allowed values")
+	}
+	if s.constant != nil && !reflect.DeepEqual(*s.constant, value) {
+		fail("value does not equal the constant")
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.required {
+			if _, exists := v[name]; !exists {
+				fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propPath := path + "." + name
+			if prop, declared := s.properties[name]; declared {
+				prop.validate(v[name], propPath, errs)
+			} else if s.noAdditional {
+				*errs = append(*errs, propPath+": additional property not allowed")
+			} else if s.additionalProperties != nil {
+				s.additionalProperties.validate(v[name], propPath, errs)
+			}
+		}
+
+	case []any:
+		if s.minItems != nil && len(v) < *s.minItems {
+			fail("expected at least %d items, got %d", *s.minItems, len(v))
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			fail("expected at most %d items, got %d", *s.maxItems, len(v))
+		}
+		if s.items != nil {
+			for i, item := range v {
+				s.items.validate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			fail("must be >= %v", *s.minimum)
+		}
+		if s.maximum != nil && v > *s.maximum {
+			fail("must be <= %v", *s.maximum)
+		}
+		if s.exclusiveMin != nil && v <= *s.exclusiveMin {
+			fail("must be > %v", *s.exclusiveMin)
+		}
+		if s.exclusiveMax != nil && v >= *s.exclusiveMax {
+			fail("must be < %v", *s.exclusiveMax)
+		}
+
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.minLength != nil && length < *s.minLength {
+			fail("expected at least %d characters, got %d", *s.minLength, length)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			fail("expected at most %d characters, got %d", *s.maxLength, length)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("does not match pattern %q", s.pattern.String())
+		}
+	}
+}
+
+// jsonType names the JSON type of a decoded value
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func matchesType(value any, types []string) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(values []any, value any) bool {
+	for _, candidate := range values {
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Process implements the processing logic
+func (v *SchemaValidator[T]) Process(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			packet, err := v.InPort.Receive(ctx)
+			if err != nil {
+				return nodes.EndOfStream(err)
+			}
+
+			if errs := v.Validate(packet.Data()); len(errs) > 0 {
+				packet.SetMetadata(MetadataSchemaErrors, errs)
+				if err := v.RejectPort.Send(ctx, packet); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := v.OutPort.Send(ctx, packet); err != nil {
+				return err
+			}
+		}
+	}
+}
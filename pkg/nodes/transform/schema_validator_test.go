@@ -0,0 +1,107 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userSchema = `{
+	"type": "object",
+	"required": ["name", "age"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0},
+		"email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+		"tags": {"type": "array", "maxItems": 2, "items": {"enum": ["admin", "staff"]}}
+	}
+}`
+
+func TestNewSchemaValidator(t *testing.T) {
+	for name, schema := range map[string]string{
+		"malformed JSON":  `{"type":`,
+		"unknown type":    `{"type": "date"}`,
+		"bad pattern":     `{"pattern": "("}`,
+		"negative length": `{"minLength": -1}`,
+		"nested":          `{"properties": {"a": {"items": 3}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewSchemaValidator[string]([]byte(schema))
+			assert.ErrorIs(t, err, ErrInvalidSchema)
+		})
+	}
+}
+
+func TestSchemaValidatorValidate(t *testing.T) {
+	validator, err := NewSchemaValidator[any]([]byte(userSchema))
+	require.NoError(t, err)
+
+	assert.Empty(t, validator.Validate(map[string]any{"name": "ada", "age": 36, "tags": []string{"admin"}}))
+	assert.Empty(t, validator.Validate(`{"name":"ada","age":36,"email":"ada@example.com"}`))
+
+	assert.Equal(t, []string{
+		`$: missing required property "name"`,
+		"$.age: expected integer, got number",
+		"$.email: does not match pattern \"^[^@]+@[^@]+$\"",
+		"$.extra: additional property not allowed",
+		"$.tags: expected at most 2 items, got 3",
+		"$.tags[1]: value is not one of the allowed values",
+	}, validator.Validate([]byte(`{"age":1.5,"email":"nobody","extra":true,"tags":["admin","root","staff"]}`)))
+
+	assert.Equal(t, []string{"$: expected object, got array"}, validator.Validate("[]"))
+	assert.Len(t, validator.Validate("{"), 1)
+}
+
+func TestSchemaValidator(t *testing.T) {
+	validator, err := NewSchemaValidator[string]([]byte(userSchema))
+	require.NoError(t, err)
+
+	inCh := make(chan *ip.IP[string], 2)
+	outCh := make(chan *ip.IP[string], 2)
+	rejectCh := make(chan *ip.IP[string], 2)
+	require.NoError(t, ports.Connect(validator.InPort, inCh))
+	require.NoError(t, ports.Connect(validator.OutPort, outCh))
+	require.NoError(t, ports.Connect(validator.RejectPort, rejectCh))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- validator.Process(ctx)
+	}()
+
+	require.NoError(t, validator.InPort.Send(ctx, ip.New(`{"name":"ada","age":36}`)))
+	require.NoError(t, validator.InPort.Send(ctx, ip.New(`{"name":"","age":-1}`)))
+
+	select {
+	case packet := <-outCh:
+		assert.Equal(t, `{"name":"ada","age":36}`, packet.Data())
+		_, exists := packet.GetMetadata(MetadataSchemaErrors)
+		assert.False(t, exists)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for output")
+	}
+
+	select {
+	case packet := <-rejectCh:
+		assert.Equal(t, `{"name":"","age":-1}`, packet.Data())
+		errs, exists := packet.GetMetadata(MetadataSchemaErrors)
+		require.True(t, exists)
+		assert.Equal(t, []string{
+			"$.age: must be >= 0",
+			"$.name: expected at least 1 characters, got 0",
+		}, errs)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for reject")
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+}
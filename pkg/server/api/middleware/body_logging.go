@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxLoggedBody is how many bytes of each body BodyLoggingMiddleware logs
+const DefaultMaxLoggedBody = 4 << 10 // 4 KiB
+
+// Redacted replaces sensitive values in logged bodies
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedFields are the JSON fields whose values are never logged
+var DefaultRedactedFields = []string{
+	"password", "token", "access_token", "refresh_token", "secret", "client_secret", "authorization",
+}
+
+// BodyLoggingOption customizes BodyLoggingMiddleware
+type BodyLoggingOption func(*bodyLoggingOptions)
+
+type bodyLoggingOptions struct {
+	maxBody        int
+	redactedFields map[string]bool
+	redactedPaths  []string
+}
+
+// WithMaxLoggedBody limits how many bytes of each body are logged
+func WithMaxLoggedBody(n int) BodyLoggingOption {
+	return func(o *bodyLoggingOptions) {
+		o.maxBody = n
+	}
+}
+
+// WithRedactedFields adds JSON fields whose values are replaced with Redacted
+func WithRedactedFields(fields ...string) BodyLoggingOption {
+	return func(o *bodyLoggingOptions) {
+		for _, field := range fields {
+			o.redactedFields[strings.ToLower(field)] = true
+		}
+	}
+}
+
+// WithRedactedPaths never logs bodies of requests whose path starts with
+// any of the given prefixes, such as a token endpoint
+func WithRedactedPaths(prefixes ...string) BodyLoggingOption {
+	return func(o *bodyLoggingOptions) {
+		o.redactedPaths = append(o.redactedPaths, prefixes...)
+	}
+}
+
+// BodyLoggingMiddleware logs request and response bodies for debugging.
+// Bodies are truncated to the size limit, values of sensitive JSON fields
+// are redacted, and bodies that cannot be parsed but mention a sensitive
+// field are redacted entirely. The handler still sees the full request body.
+func BodyLoggingMiddleware(opts ...BodyLoggingOption) func(http.Handler) http.Handler {
+	o := &bodyLoggingOptions{
+		maxBody:        DefaultMaxLoggedBody,
+		redactedFields: make(map[string]bool),
+	}
+	WithRedactedFields(DefaultRedactedFields...)(o)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reqBody []byte
+			if r.Body != nil {
+				// Read only the logged prefix and hand the handler the
+				// prefix followed by the unread rest
+				prefix, _ := io.ReadAll(io.LimitReader(r.Body, int64(o.maxBody)+1))
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(prefix), r.Body), r.Body}
+				reqBody = prefix
+			}
+
+			recorder := &bodyRecorder{ResponseWriter: w, status: http.StatusOK, max: o.maxBody + 1}
+			next.ServeHTTP(recorder, r)
+
+			log.Printf("%s %s request body: %s", r.Method, r.URL.Path, o.format(r.URL.Path, reqBody))
+			log.Printf("%s %s response %d body: %s", r.Method, r.URL.Path, recorder.status,
+				o.format(r.URL.Path, recorder.body.Bytes()))
+		})
+	}
+}
+
+// format prepares a body for logging
+func (o *bodyLoggingOptions) format(path string, body []byte) string {
+	for _, prefix := range o.redactedPaths {
+		if strings.HasPrefix(path, prefix) {
+			return Redacted
+		}
+	}
+	if len(body) == 0 {
+		return "(empty)"
+	}
+
+	truncated := len(body) > o.maxBody
+	if truncated {
+		body = body[:o.maxBody]
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		redacted, err := json.Marshal(o.redact(value))
+		if err == nil {
+			return string(redacted)
+		}
+	}
+
+	// Not parseable JSON (possibly cut off): only log it if nothing
+	// sensitive could be in it
+	lower := strings.ToLower(string(body))
+	for field := range o.redactedFields {
+		if strings.Contains(lower, field) {
+			return Redacted
+		}
+	}
+	if truncated {
+		return string(body) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// redact replaces the values of sensitive fields in decoded JSON
+func (o *bodyLoggingOptions) redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if o.redactedFields[strings.ToLower(key)] {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = o.redact(nested)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = o.redact(nested)
+		}
+	}
+	return value
+}
+
+// readCloser pairs a reader with the original body's Close
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyRecorder captures the status and the first max bytes of a response
+type bodyRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	max         int
+}
+
+func (w *bodyRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	if room := w.max - w.body.Len(); room > 0 {
+		if len(b) < room {
+			room = len(b)
+		}
+		w.body.Write(b[:room])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *bodyRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLoggingMiddleware(t *testing.T) {
+	var logBuf bytes.Buffer
+	original := log.Writer()
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(original)
+
+	// echo returns the request body so tests can check it was not consumed
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+	handler := BodyLoggingMiddleware(WithRedactedPaths("/api/v1/auth/token"))(echo)
+
+	serve := func(path, body string) *httptest.ResponseRecorder {
+		logBuf.Reset()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("logs bodies", func(t *testing.T) {
+		w := serve("/api/v1/flows", `{"id":"flow-1"}`)
+		assert.Equal(t, `{"id":"flow-1"}`, w.Body.String(), "handler must see the full body")
+		assert.Contains(t, logBuf.String(), `POST /api/v1/flows request body: {"id":"flow-1"}`)
+		assert.Contains(t, logBuf.String(), `POST /api/v1/flows response 201 body: {"id":"flow-1"}`)
+	})
+
+	t.Run("redacts sensitive fields", func(t *testing.T) {
+		serve("/api/v1/users", `{"user":"ada","password":"hunter2","nested":{"Token":"abc"}}`)
+		assert.NotContains(t, logBuf.String(), "hunter2")
+		assert.NotContains(t, logBuf.String(), "abc")
+		assert.Contains(t, logBuf.String(), `"password":"[REDACTED]"`)
+		assert.Contains(t, logBuf.String(), `"user":"ada"`)
+	})
+
+	t.Run("redacts token endpoint", func(t *testing.T) {
+		w := serve("/api/v1/auth/token", `grant_type=password&username=ada&pw=hunter2`)
+		assert.Contains(t, w.Body.String(), "hunter2")
+		assert.NotContains(t, logBuf.String(), "hunter2")
+		assert.Contains(t, logBuf.String(), "request body: [REDACTED]")
+	})
+
+	t.Run("truncates large bodies", func(t *testing.T) {
+		large := strings.Repeat("x", DefaultMaxLoggedBody*2)
+		w := serve("/api/v1/flows", large)
+		assert.Len(t, w.Body.String(), len(large))
+		assert.Contains(t, logBuf.String(), "...(truncated)")
+		assert.Less(t, logBuf.Len(), len(large)*2)
+	})
+
+	t.Run("unparseable sensitive bodies", func(t *testing.T) {
+		serve("/api/v1/login", `user=ada&password=hunter2`)
+		assert.NotContains(t, logBuf.String(), "hunter2")
+	})
+}
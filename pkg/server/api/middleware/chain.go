@@ -0,0 +1,18 @@
+package middleware
+
+import "net/http"
+
+// Middleware wraps an http.Handler
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes middleware into one; the first wraps all the others, so
+// Chain(a, b)(h) handles a request as a(b(h)). Place RecoveryMiddleware
+// inside logging and metrics so they observe the 500 for a recovered panic.
+func Chain(middleware ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			next = middleware[i](next)
+		}
+		return next
+	}
+}
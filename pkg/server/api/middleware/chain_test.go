@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name+" in")
+				next.ServeHTTP(w, r)
+				order = append(order, name+" out")
+			})
+		}
+	}
+
+	handler := Chain(trace("a"), trace("b"), trace("c"))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		order = append(order, "handler")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"a in", "b in", "c in", "handler", "c out", "b out", "a out"}, order)
+
+	t.Run("empty chain", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Chain()(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
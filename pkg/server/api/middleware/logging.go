@@ -2,30 +2,84 @@ package middleware
 
 import (
 	"log"
+	"math/rand"
 	"net/http"
 	"time"
 )
 
+// LoggingOption customizes request logging
+type LoggingOption func(*loggingOptions)
+
+type loggingOptions struct {
+	slowThreshold time.Duration
+	sampleRate    float64
+}
+
+// WithSlowRequestThreshold logs only requests taking at least threshold,
+// at warn level. Faster requests are skipped unless sampled with
+// WithFastRequestSampling.
+func WithSlowRequestThreshold(threshold time.Duration) LoggingOption {
+	return func(o *loggingOptions) {
+		o.slowThreshold = threshold
+	}
+}
+
+// WithFastRequestSampling still logs the given fraction (0 to 1) of requests
+// under the slow request threshold
+func WithFastRequestSampling(rate float64) LoggingOption {
+	return func(o *loggingOptions) {
+		o.sampleRate = rate
+	}
+}
+
 // LoggingMiddleware logs request details
 func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+	return NewLoggingMiddleware()(next)
+}
+
+// NewLoggingMiddleware creates a request logging middleware with options.
+// Without options every request is logged.
+func NewLoggingMiddleware(opts ...LoggingOption) func(http.Handler) http.Handler {
+	options := &loggingOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
 
-		// Wrap response writer to capture status code
-		wrapped := wrapResponseWriter(w)
+			// Wrap response writer to capture status code
+			wrapped := wrapResponseWriter(w)
 
-		// Process request
-		next.ServeHTTP(wrapped, r)
+			// Process request
+			next.ServeHTTP(wrapped, r)
 
-		// Log request details
-		log.Printf(
-			"%s %s %d %s",
-			r.Method,
-			r.RequestURI,
-			wrapped.status,
-			time.Since(start),
-		)
-	})
+			duration := time.Since(start)
+			if options.slowThreshold > 0 && duration >= options.slowThreshold {
+				log.Printf(
+					"WARN slow request: %s %s %d %s",
+					r.Method,
+					r.RequestURI,
+					wrapped.status,
+					duration,
+				)
+				return
+			}
+			if options.slowThreshold > 0 && rand.Float64() >= options.sampleRate {
+				return
+			}
+
+			// Log request details
+			log.Printf(
+				"%s %s %d %s",
+				r.Method,
+				r.RequestURI,
+				wrapped.status,
+				duration,
+			)
+		})
+	}
 }
 
 type responseWriter struct {
@@ -53,3 +107,8 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 	}
 	return w.ResponseWriter.Write(b)
 }
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -43,6 +44,45 @@ func TestLoggingMiddleware(t *testing.T) {
 		"Log should contain request method, path and status code")
 }
 
+func TestSlowRequestLogging(t *testing.T) {
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(handler http.Handler, path string) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	t.Run("slow only", func(t *testing.T) {
+		logBuf.Reset()
+		handler := NewLoggingMiddleware(WithSlowRequestThreshold(20 * time.Millisecond))(testHandler)
+
+		serve(handler, "/fast")
+		assert.Empty(t, logBuf.String(), "fast request should not be logged")
+
+		serve(handler, "/slow")
+		assert.Contains(t, logBuf.String(), "WARN slow request: GET /slow 200")
+	})
+
+	t.Run("sampled fast requests", func(t *testing.T) {
+		logBuf.Reset()
+		handler := NewLoggingMiddleware(
+			WithSlowRequestThreshold(20*time.Millisecond),
+			WithFastRequestSampling(1),
+		)(testHandler)
+
+		serve(handler, "/fast")
+		assert.Contains(t, logBuf.String(), "GET /fast 200")
+		assert.NotContains(t, logBuf.String(), "WARN")
+	})
+}
+
 func TestResponseWriterWrapper(t *testing.T) {
 	t.Run("explicit_status_code", func(t *testing.T) {
 		rw := wrapResponseWriter(httptest.NewRecorder())
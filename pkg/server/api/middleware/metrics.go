@@ -3,9 +3,16 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/gorilla/mux"
 )
 
+// UnmatchedPathLabel is recorded for requests that match no registered route
+// when path normalization is enabled
+const UnmatchedPathLabel = "unmatched"
+
 // Metrics interface defines methods for recording metrics
 type Metrics interface {
 	RecordRequest(method, path string)
@@ -17,6 +24,68 @@ type Metrics interface {
 	RecordFlowStop(flowID string)
 }
 
+// LabelRecorder is implemented by metrics backends that accept per-request labels
+type LabelRecorder interface {
+	AddLabels(labels map[string]string)
+}
+
+// MetricsOption customizes MetricsMiddleware
+type MetricsOption func(*metricsOptions)
+
+type metricsOptions struct {
+	excludedPrefixes []string
+	router           *mux.Router
+}
+
+// WithExcludedPaths skips recording for requests whose path starts with any
+// of the given prefixes, e.g. "/health" or "/metrics"
+func WithExcludedPaths(prefixes ...string) MetricsOption {
+	return func(o *metricsOptions) {
+		o.excludedPrefixes = append(o.excludedPrefixes, prefixes...)
+	}
+}
+
+// WithRouteTemplates records the matched route template (e.g.
+// "/api/v1/flows/{id}") instead of the raw request path, keeping the
+// number of distinct path labels bounded by the number of routes.
+// Requests matching no route are recorded as UnmatchedPathLabel.
+func WithRouteTemplates(router *mux.Router) MetricsOption {
+	return func(o *metricsOptions) {
+		o.router = router
+	}
+}
+
+// pathLabel returns the path to record for a request
+func (o *metricsOptions) pathLabel(r *http.Request) string {
+	// Middleware installed with router.Use sees the already matched route
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+
+	if o.router == nil {
+		return r.URL.Path
+	}
+
+	var match mux.RouteMatch
+	if o.router.Match(r, &match) && match.Route != nil {
+		if tpl, err := match.Route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return UnmatchedPathLabel
+}
+
+func (o *metricsOptions) excluded(path string) bool {
+	for _, prefix := range o.excludedPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // metricsResponseWriter wraps http.ResponseWriter to capture the status code
 type metricsResponseWriter struct {
 	http.ResponseWriter
@@ -29,12 +98,23 @@ func (w *metricsResponseWriter) WriteHeader(status int) {
 }
 
 // MetricsMiddleware creates middleware for recording request metrics
-func MetricsMiddleware(m Metrics) func(http.Handler) http.Handler {
+func MetricsMiddleware(m Metrics, opts ...MetricsOption) func(http.Handler) http.Handler {
+	options := &metricsOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if options.excluded(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			start := time.Now()
+			path := options.pathLabel(r)
 
-			m.RecordRequest(r.Method, r.URL.Path)
+			m.RecordRequest(r.Method, path)
 
 			// Create response wrapper to capture status code
 			rw := &metricsResponseWriter{ResponseWriter: w, status: http.StatusOK}
@@ -46,13 +126,18 @@ func MetricsMiddleware(m Metrics) func(http.Handler) http.Handler {
 			m.RecordResponseStatus(rw.status)
 
 			// Record labels after response is complete
-			if m, ok := m.(*mockMetrics); ok {
-				m.AddLabels(map[string]string{
+			if lr, ok := m.(LabelRecorder); ok {
+				lr.AddLabels(map[string]string{
 					"method": r.Method,
-					"path":   r.URL.Path,
+					"path":   path,
 					"status": fmt.Sprintf("%d", rw.status),
 				})
 			}
 		})
 	}
 }
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *metricsResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -228,3 +229,84 @@ func TestMetricsLabels(t *testing.T) {
 			"Label %s should have value %s", key, expectedValue)
 	}
 }
+
+func TestMetricsExcludedPaths(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("excluded", func(t *testing.T) {
+		metrics := newMockMetrics()
+		handler := MetricsMiddleware(metrics, WithExcludedPaths("/health", "/metrics"))(okHandler)
+
+		for _, path := range []string{"/health", "/metrics", "/api/v1/flows"} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+			assert.Equal(t, http.StatusOK, w.Code, "excluded paths must still be served")
+		}
+
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+
+		assert.Zero(t, metrics.requests["GET /health"], "Should not record /health")
+		assert.Zero(t, metrics.requests["GET /metrics"], "Should not record /metrics")
+		assert.Equal(t, 1, metrics.requests["GET /api/v1/flows"], "Should record other paths")
+		assert.Len(t, metrics.requestDurations, 1)
+	})
+
+	t.Run("default records everything", func(t *testing.T) {
+		metrics := newMockMetrics()
+		handler := MetricsMiddleware(metrics)(okHandler)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+
+		assert.Equal(t, 1, metrics.requests["GET /health"], "Should record /health by default")
+	})
+}
+
+func TestMetricsRouteTemplates(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/flows/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("wrapped router", func(t *testing.T) {
+		metrics := newMockMetrics()
+		handler := MetricsMiddleware(metrics, WithRouteTemplates(router))(router)
+
+		for _, path := range []string{"/api/v1/flows/abc", "/api/v1/flows/def", "/unknown/path"} {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		}
+
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+
+		assert.Equal(t, 2, metrics.requests["GET /api/v1/flows/{id}"],
+			"Different flow ids should collapse to one label")
+		assert.Equal(t, 1, metrics.requests["GET "+UnmatchedPathLabel])
+		assert.Zero(t, metrics.requests["GET /api/v1/flows/abc"])
+		require.Len(t, metrics.labels, 3)
+		assert.Equal(t, "/api/v1/flows/{id}", metrics.labels[0]["path"])
+	})
+
+	t.Run("router middleware", func(t *testing.T) {
+		metrics := newMockMetrics()
+		r := mux.NewRouter()
+		r.Use(MetricsMiddleware(metrics))
+		r.HandleFunc("/api/v1/flows/{id}", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/flows/abc", nil))
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/flows/def", nil))
+
+		metrics.mu.Lock()
+		defer metrics.mu.Unlock()
+
+		assert.Equal(t, 2, metrics.requests["GET /api/v1/flows/{id}"])
+	})
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// DefaultTenantHeader is the request header TenantMiddleware reads by default
+const DefaultTenantHeader = "X-Tenant-ID"
+
+type tenantKey struct{}
+
+// WithTenant returns a context scoped to tenant. Authentication middleware
+// should call it with the tenant claim of the authenticated caller.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant a request is scoped to, or "" when
+// it is not scoped
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// TenantMiddleware scopes each request to the tenant named by the given
+// header (DefaultTenantHeader when empty). The header is trusted as-is, so
+// only use it behind a proxy that authenticates callers and sets it.
+func TenantMiddleware(header string) func(http.Handler) http.Handler {
+	if header == "" {
+		header = DefaultTenantHeader
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tenant := r.Header.Get(header); tenant != "" {
+				r = r.WithContext(WithTenant(r.Context(), tenant))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
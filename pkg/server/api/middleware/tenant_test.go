@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	var got string
+	handler := TenantMiddleware("")(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = TenantFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(DefaultTenantHeader, "acme")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "acme", got)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "", got)
+
+	assert.Equal(t, "globex", TenantFromContext(WithTenant(context.Background(), "globex")))
+}
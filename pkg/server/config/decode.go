@@ -0,0 +1,224 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Tag is the struct tag naming the config key of a field, optionally
+// followed by ",required": `config:"bufferSize,required"`. Untagged fields
+// use the field name, matched case-insensitively. A tag of "-" skips the field.
+const Tag = "config"
+
+var (
+	// ErrMissingField is returned when a required config key is absent
+	ErrMissingField = errors.New("missing required config field")
+	// ErrTypeMismatch is returned when a config value cannot be converted to its field type
+	ErrTypeMismatch = errors.New("config type mismatch")
+	// ErrInvalidTarget is returned when Decode is not given a pointer to a struct
+	ErrInvalidTarget = errors.New("config target must be a non-nil pointer to a struct")
+)
+
+// Decode decodes a node config map, as parsed from JSON, into the struct
+// pointed to by dst. Numbers convert to any numeric field when they fit,
+// durations accept strings such as "1.5s", and nested maps and slices decode
+// into nested structs, maps and slices. Fields without a config value keep
+// their current value, so dst can be pre-filled with defaults.
+func Decode(m map[string]interface{}, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrInvalidTarget
+	}
+	return decodeStruct("", m, v.Elem())
+}
+
+func decodeStruct(path string, m map[string]interface{}, dst reflect.Value) error {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, required := field.Name, false
+		if tag, ok := field.Tag.Lookup(Tag); ok {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				required = required || opt == "required"
+			}
+		}
+
+		fieldPath := joinPath(path, name)
+		value, ok := lookup(m, name)
+		if !ok || value == nil {
+			if required {
+				return fmt.Errorf("%w: %s", ErrMissingField, fieldPath)
+			}
+			continue
+		}
+		if err := decodeValue(fieldPath, value, dst.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookup finds key in m, falling back to a case-insensitive match
+func lookup(m map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := m[key]; ok {
+		return value, true
+	}
+	for k, value := range m {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func decodeValue(path string, value interface{}, dst reflect.Value) error {
+	mismatch := func() error {
+		return fmt.Errorf("%w: %s: cannot use %T as %s", ErrTypeMismatch, path, value, dst.Type())
+	}
+
+	// Values that already have the right type are assigned as-is
+	src := reflect.ValueOf(value)
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+
+	if dst.Type() == reflect.TypeOf(time.Duration(0)) {
+		switch v := value.(type) {
+		case string:
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrTypeMismatch, path, err)
+			}
+			dst.SetInt(int64(d))
+			return nil
+		case float64:
+			if v != math.Trunc(v) {
+				return mismatch()
+			}
+			dst.SetInt(int64(v))
+			return nil
+		}
+		return mismatch()
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(dst.Type().Elem())
+		if err := decodeValue(path, value, elem.Elem()); err != nil {
+			return err
+		}
+		dst.Set(elem)
+
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return mismatch()
+		}
+		dst.SetString(s)
+
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return mismatch()
+		}
+		dst.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, ok := toFloat(value)
+		if !ok || f != math.Trunc(f) || dst.OverflowInt(int64(f)) {
+			return mismatch()
+		}
+		dst.SetInt(int64(f))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, ok := toFloat(value)
+		if !ok || f < 0 || f != math.Trunc(f) || dst.OverflowUint(uint64(f)) {
+			return mismatch()
+		}
+		dst.SetUint(uint64(f))
+
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat(value)
+		if !ok || dst.OverflowFloat(f) {
+			return mismatch()
+		}
+		dst.SetFloat(f)
+
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return mismatch()
+		}
+		slice := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(fmt.Sprintf("%s[%d]", path, i), item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+
+	case reflect.Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		result := reflect.MakeMapWithSize(dst.Type(), len(entries))
+		for k, entry := range entries {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := decodeValue(joinPath(path, k), entry, elem); err != nil {
+				return err
+			}
+			result.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+		}
+		dst.Set(result)
+
+	case reflect.Struct:
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		return decodeStruct(path, nested, dst)
+
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+// toFloat converts any Go number to float64
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type readerConfig struct {
+	Path       string            `config:"path,required"`
+	BufferSize int               `config:"bufferSize"`
+	Timeout    time.Duration     `config:"timeout"`
+	Ratio      float64           `config:"ratio"`
+	Follow     bool              // untagged, matched as "follow"
+	Tags       []string          `config:"tags"`
+	Labels     map[string]string `config:"labels"`
+	Retry      *retryConfig      `config:"retry"`
+	Ignored    string            `config:"-"`
+}
+
+type retryConfig struct {
+	Attempts uint8 `config:"attempts,required"`
+}
+
+// parse decodes a JSON object the way flow configs arrive
+func parse(t *testing.T, data string) map[string]interface{} {
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(data), &m))
+	return m
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("all fields", func(t *testing.T) {
+		m := parse(t, `{
+			"path": "/tmp/in.txt",
+			"bufferSize": 4096,
+			"timeout": "1.5s",
+			"ratio": 0.25,
+			"follow": true,
+			"tags": ["a", "b"],
+			"labels": {"env": "test"},
+			"retry": {"attempts": 3},
+			"Ignored": "nope"
+		}`)
+
+		var cfg readerConfig
+		require.NoError(t, Decode(m, &cfg))
+		assert.Equal(t, readerConfig{
+			Path:       "/tmp/in.txt",
+			BufferSize: 4096,
+			Timeout:    1500 * time.Millisecond,
+			Ratio:      0.25,
+			Follow:     true,
+			Tags:       []string{"a", "b"},
+			Labels:     map[string]string{"env": "test"},
+			Retry:      &retryConfig{Attempts: 3},
+		}, cfg)
+	})
+
+	t.Run("optional fields keep defaults", func(t *testing.T) {
+		cfg := readerConfig{BufferSize: 1024, Timeout: time.Second}
+		require.NoError(t, Decode(parse(t, `{"path": "/tmp/in.txt"}`), &cfg))
+		assert.Equal(t, "/tmp/in.txt", cfg.Path)
+		assert.Equal(t, 1024, cfg.BufferSize)
+		assert.Equal(t, time.Second, cfg.Timeout)
+		assert.Nil(t, cfg.Retry)
+	})
+
+	t.Run("missing required field", func(t *testing.T) {
+		var cfg readerConfig
+		err := Decode(parse(t, `{"bufferSize": 10}`), &cfg)
+		assert.ErrorIs(t, err, ErrMissingField)
+		assert.Contains(t, err.Error(), "path")
+
+		err = Decode(parse(t, `{"path": "x", "retry": {}}`), &cfg)
+		assert.ErrorIs(t, err, ErrMissingField)
+		assert.Contains(t, err.Error(), "retry.attempts")
+	})
+
+	t.Run("type mismatches", func(t *testing.T) {
+		for _, data := range []string{
+			`{"path": 42}`,
+			`{"path": "x", "bufferSize": "big"}`,
+			`{"path": "x", "bufferSize": 1.5}`,
+			`{"path": "x", "timeout": "soon"}`,
+			`{"path": "x", "tags": ["a", 1]}`,
+			`{"path": "x", "retry": {"attempts": 300}}`,
+			`{"path": "x", "retry": {"attempts": -1}}`,
+		} {
+			var cfg readerConfig
+			assert.ErrorIs(t, Decode(parse(t, data), &cfg), ErrTypeMismatch, data)
+		}
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		var cfg readerConfig
+		assert.ErrorIs(t, Decode(nil, cfg), ErrInvalidTarget)
+		assert.ErrorIs(t, Decode(nil, (*readerConfig)(nil)), ErrInvalidTarget)
+		var n int
+		assert.ErrorIs(t, Decode(nil, &n), ErrInvalidTarget)
+	})
+}
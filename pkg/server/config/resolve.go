@@ -0,0 +1,46 @@
+package config
+
+// Resolve merges configuration maps with increasing precedence: values in
+// later overrides replace those in earlier ones. Nested maps are merged
+// key by key rather than replaced wholesale. Inputs are not modified; the
+// result shares no maps with them.
+//
+// A typical call is Resolve(defaults, flowConfig, nodeConfig).
+func Resolve(base map[string]interface{}, overrides ...map[string]interface{}) map[string]interface{} {
+	result := deepCopy(base)
+	for _, override := range overrides {
+		mergeInto(result, override)
+	}
+	return result
+}
+
+// mergeInto deep-merges src into dst
+func mergeInto(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+
+		if srcIsMap && dstIsMap {
+			mergeInto(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			dst[key] = deepCopy(srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// deepCopy copies a map and every nested map within it
+func deepCopy(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if nested, ok := value.(map[string]interface{}); ok {
+			result[key] = deepCopy(nested)
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	defaults := map[string]interface{}{
+		"bufferSize": 10,
+		"timeout":    "1s",
+		"retry": map[string]interface{}{
+			"attempts": 3,
+			"delay":    "100ms",
+		},
+	}
+	flowConfig := map[string]interface{}{
+		"timeout": "5s",
+		"retry": map[string]interface{}{
+			"attempts": 5,
+		},
+	}
+	nodeConfig := map[string]interface{}{
+		"bufferSize": 100,
+		"retry": map[string]interface{}{
+			"delay": "1s",
+		},
+	}
+
+	resolved := Resolve(defaults, flowConfig, nodeConfig)
+
+	assert.Equal(t, map[string]interface{}{
+		"bufferSize": 100,  // node overrides default
+		"timeout":    "5s", // flow overrides default
+		"retry": map[string]interface{}{
+			"attempts": 5,    // flow overrides nested default
+			"delay":    "1s", // node overrides nested default
+		},
+	}, resolved)
+
+	t.Run("inputs unchanged", func(t *testing.T) {
+		assert.Equal(t, 10, defaults["bufferSize"])
+		assert.Equal(t, 3, defaults["retry"].(map[string]interface{})["attempts"])
+
+		resolved["retry"].(map[string]interface{})["attempts"] = 99
+		assert.Equal(t, 5, flowConfig["retry"].(map[string]interface{})["attempts"])
+	})
+
+	t.Run("non-map replaces map", func(t *testing.T) {
+		resolved := Resolve(defaults, map[string]interface{}{"retry": false})
+		assert.Equal(t, false, resolved["retry"])
+	})
+
+	t.Run("nil inputs", func(t *testing.T) {
+		assert.Equal(t, map[string]interface{}{}, Resolve(nil))
+		assert.Equal(t, map[string]interface{}{"a": 1},
+			Resolve(nil, nil, map[string]interface{}{"a": 1}))
+	})
+}
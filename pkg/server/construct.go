@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/server/config"
+)
+
+// StatusClientClosedRequest reports a request abandoned by its client
+const StatusClientClosedRequest = 499
+
+// DefaultReleaseTimeout bounds stopping the processes of a flow that is
+// rolled back or deleted
+const DefaultReleaseTimeout = 5 * time.Second
+
+// ContextProcessFactory is implemented by factories whose processes are
+// slow to create, for example because they open external connections.
+// Flow construction passes the request context so a client disconnecting
+// aborts it.
+type ContextProcessFactory interface {
+	ProcessFactory
+	CreateContext(ctx context.Context, config map[string]interface{}) (Process, error)
+}
+
+// Defaulter is implemented by process factories whose processes have a
+// default configuration. Flow construction resolves each node's config
+// over these defaults and the flow's "defaults".
+type Defaulter interface {
+	Defaults() map[string]interface{}
+}
+
+// LoggerSetter is implemented by processes that accept a logger, such as
+// those embedding nodes.BaseNode. Flow construction gives each one the
+// flow's logger, so its lines are served at /api/v1/flows/{id}/logs.
+type LoggerSetter interface {
+	SetLogger(logger *log.Logger)
+}
+
+// instantiateFlow creates the process of every node of a flow config in
+// node id order, handing logger, when set, to each LoggerSetter. Each
+// process is created with its node's config resolved over the flow's
+// "defaults", which in turn override the defaults of the process type.
+// When ctx is canceled or a node fails, the processes already created are
+// stopped before the error is returned, so nothing of a partial flow
+// outlives the call.
+func (s *Server) instantiateFlow(ctx context.Context, flowConfig map[string]interface{}, logger *log.Logger) (map[string]Process, error) {
+	nodes, _ := flowConfig["nodes"].(map[string]interface{})
+	flowDefaults, _ := flowConfig["defaults"].(map[string]interface{})
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	processes := make(map[string]Process, len(ids))
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Join(err, releaseProcesses(ctx, processes))
+		}
+
+		nodeConfig, _ := nodes[id].(map[string]interface{})
+		nodeType, _ := nodeConfig["type"].(string)
+		processConfig, _ := nodeConfig["config"].(map[string]interface{})
+		processConfig = config.Resolve(s.processDefaults(nodeType), flowDefaults, processConfig)
+
+		process, err := s.createProcessContext(ctx, nodeType, processConfig)
+		if err != nil {
+			if ctx.Err() == nil {
+				err = fmt.Errorf("node %q: %w", id, err)
+			}
+			return nil, errors.Join(err, releaseProcesses(ctx, processes))
+		}
+		if setter, ok := process.(LoggerSetter); ok && logger != nil {
+			setter.SetLogger(logger)
+		}
+		processes[id] = process
+	}
+	return processes, nil
+}
+
+// createProcessContext creates a process of a registered type, passing ctx
+// to factories that accept it
+func (s *Server) createProcessContext(ctx context.Context, processType string, config map[string]interface{}) (Process, error) {
+	s.processes.mu.RLock()
+	factory, exists := s.processes.processes[processType]
+	s.processes.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProcessType, processType)
+	}
+	if factory, ok := factory.(ContextProcessFactory); ok {
+		return factory.CreateContext(ctx, config)
+	}
+	return factory.Create(config)
+}
+
+// processDefaults returns the default config of a registered process
+// type, or nil when its factory has none
+func (s *Server) processDefaults(processType string) map[string]interface{} {
+	s.processes.mu.RLock()
+	factory := s.processes.processes[processType]
+	s.processes.mu.RUnlock()
+	if d, ok := factory.(Defaulter); ok {
+		return d.Defaults()
+	}
+	return nil
+}
+
+// releaseProcesses stops every process, joining their errors. It runs
+// even when ctx is canceled, since that is when partial flows are rolled
+// back.
+func releaseProcesses(ctx context.Context, processes map[string]Process) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultReleaseTimeout)
+	defer cancel()
+
+	var errs []error
+	for id, process := range processes {
+		if err := process.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("node %q: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// respondConstructionError reports a flow that could not be instantiated
+func respondConstructionError(w http.ResponseWriter, r *http.Request, err error) {
+	if r.Context().Err() != nil {
+		respondError(w, StatusClientClosedRequest, err)
+		return
+	}
+	respondError(w, http.StatusBadRequest, err)
+}
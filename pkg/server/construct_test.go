@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trackedProcessFactory records every process it creates. Creating a
+// process with "block" set waits until the context is canceled.
+type trackedProcessFactory struct {
+	mu       sync.Mutex
+	created  []*trackedProcess
+	blocking chan struct{}
+}
+
+func (f *trackedProcessFactory) Create(config map[string]interface{}) (Process, error) {
+	return f.CreateContext(context.Background(), config)
+}
+
+func (f *trackedProcessFactory) CreateContext(ctx context.Context, config map[string]interface{}) (Process, error) {
+	if block, _ := config["block"].(bool); block {
+		close(f.blocking)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	process := &trackedProcess{}
+	f.mu.Lock()
+	f.created = append(f.created, process)
+	f.mu.Unlock()
+	return process, nil
+}
+
+// live counts created processes that were not stopped
+func (f *trackedProcessFactory) live() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, process := range f.created {
+		if !process.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+type trackedProcess struct {
+	stopped bool
+}
+
+func (p *trackedProcess) Start(_ context.Context) error { return nil }
+func (p *trackedProcess) Stop(_ context.Context) error {
+	p.stopped = true
+	return nil
+}
+
+func TestCancelFlowConstruction(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	factory := &trackedProcessFactory{blocking: make(chan struct{})}
+	require.NoError(t, srv.RegisterProcessType("tracked", factory))
+
+	t.Run("canceled mid-construction", func(t *testing.T) {
+		// Nodes are built in id order, so a and b exist when c blocks
+		body := `{"id":"partial","config":{"nodes":{
+			"a":{"type":"tracked"},"b":{"type":"tracked"},
+			"c":{"type":"tracked","config":{"block":true}},"d":{"type":"tracked"}}}}`
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body)).WithContext(ctx)
+
+		w := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			srv.ServeHTTP(w, req)
+		}()
+
+		select {
+		case <-factory.blocking:
+		case <-time.After(time.Second):
+			t.Fatal("construction never reached the blocking node")
+		}
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("construction was not aborted")
+		}
+
+		assert.Equal(t, StatusClientClosedRequest, w.Code)
+		srv.flows.mu.RLock()
+		assert.Empty(t, srv.flows.flows)
+		srv.flows.mu.RUnlock()
+		assert.Len(t, factory.created, 2)
+		assert.Zero(t, factory.live(), "partially created nodes should be stopped")
+	})
+
+	t.Run("delete releases nodes", func(t *testing.T) {
+		body := `{"id":"whole","config":{"nodes":{"a":{"type":"tracked"},"b":{"type":"tracked"}}}}`
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body)))
+		require.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 2, factory.live())
+
+		w = httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/flows/whole", nil))
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Zero(t, factory.live())
+	})
+}
+
+// defaultedProcessFactory has default config and records the config each
+// process is created with
+type defaultedProcessFactory struct {
+	configs map[string]map[string]interface{}
+}
+
+func (f *defaultedProcessFactory) Defaults() map[string]interface{} {
+	return map[string]interface{}{
+		"timeout": "1s",
+		"buffer":  float64(10),
+		"retry":   map[string]interface{}{"attempts": float64(3), "delay": "100ms"},
+	}
+}
+
+func (f *defaultedProcessFactory) Create(config map[string]interface{}) (Process, error) {
+	f.configs[config["name"].(string)] = config
+	return &mockProcess{}, nil
+}
+
+func TestResolveNodeConfig(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	factory := &defaultedProcessFactory{configs: make(map[string]map[string]interface{})}
+	require.NoError(t, srv.RegisterProcessType("defaulted", factory))
+
+	do := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body)))
+		return w
+	}
+
+	w := do(`{"id":"layered","config":{
+		"defaults":{"timeout":"5s","retry":{"attempts":5}},
+		"nodes":{
+			"plain":{"type":"defaulted","config":{"name":"plain"}},
+			"tuned":{"type":"defaulted","config":{"name":"tuned","buffer":100,"retry":{"delay":"1s"}}}
+		}}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	assert.Equal(t, map[string]interface{}{
+		"name":    "plain",
+		"timeout": "5s",        // flow defaults override type defaults
+		"buffer":  float64(10), // type default
+		"retry":   map[string]interface{}{"attempts": float64(5), "delay": "100ms"},
+	}, factory.configs["plain"])
+	assert.Equal(t, map[string]interface{}{
+		"name":    "tuned",
+		"timeout": "5s",
+		"buffer":  float64(100), // node config overrides both
+		"retry":   map[string]interface{}{"attempts": float64(5), "delay": "1s"},
+	}, factory.configs["tuned"])
+
+	t.Run("defaults must be an object", func(t *testing.T) {
+		w := do(`{"id":"bad","config":{"defaults":"fast","nodes":{"a":{"type":"defaulted","config":{"name":"a"}}}}}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid defaults")
+	})
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+)
+
+// DefaultRecentErrors is how many errors of its attached network each flow
+// keeps for /debug/flows
+const DefaultRecentErrors = 20
+
+// RuntimeStats are process-wide gauges for capacity planning. Channels and
+// BufferedPackets total the networks attached to flows.
+type RuntimeStats struct {
+	Goroutines      int `json:"goroutines"`
+	Flows           int `json:"flows"`
+	ActiveFlows     int `json:"active_flows"`
+	Channels        int `json:"channels"`
+	BufferedPackets int `json:"buffered_packets"`
+}
+
+// DebugDump is the payload of the /debug/flows endpoint
+type DebugDump struct {
+	Runtime RuntimeStats     `json:"runtime"`
+	Flows   []FlowDebugState `json:"flows"`
+}
+
+// FlowDebugState is a snapshot of a flow's runtime state
+type FlowDebugState struct {
+	ID        string           `json:"id"`
+	State     FlowState        `json:"state"`
+	StartTime *time.Time       `json:"started_at,omitempty"`
+	RequestID string           `json:"request_id,omitempty"`
+	Nodes     []NodeDebugState `json:"nodes"`
+	Edges     []EdgeDebugState `json:"edges"`
+	// Ports are the runtime stats of the attached network's ports, empty
+	// when the flow has no network attached
+	Ports  []PortStatsDebugState `json:"ports"`
+	Errors []string              `json:"errors"`
+}
+
+// NodeDebugState is a snapshot of one node in a flow
+type NodeDebugState struct {
+	ID      string            `json:"id"`
+	Type    string            `json:"type"`
+	State   FlowState         `json:"state"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Inputs  []PortDebugState  `json:"inputs"`
+	Outputs []PortDebugState  `json:"outputs"`
+}
+
+// PortDebugState describes one node port
+type PortDebugState struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+// PortStatsDebugState is the runtime state of one port of a flow's
+// attached network. A channel's queued packets count on both its ports.
+type PortStatsDebugState struct {
+	Process     string `json:"process"`
+	Port        string `json:"port"`
+	Direction   string `json:"direction"`
+	Connections int    `json:"connections"`
+	Queued      int    `json:"queued"`
+	Dropped     uint64 `json:"dropped"`
+}
+
+// EdgeDebugState is one connection between nodes
+type EdgeDebugState struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Port string `json:"port,omitempty"`
+	When string `json:"when,omitempty"`
+}
+
+// RuntimeStats returns the current runtime gauges. Flows that are starting
+// or running count as active. Each channel is counted once, at the input
+// port it feeds, along with the packets queued in it.
+func (s *Server) RuntimeStats() RuntimeStats {
+	s.flows.mu.RLock()
+	defer s.flows.mu.RUnlock()
+
+	stats := RuntimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		Flows:      len(s.flows.flows),
+	}
+	for _, flow := range s.flows.flows {
+		if flow.State == FlowStateStarting || flow.State == FlowStateRunning {
+			stats.ActiveFlows++
+		}
+		if flow.network == nil {
+			continue
+		}
+		for _, port := range flow.network.PortStats() {
+			if port.Type == ports.TypeInput {
+				stats.Channels += port.Connections
+				stats.BufferedPackets += port.Queued
+			}
+		}
+	}
+	return stats
+}
+
+// debugFlows snapshots every flow, ordered by id
+func (s *Server) debugFlows() []FlowDebugState {
+	s.flows.mu.RLock()
+	defer s.flows.mu.RUnlock()
+
+	states := make([]FlowDebugState, 0, len(s.flows.flows))
+	for _, flow := range s.flows.flows {
+		states = append(states, s.debugFlow(flow))
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].ID < states[j].ID
+	})
+	return states
+}
+
+// debugFlow snapshots a single flow; the caller must hold the flows lock
+func (s *Server) debugFlow(flow *ManagedFlow) FlowDebugState {
+	state := FlowDebugState{
+		ID:        flow.ID,
+		State:     flow.State,
+		StartTime: flow.StartTime,
+		RequestID: flow.RequestID,
+		Nodes:     []NodeDebugState{},
+		Edges:     []EdgeDebugState{},
+		Ports:     []PortStatsDebugState{},
+		Errors:    []string{},
+	}
+	if flow.Error != "" {
+		state.Errors = append(state.Errors, flow.Error)
+	}
+	if flow.recentErrors != nil {
+		state.Errors = append(state.Errors, flow.recentErrors.tail(0)...)
+	}
+	if flow.network != nil {
+		for _, stats := range flow.network.PortStats() {
+			direction := "output"
+			if stats.Type == ports.TypeInput {
+				direction = "input"
+			}
+			state.Ports = append(state.Ports, PortStatsDebugState{
+				Process:     stats.Process,
+				Port:        stats.Port,
+				Direction:   direction,
+				Connections: stats.Connections,
+				Queued:      stats.Queued,
+				Dropped:     stats.Dropped,
+			})
+		}
+	}
+
+	nodes, _ := flow.Config["nodes"].(map[string]interface{})
+	for id, node := range nodes {
+		nodeConfig, _ := node.(map[string]interface{})
+		nodeType, _ := nodeConfig["type"].(string)
+
+		nodeState := NodeDebugState{
+			ID:      id,
+			Type:    nodeType,
+			State:   flow.State,
+			Labels:  flow.NodeLabels[id],
+			Inputs:  []PortDebugState{},
+			Outputs: []PortDebugState{},
+		}
+		if desc, exists := s.describeProcessType(nodeType); exists {
+			nodeState.Inputs = debugPorts(desc.Inputs)
+			nodeState.Outputs = debugPorts(desc.Outputs)
+		}
+		state.Nodes = append(state.Nodes, nodeState)
+	}
+	sort.Slice(state.Nodes, func(i, j int) bool {
+		return state.Nodes[i].ID < state.Nodes[j].ID
+	})
+
+	edges, _ := flow.Config["edges"].([]interface{})
+	for _, edge := range edges {
+		edgeConfig, ok := edge.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		from, _ := edgeConfig["from"].(string)
+		to, _ := edgeConfig["to"].(string)
+		port, _ := edgeConfig["port"].(string)
+		when, _ := edgeConfig["when"].(string)
+		state.Edges = append(state.Edges, EdgeDebugState{From: from, To: to, Port: port, When: when})
+	}
+
+	return state
+}
+
+func debugPorts(descs []PortDescriptor) []PortDebugState {
+	ports := make([]PortDebugState, 0, len(descs))
+	for _, desc := range descs {
+		ports = append(ports, PortDebugState{
+			Name:     desc.Name,
+			Type:     desc.Type,
+			Required: desc.Required,
+		})
+	}
+	return ports
+}
+
+func (s *Server) handleDebugFlows(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !s.config.EnableDebug {
+		respondError(w, http.StatusNotFound, fmt.Errorf("debug endpoints are disabled"))
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(map[string]interface{}{
+		"data": DebugDump{
+			Runtime: s.RuntimeStats(),
+			Flows:   s.debugFlows(),
+		},
+	}); err != nil {
+		log.Printf("Error encoding debug response: %v", err)
+	}
+}
+
+// operatorOnly requires the configured operator bearer token. Without an
+// OperatorToken there is no operator, so the handler is not served at all.
+func (s *Server) operatorOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.config.OperatorToken == "" {
+			w.Header().Set("Content-Type", "application/json")
+			respondError(w, http.StatusNotFound, fmt.Errorf("debug endpoints require an operator token"))
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.config.OperatorToken)) != 1 {
+			w.Header().Set("Content-Type", "application/json")
+			respondError(w, http.StatusForbidden, fmt.Errorf("operator access required"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleProfiling(w http.ResponseWriter, r *http.Request) {
+	if !s.config.EnableProfiling {
+		w.Header().Set("Content-Type", "application/json")
+		respondError(w, http.StatusNotFound, fmt.Errorf("profiling is disabled"))
+		return
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, "/debug/pprof/") {
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Index(w, r)
+	}
+}
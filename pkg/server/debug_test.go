@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingNode fails as soon as it is started
+type failingNode struct {
+	*nodes.BaseNode[string, string]
+}
+
+func (n *failingNode) Process(_ context.Context) error {
+	return errors.New("disk full")
+}
+
+// attachDebugNetwork attaches a network to the flow whose reader has two
+// packets queued on its output and whose loader fails, and runs it until
+// the test ends
+func attachDebugNetwork(t *testing.T, srv *Server, flowID string) {
+	reader := nodes.NewBaseNode[string, string]("reader", nodes.WithOptionalInput())
+	queue := make(chan *ip.IP[string], 4)
+	require.NoError(t, ports.Connect(reader.OutPort, queue))
+	loader := &failingNode{nodes.NewBaseNode[string, string]("loader",
+		nodes.WithOptionalInput(), nodes.WithOptionalOutput())}
+
+	n := network.New()
+	n.AddProcess(reader)
+	n.AddProcess(loader)
+	require.NoError(t, srv.AttachNetwork(flowID, n))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	for _, word := range []string{"a", "b"} {
+		require.NoError(t, reader.OutPort.Send(ctx, ip.New(word)))
+	}
+	go func() {
+		_ = n.Start(ctx)
+	}()
+}
+
+func TestDebugFlows(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	srv.config.OperatorToken = "operator-secret"
+	require.NoError(t, srv.RegisterProcessType("FileReader", &describedProcessFactory{}))
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer operator-secret")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		w := do(http.MethodGet, "/debug/flows", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	srv.config.EnableDebug = true
+
+	w := do(http.MethodPost, "/api/v1/flows", `{"id":"debug-flow","config":{
+		"nodes":{"reader":{"type":"FileReader"},"sink":{"type":"test"}},
+		"edges":[{"from":"reader","to":"sink","port":"out"}]}}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = do(http.MethodPost, "/api/v1/flows/debug-flow/start", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Eventually(t, func() bool {
+		srv.flows.mu.RLock()
+		defer srv.flows.mu.RUnlock()
+		return srv.flows.flows["debug-flow"].State == FlowStateRunning
+	}, time.Second, 10*time.Millisecond)
+
+	t.Run("no network attached", func(t *testing.T) {
+		w := do(http.MethodGet, "/debug/flows", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data DebugDump `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		require.Len(t, resp.Data.Flows, 1)
+		assert.Empty(t, resp.Data.Flows[0].Ports)
+	})
+
+	attachDebugNetwork(t, srv, "debug-flow")
+
+	var resp struct {
+		Data DebugDump `json:"data"`
+	}
+	require.Eventually(t, func() bool {
+		w = do(http.MethodGet, "/debug/flows", "")
+		resp.Data = DebugDump{}
+		return w.Code == http.StatusOK &&
+			json.Unmarshal(w.Body.Bytes(), &resp) == nil &&
+			len(resp.Data.Flows) == 1 && len(resp.Data.Flows[0].Errors) > 0
+	}, time.Second, 10*time.Millisecond, "the loader's error should reach the dump")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "\n  ", "dump should be indented")
+	assert.Equal(t, 1, resp.Data.Runtime.ActiveFlows)
+
+	flow := resp.Data.Flows[0]
+	assert.Equal(t, "debug-flow", flow.ID)
+	assert.Equal(t, FlowStateRunning, flow.State)
+	assert.NotNil(t, flow.StartTime)
+	assert.Equal(t, []EdgeDebugState{{From: "reader", To: "sink", Port: "out"}}, flow.Edges)
+	assert.Equal(t, []string{"loader: disk full"}, flow.Errors)
+	assert.Equal(t, []PortStatsDebugState{
+		{Process: "reader", Port: "in", Direction: "input"},
+		{Process: "reader", Port: "out", Direction: "output", Connections: 1, Queued: 2},
+		{Process: "loader", Port: "in", Direction: "input"},
+		{Process: "loader", Port: "out", Direction: "output"},
+	}, flow.Ports)
+
+	require.Len(t, flow.Nodes, 2)
+	reader := flow.Nodes[0]
+	assert.Equal(t, "reader", reader.ID)
+	assert.Equal(t, "FileReader", reader.Type)
+	assert.Equal(t, FlowStateRunning, reader.State)
+	assert.Equal(t, []PortDebugState{{Name: "filename", Type: "string", Required: true}}, reader.Inputs)
+	assert.Equal(t, []PortDebugState{{Name: "out", Type: "[]uint8", Required: true}}, reader.Outputs)
+
+	sink := flow.Nodes[1]
+	assert.Equal(t, "sink", sink.ID)
+	assert.Empty(t, sink.Inputs)
+}
+
+func TestRuntimeStats(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	do := func(method, path, body string) int {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w.Code
+	}
+
+	for _, id := range []string{"flow-a", "flow-b", "flow-c"} {
+		require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/v1/flows",
+			`{"id":"`+id+`","config":{"nodes":{"test":{"type":"test"}}}}`))
+	}
+	assert.Equal(t, 0, srv.RuntimeStats().ActiveFlows)
+
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/v1/flows/flow-a/start", ""))
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/v1/flows/flow-b/start", ""))
+
+	stats := srv.RuntimeStats()
+	assert.Equal(t, 3, stats.Flows)
+	assert.Equal(t, 2, stats.ActiveFlows)
+	assert.Positive(t, stats.Goroutines)
+
+	require.Eventually(t, func() bool {
+		return srv.RuntimeStats().ActiveFlows == 2 &&
+			do(http.MethodPost, "/api/v1/flows/flow-a/stop", "") == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return srv.RuntimeStats().ActiveFlows == 1
+	}, time.Second, 10*time.Millisecond)
+
+	t.Run("channels of attached networks", func(t *testing.T) {
+		assert.Zero(t, srv.RuntimeStats().Channels)
+
+		// flow-b: a -> b over a channel holding two packets, and an
+		// initial packet waiting at b
+		a := nodes.NewBaseNode[string, string]("a")
+		b := nodes.NewBaseNode[string, string]("b")
+		ch := make(chan *ip.IP[string], 4)
+		require.NoError(t, ports.Connect(a.OutPort, ch))
+		require.NoError(t, ports.Connect(b.InPort, ch))
+		b.InPort.AddInitial("first")
+		nb := network.New()
+		nb.AddProcess(a)
+		nb.AddProcess(b)
+		require.NoError(t, srv.AttachNetwork("flow-b", nb))
+
+		// flow-c: a fan-in of two empty channels into c
+		c := nodes.NewBaseNode[string, string]("c")
+		for i := 0; i < 2; i++ {
+			require.NoError(t, ports.Connect(c.InPort, make(chan *ip.IP[string], 1)))
+		}
+		nc := network.New()
+		nc.AddProcess(c)
+		require.NoError(t, srv.AttachNetwork("flow-c", nc))
+
+		ctx := context.Background()
+		require.NoError(t, a.OutPort.Send(ctx, ip.New("x")))
+		require.NoError(t, a.OutPort.Send(ctx, ip.New("y")))
+
+		stats := srv.RuntimeStats()
+		assert.Equal(t, 3, stats.Channels)
+		assert.Equal(t, 3, stats.BufferedPackets)
+	})
+}
+
+func TestProfiling(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/debug/pprof/", "").Code)
+	})
+
+	srv.config.EnableProfiling = true
+
+	t.Run("no operator token configured", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/debug/pprof/", "").Code)
+		assert.Equal(t, http.StatusNotFound, get("/debug/pprof/goroutine?debug=1", "").Code)
+
+		srv.config.EnableDebug = true
+		defer func() { srv.config.EnableDebug = false }()
+		assert.Equal(t, http.StatusNotFound, get("/debug/flows", "").Code)
+	})
+
+	srv.config.OperatorToken = "operator-secret"
+
+	t.Run("operator token", func(t *testing.T) {
+		w := get("/debug/pprof/", "operator-secret")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "goroutine")
+
+		w = get("/debug/pprof/goroutine?debug=1", "operator-secret")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("missing or wrong token", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, get("/debug/pprof/", "").Code)
+		assert.Equal(t, http.StatusForbidden, get("/debug/pprof/", "guess").Code)
+	})
+
+	t.Run("debug flows share the guard", func(t *testing.T) {
+		srv.config.EnableDebug = true
+		assert.Equal(t, http.StatusForbidden, get("/debug/flows", "").Code)
+		assert.Equal(t, http.StatusOK, get("/debug/flows", "operator-secret").Code)
+	})
+}
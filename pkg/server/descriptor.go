@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/gorilla/mux"
+)
+
+// PortDescriptor describes one port of a process type
+type PortDescriptor struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+}
+
+// ConfigDescriptor describes one configuration key of a process type
+type ConfigDescriptor struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+}
+
+// ProcessDescriptor describes the ports and configuration of a process type
+type ProcessDescriptor struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Inputs      []PortDescriptor   `json:"inputs"`
+	Outputs     []PortDescriptor   `json:"outputs"`
+	Config      []ConfigDescriptor `json:"config"`
+}
+
+// Describer is implemented by process factories that can describe the
+// processes they create
+type Describer interface {
+	Describe() ProcessDescriptor
+}
+
+// DescribePort builds a PortDescriptor from a port, using its element type
+func DescribePort[T any](p *ports.Port[T]) PortDescriptor {
+	return PortDescriptor{
+		Name:        p.Name(),
+		Type:        reflect.TypeOf((*T)(nil)).Elem().String(),
+		Description: p.Description(),
+		Required:    p.Required(),
+	}
+}
+
+// describeProcessType returns the descriptor of a registered process type
+func (s *Server) describeProcessType(name string) (ProcessDescriptor, bool) {
+	s.processes.mu.RLock()
+	factory, exists := s.processes.processes[name]
+	s.processes.mu.RUnlock()
+
+	if !exists {
+		return ProcessDescriptor{}, false
+	}
+
+	desc := ProcessDescriptor{}
+	if d, ok := factory.(Describer); ok {
+		desc = d.Describe()
+	}
+	if desc.Name == "" {
+		desc.Name = name
+	}
+	if desc.Inputs == nil {
+		desc.Inputs = []PortDescriptor{}
+	}
+	if desc.Outputs == nil {
+		desc.Outputs = []PortDescriptor{}
+	}
+	if desc.Config == nil {
+		desc.Config = []ConfigDescriptor{}
+	}
+	return desc, true
+}
+
+func (s *Server) handleGetProcessType(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	desc, exists := s.describeProcessType(name)
+	if !exists {
+		respondError(w, http.StatusNotFound, fmt.Errorf("process type %s not found", name))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, desc)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// describedProcessFactory is a process factory that describes its processes
+type describedProcessFactory struct {
+	mockProcessFactory
+}
+
+func (f *describedProcessFactory) Describe() ProcessDescriptor {
+	return ProcessDescriptor{
+		Description: "Reads lines from a file",
+		Inputs: []PortDescriptor{
+			DescribePort(ports.NewInput[string]("filename", "File to read", true)),
+		},
+		Outputs: []PortDescriptor{
+			DescribePort(ports.NewOutput[[]byte]("out", "File contents", true)),
+		},
+		Config: []ConfigDescriptor{
+			{Name: "bufferSize", Type: "int", Required: false},
+			{Name: "path", Type: "string", Required: true},
+		},
+	}
+}
+
+func TestProcessTypeIntrospection(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("FileReader", &describedProcessFactory{}))
+	require.NoError(t, srv.RegisterProcessType("plain", &mockProcessFactory{}))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("described type", func(t *testing.T) {
+		w := get("/api/v1/process-types/FileReader")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data ProcessDescriptor `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+		desc := resp.Data
+		assert.Equal(t, "FileReader", desc.Name)
+		assert.Equal(t, "Reads lines from a file", desc.Description)
+		require.Len(t, desc.Inputs, 1)
+		assert.Equal(t, PortDescriptor{
+			Name: "filename", Type: "string", Description: "File to read", Required: true,
+		}, desc.Inputs[0])
+		require.Len(t, desc.Outputs, 1)
+		assert.Equal(t, "[]uint8", desc.Outputs[0].Type)
+		require.Len(t, desc.Config, 2)
+		assert.Equal(t, "path", desc.Config[1].Name)
+		assert.True(t, desc.Config[1].Required)
+	})
+
+	t.Run("undescribed type", func(t *testing.T) {
+		w := get("/api/v1/process-types/plain")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t,
+			`{"data":{"name":"plain","inputs":[],"outputs":[],"config":[]}}`,
+			w.Body.String())
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		w := get("/api/v1/process-types/missing")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+)
+
+// FlowDiff is the difference between two flow configs
+type FlowDiff struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Nodes NodesDiff `json:"nodes"`
+	Edges EdgesDiff `json:"edges"`
+}
+
+// NodesDiff lists node ids, sorted, by how they differ
+type NodesDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+// EdgesDiff lists edges, in canonical order, present in only one config
+type EdgesDiff struct {
+	Added   []map[string]interface{} `json:"added"`
+	Removed []map[string]interface{} `json:"removed"`
+}
+
+// diffFlowConfigs compares two flow configs. Values are compared by their
+// canonical JSON encoding, in which map keys are sorted, so key order never
+// shows up as a change.
+func diffFlowConfigs(from, to map[string]interface{}) (NodesDiff, EdgesDiff) {
+	nodes := NodesDiff{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	fromNodes, _ := from["nodes"].(map[string]interface{})
+	toNodes, _ := to["nodes"].(map[string]interface{})
+	for id, node := range toNodes {
+		old, exists := fromNodes[id]
+		switch {
+		case !exists:
+			nodes.Added = append(nodes.Added, id)
+		case canonicalJSON(old) != canonicalJSON(node):
+			nodes.Changed = append(nodes.Changed, id)
+		}
+	}
+	for id := range fromNodes {
+		if _, exists := toNodes[id]; !exists {
+			nodes.Removed = append(nodes.Removed, id)
+		}
+	}
+	sort.Strings(nodes.Added)
+	sort.Strings(nodes.Removed)
+	sort.Strings(nodes.Changed)
+
+	fromEdges, _ := from["edges"].([]interface{})
+	toEdges, _ := to["edges"].([]interface{})
+	edges := EdgesDiff{
+		Added:   edgesMissing(toEdges, fromEdges),
+		Removed: edgesMissing(fromEdges, toEdges),
+	}
+	return nodes, edges
+}
+
+// edgesMissing returns the edges of a that are not in b, counting
+// duplicates, in canonical order
+func edgesMissing(a, b []interface{}) []map[string]interface{} {
+	remaining := make(map[string]int, len(b))
+	for _, edge := range b {
+		remaining[canonicalJSON(edge)]++
+	}
+
+	var keys []string
+	byKey := make(map[string]map[string]interface{})
+	for _, edge := range a {
+		key := canonicalJSON(edge)
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		edgeConfig, _ := edge.(map[string]interface{})
+		keys = append(keys, key)
+		byKey[key] = edgeConfig
+	}
+	sort.Strings(keys)
+
+	missing := make([]map[string]interface{}, 0, len(keys))
+	for _, key := range keys {
+		missing = append(missing, byKey[key])
+	}
+	return missing
+}
+
+// canonicalJSON encodes a decoded JSON value with sorted map keys
+func canonicalJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%#v", value)
+	}
+	return string(data)
+}
+
+func (s *Server) handleDiffFlows(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	s.flows.mu.RLock()
+	from, fromExists := s.flows.flows[requestFlowKey(r, vars["id"])]
+	to, toExists := s.flows.flows[requestFlowKey(r, vars["other"])]
+	var diff FlowDiff
+	if fromExists && toExists {
+		diff = FlowDiff{From: from.ID, To: to.ID}
+		diff.Nodes, diff.Edges = diffFlowConfigs(from.Config, to.Config)
+	}
+	s.flows.mu.RUnlock()
+
+	if !fromExists {
+		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", vars["id"]))
+		return
+	}
+	if !toExists {
+		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", vars["other"]))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, diff)
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffFlows(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	// b adds node c and edge b->c; key order differs but means the same
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/v1/flows", `{"id":"a","config":{
+		"nodes":{"a":{"type":"test","config":{"x":1,"y":2}},"b":{"type":"test"}},
+		"edges":[{"from":"a","to":"b"}]}}`).Code)
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/v1/flows", `{"id":"b","config":{
+		"edges":[{"to":"b","from":"a"},{"from":"b","to":"c"}],
+		"nodes":{"b":{"type":"test"},"a":{"config":{"y":2,"x":1},"type":"test"},"c":{"type":"test"}}}}`).Code)
+
+	w := do(http.MethodGet, "/api/v1/flows/a/diff/b", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response struct {
+		Data FlowDiff `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	diff := response.Data
+	assert.Equal(t, "a", diff.From)
+	assert.Equal(t, "b", diff.To)
+	assert.Equal(t, []string{"c"}, diff.Nodes.Added)
+	assert.Empty(t, diff.Nodes.Removed)
+	assert.Empty(t, diff.Nodes.Changed)
+	assert.Equal(t, []map[string]interface{}{{"from": "b", "to": "c"}}, diff.Edges.Added)
+	assert.Empty(t, diff.Edges.Removed)
+
+	// The reverse diff mirrors it
+	w = do(http.MethodGet, "/api/v1/flows/b/diff/a", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []string{"c"}, response.Data.Nodes.Removed)
+	assert.Equal(t, []map[string]interface{}{{"from": "b", "to": "c"}}, response.Data.Edges.Removed)
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/api/v1/flows/a/diff/missing", "").Code)
+}
+
+func TestDiffFlowConfigsChangedNode(t *testing.T) {
+	from := map[string]interface{}{
+		"nodes": map[string]interface{}{"n": map[string]interface{}{"type": "test", "config": map[string]interface{}{"size": 1.0}}},
+	}
+	to := map[string]interface{}{
+		"nodes": map[string]interface{}{"n": map[string]interface{}{"type": "test", "config": map[string]interface{}{"size": 2.0}}},
+	}
+
+	nodes, edges := diffFlowConfigs(from, to)
+	assert.Equal(t, []string{"n"}, nodes.Changed)
+	assert.Empty(t, nodes.Added)
+	assert.Empty(t, nodes.Removed)
+	assert.Empty(t, edges.Added)
+	assert.Empty(t, edges.Removed)
+}
@@ -1,7 +1,10 @@
 package docs
 
 import (
-	"fmt"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -13,12 +16,19 @@ import (
 
 type Config struct {
 	DocsPath string
+
+	// AllowedTags and AllowedAttrs extend the safe subset of HTML kept when
+	// rendered markdown is sanitized. Scripts, event handlers and
+	// javascript: URLs are always removed.
+	AllowedTags  []string
+	AllowedAttrs []string
 }
 
 type Server struct {
 	router     *mux.Router
 	docsPath   string
 	mermaidGen *MermaidGenerator
+	sanitize   string
 }
 
 func NewServer(config Config) *Server {
@@ -26,7 +36,28 @@ func NewServer(config Config) *Server {
 		router:     mux.NewRouter(),
 		docsPath:   config.DocsPath,
 		mermaidGen: NewMermaidGenerator(),
+		sanitize:   sanitizeConfig(config),
+	}
+}
+
+// sanitizeConfig returns the DOMPurify options for config as a JavaScript
+// object literal
+func sanitizeConfig(config Config) string {
+	options := map[string][]string{
+		"ADD_TAGS": config.AllowedTags,
+		"ADD_ATTR": config.AllowedAttrs,
+	}
+	for key, values := range options {
+		if values == nil {
+			options[key] = []string{}
+		}
+	}
+	// json.Marshal escapes <, > and &, so the result is safe in a script
+	literal, err := json.Marshal(options)
+	if err != nil {
+		return "{}"
 	}
+	return string(literal)
 }
 
 func (s *Server) Router() *mux.Router {
@@ -76,16 +107,19 @@ func (s *Server) SetupRoutes() {
 
 		// For Markdown files, wrap them in HTML
 		if strings.HasSuffix(r.URL.Path, ".md") {
-			content, err := os.ReadFile(fullPath)
+			file, err := os.Open(fullPath)
 			if err != nil {
 				s.logDebug("Error reading file: %v", err)
 				http.Error(w, "Documentation not found", http.StatusNotFound)
 				return
 			}
+			defer file.Close()
 
 			s.logDebug("Serving markdown file with HTML wrapper")
 			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			s.renderDocPage(w, string(content))
+			if err := s.streamDocPage(w, file); err != nil {
+				s.logDebug("Error streaming markdown: %v", err)
+			}
 			return
 		}
 
@@ -95,9 +129,11 @@ func (s *Server) SetupRoutes() {
 	}))
 }
 
-// renderDocPage wraps markdown content in a styled HTML page
-func (s *Server) renderDocPage(w http.ResponseWriter, content string) {
-	html := `<!DOCTYPE html>
+// docPageHeader and docPageFooter wrap streamed markdown in a styled HTML
+// page; the markdown sits in a JavaScript template literal between them,
+// followed by the sanitizer options
+const (
+	docPageHeader = `<!DOCTYPE html>
 <html>
 <head>
     <meta charset="UTF-8">
@@ -105,6 +141,7 @@ func (s *Server) renderDocPage(w http.ResponseWriter, content string) {
     <title>noPromises Documentation</title>
     <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/github-markdown-css@5/github-markdown.min.css">
     <script src="https://cdn.jsdelivr.net/npm/marked/marked.min.js"></script>
+    <script src="https://cdn.jsdelivr.net/npm/dompurify@3/dist/purify.min.js"></script>
     <style>
         body {
             box-sizing: border-box;
@@ -152,8 +189,10 @@ func (s *Server) renderDocPage(w http.ResponseWriter, content string) {
         <div id="content"></div>
     </div>
     <script>
-        // Render markdown content
-        document.getElementById('content').innerHTML = marked.parse(` + "`" + content + "`" + `);
+        // Render markdown content, keeping only safe HTML; mermaid code
+        // blocks survive as plain pre/code elements
+        document.getElementById('content').innerHTML = DOMPurify.sanitize(marked.parse(` + "`"
+	docPageFooter = `);
 
         // Add syntax highlighting to code blocks
         document.querySelectorAll('pre code').forEach(block => {
@@ -162,8 +201,90 @@ func (s *Server) renderDocPage(w http.ResponseWriter, content string) {
     </script>
 </body>
 </html>`
+)
+
+// DocChunkSize is roughly how much escaped markdown streamDocPage buffers
+// before writing it out. Chunks end at blank lines, between top-level
+// blocks, unless a single block grows past four times this size.
+const DocChunkSize = 32 << 10 // 32 KiB
+
+// streamDocPage writes the markdown read from r wrapped in a styled HTML
+// page. The markdown is streamed in chunks and flushed as it goes, so
+// memory stays bounded however large the document is. The browser
+// renders it and sanitizes the resulting HTML before inserting it, since
+// docs may be user-supplied.
+func (s *Server) streamDocPage(w io.Writer, r io.Reader) error {
+	flush := func() {}
+	if f, ok := w.(http.Flusher); ok {
+		flush = f.Flush
+	}
+
+	if _, err := io.WriteString(w, docPageHeader); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(r, DocChunkSize)
+	var chunk bytes.Buffer
+	writeChunk := func() error {
+		if chunk.Len() == 0 {
+			return nil
+		}
+		_, err := w.Write(chunk.Bytes())
+		chunk.Reset()
+		flush()
+		return err
+	}
+
+	lineStart := true
+	for {
+		line, err := reader.ReadSlice('\n')
+		blank := lineStart && len(bytes.TrimSpace(line)) == 0 && len(line) > 0
+		escapeTemplateLiteral(&chunk, line)
+		lineStart = err != bufio.ErrBufferFull
+
+		if (blank && chunk.Len() >= DocChunkSize) || chunk.Len() >= 4*DocChunkSize {
+			if werr := writeChunk(); werr != nil {
+				return werr
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return err
+		}
+	}
+	if err := writeChunk(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, "`), "+s.sanitize); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, docPageFooter)
+	return err
+}
 
-	fmt.Fprint(w, html)
+// escapeTemplateLiteral appends b escaped for a JavaScript template
+// literal inside a script element. Each byte is escaped on its own, so
+// input may be split anywhere.
+func escapeTemplateLiteral(buf *bytes.Buffer, b []byte) {
+	for _, c := range b {
+		switch c {
+		case '\\':
+			buf.WriteString(`\\`)
+		case '`':
+			buf.WriteString("\\`")
+		case '$':
+			buf.WriteString(`\$`)
+		case '<':
+			// Keeps "</script>" in the document from ending the script
+			buf.WriteString(`\x3C`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
 }
 
 // Add these debug logging functions
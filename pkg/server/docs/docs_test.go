@@ -1,11 +1,13 @@
 package docs
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -232,3 +234,106 @@ func TestServerServeHTTP(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), testContent)
 }
+
+// maxWriteRecorder records the output and the largest single write
+type maxWriteRecorder struct {
+	bytes.Buffer
+	maxWrite int
+	writes   int
+}
+
+func (w *maxWriteRecorder) Write(p []byte) (int, error) {
+	w.writes++
+	if len(p) > w.maxWrite {
+		w.maxWrite = len(p)
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestStreamDocPage(t *testing.T) {
+	srv := NewServer(Config{DocsPath: t.TempDir()})
+
+	t.Run("escapes for the script", func(t *testing.T) {
+		var out maxWriteRecorder
+		require.NoError(t, srv.streamDocPage(&out, strings.NewReader("```go\nx := `a\\b` + ${y}\n```\n</script>\n")))
+
+		body := out.String()
+		assert.True(t, strings.HasPrefix(body, "<!DOCTYPE html>"))
+		assert.True(t, strings.HasSuffix(body, "</html>"))
+		assert.Contains(t, body, "\\`\\`\\`go\nx := \\`a\\\\b\\` + \\${y}\n\\`\\`\\`\n\\x3C/script>\n")
+		// Only the two imports and the page script itself close a script
+		assert.Equal(t, 3, strings.Count(body, "</script>"))
+	})
+
+	t.Run("large document", func(t *testing.T) {
+		block := "## Section\n\nSome text for a paragraph that is long enough to matter.\n\n"
+		const blocks = 100000 // several megabytes
+		doc := strings.Repeat(block, blocks)
+
+		var out maxWriteRecorder
+		out.Grow(len(doc) + 4096)
+
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		require.NoError(t, srv.streamDocPage(&out, strings.NewReader(doc)))
+		runtime.ReadMemStats(&after)
+
+		body := out.String()
+		assert.Equal(t, blocks, strings.Count(body, "## Section"))
+		assert.True(t, strings.HasSuffix(body, "</html>"))
+
+		// The body is written in bounded chunks as it is read, not
+		// buffered whole
+		assert.Greater(t, out.writes, len(doc)/(4*DocChunkSize))
+		assert.LessOrEqual(t, out.maxWrite, 4*DocChunkSize+len(block))
+		assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(len(doc)/4),
+			"streaming should not allocate in proportion to the document")
+	})
+}
+
+func TestLargeMarkdownFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	doc := strings.Repeat("# Heading\n\nParagraph with `code`.\n\n", 50000)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "large.md"), []byte(doc), 0644))
+
+	srv := NewServer(Config{DocsPath: tmpDir})
+	srv.SetupRoutes()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/large.md", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.True(t, w.Flushed, "chunks should be flushed to the client")
+	assert.Equal(t, 50000, strings.Count(w.Body.String(), "Paragraph with \\`code\\`."))
+}
+
+func TestSanitizedMarkdown(t *testing.T) {
+	tmpDir := t.TempDir()
+	doc := "# Notes\n\n<script>alert('xss')</script>\n<img src=x onerror=alert(1)>\n\n```mermaid\ngraph LR\n  a --> b\n```\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "unsafe.md"), []byte(doc), 0644))
+
+	srv := NewServer(Config{DocsPath: tmpDir})
+	srv.SetupRoutes()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unsafe.md", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	// The raw script never reaches the page as markup, and the rendered
+	// HTML is passed through the sanitizer before it is inserted
+	assert.NotContains(t, body, "<script>alert")
+	assert.Contains(t, body, "\\x3Cscript>alert")
+	assert.Contains(t, body, "purify.min.js")
+	assert.Contains(t, body, "DOMPurify.sanitize(marked.parse(`")
+	assert.Contains(t, body, "\\`\\`\\`mermaid\ngraph LR")
+	assert.Contains(t, body, "`), {\"ADD_ATTR\":[],\"ADD_TAGS\":[]});")
+}
+
+func TestSanitizeConfig(t *testing.T) {
+	assert.Equal(t, `{"ADD_ATTR":[],"ADD_TAGS":[]}`, sanitizeConfig(Config{}))
+	assert.Equal(t, `{"ADD_ATTR":["target"],"ADD_TAGS":["\u003c/script\u003e"]}`,
+		sanitizeConfig(Config{AllowedTags: []string{"</script>"}, AllowedAttrs: []string{"target"}}))
+}
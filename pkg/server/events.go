@@ -0,0 +1,175 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/server/hub"
+	"github.com/gorilla/mux"
+)
+
+// DefaultEventBufferSize is how many recent events each flow keeps for
+// clients resuming with Last-Event-ID
+const DefaultEventBufferSize = 100
+
+// FlowEvent records a change in a flow's lifecycle
+type FlowEvent struct {
+	ID     uint64    `json:"id"`
+	FlowID string    `json:"flow_id"`
+	Type   string    `json:"type"`
+	State  FlowState `json:"state"`
+	Time   time.Time `json:"time"`
+}
+
+// eventLog is a bounded ring buffer of a flow's recent events plus the
+// hub broadcasting them live
+type eventLog struct {
+	flowID string
+	buf    []FlowEvent
+	start  int // index of the oldest event in buf
+	count  int
+	lastID uint64
+	live   *hub.Hub[FlowEvent]
+	mu     sync.Mutex
+}
+
+func newEventLog(flowID string, size int) *eventLog {
+	if size <= 0 {
+		size = DefaultEventBufferSize
+	}
+	return &eventLog{
+		flowID: flowID,
+		buf:    make([]FlowEvent, size),
+		live:   hub.New[FlowEvent](hub.WithBufferSize(size)),
+	}
+}
+
+// publish records an event for the flow's current state and notifies
+// subscribers. Slow subscribers miss live events rather than block the
+// publisher; they can resume from the buffer.
+func (l *eventLog) publish(eventType string, state FlowState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lastID++
+	event := FlowEvent{
+		ID:     l.lastID,
+		FlowID: l.flowID,
+		Type:   eventType,
+		State:  state,
+		Time:   time.Now(),
+	}
+
+	end := (l.start + l.count) % len(l.buf)
+	l.buf[end] = event
+	if l.count < len(l.buf) {
+		l.count++
+	} else {
+		l.start = (l.start + 1) % len(l.buf)
+	}
+
+	l.live.Publish(event)
+}
+
+// close ends every live subscription. Later subscribers still receive the
+// buffered events, then a closed channel.
+func (l *eventLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.live.Close()
+}
+
+// subscribe returns the buffered events after lastID and a channel of
+// live events that follow them. Call unsubscribe when done.
+func (l *eventLog) subscribe(lastID uint64) (backlog []FlowEvent, live <-chan FlowEvent, unsubscribe func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := 0; i < l.count; i++ {
+		event := l.buf[(l.start+i)%len(l.buf)]
+		if event.ID > lastID {
+			backlog = append(backlog, event)
+		}
+	}
+
+	live, unsubscribe = l.live.Subscribe()
+	return backlog, live, unsubscribe
+}
+
+// handleFlowEvents streams a flow's events as server-sent events. A
+// reconnecting client sending Last-Event-ID receives only newer events.
+func (s *Server) handleFlowEvents(w http.ResponseWriter, r *http.Request) {
+	flowID := mux.Vars(r)["id"]
+
+	s.flows.mu.RLock()
+	flow, exists := s.flows.flows[requestFlowKey(r, flowID)]
+	s.flows.mu.RUnlock()
+
+	if !exists {
+		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", flowID))
+		return
+	}
+
+	var lastID uint64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid Last-Event-ID %q", header))
+			return
+		}
+		lastID = id
+	}
+
+	backlog, live, unsubscribe := flow.events.subscribe(lastID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's WriteTimeout by design
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	send := func(event FlowEvent) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	for _, event := range backlog {
+		if err := send(event); err != nil {
+			return
+		}
+		lastID = event.ID
+	}
+	if len(backlog) == 0 {
+		_ = rc.Flush()
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-live:
+			if !ok {
+				return
+			}
+			if event.ID <= lastID {
+				continue // already sent from the backlog
+			}
+			if err := send(event); err != nil {
+				return
+			}
+			lastID = event.ID
+		}
+	}
+}
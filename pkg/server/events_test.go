@@ -0,0 +1,177 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvents reads n server-sent event ids from a flow's event stream
+func readEvents(t *testing.T, url, lastEventID string, n int) []uint64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var ids []uint64
+	scanner := bufio.NewScanner(resp.Body)
+	for len(ids) < n && scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			id, err := strconv.ParseUint(value, 10, 64)
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+	}
+	require.Len(t, ids, n, "stream ended early")
+	return ids
+}
+
+func TestFlowEventsResume(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	post := func(path, body string) int {
+		resp, err := http.Post(ts.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusCreated,
+		post("/api/v1/flows", `{"id":"events-flow","config":{"nodes":{"test":{"type":"test"}}}}`))
+	require.Equal(t, http.StatusOK, post("/api/v1/flows/events-flow/start", ""))
+
+	eventsURL := ts.URL + "/api/v1/flows/events-flow/events"
+
+	// Read created and starting, then disconnect
+	assert.Equal(t, []uint64{1, 2}, readEvents(t, eventsURL, "", 2))
+
+	// Wait for running, then stop the flow while disconnected
+	require.Eventually(t, func() bool {
+		return post("/api/v1/flows/events-flow/stop", "") == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+
+	// Resuming after event 2 delivers running, stopping and stopped once each
+	assert.Equal(t, []uint64{3, 4, 5}, readEvents(t, eventsURL, "2", 3))
+
+	t.Run("invalid last event id", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, eventsURL, nil)
+		req.Header.Set("Last-Event-ID", "abc")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("unknown flow", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/api/v1/flows/missing/events")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestFlowEventsEndOnDelete(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/api/v1/flows", "application/json",
+		strings.NewReader(`{"id":"deleted-flow","config":{"nodes":{"test":{"type":"test"}}}}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/v1/flows/deleted-flow/events", nil)
+	require.NoError(t, err)
+	stream, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	require.Equal(t, http.StatusOK, stream.StatusCode)
+
+	var events []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(stream.Body)
+		for scanner.Scan() {
+			if value, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+				events = append(events, value)
+			}
+		}
+	}()
+
+	require.Eventually(t, func() bool {
+		srv.flows.mu.RLock()
+		defer srv.flows.mu.RUnlock()
+		return srv.flows.flows["deleted-flow"].events.live.Len() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	del, err := http.NewRequest(http.MethodDelete, ts.URL+"/api/v1/flows/deleted-flow", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(del)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("event stream did not end after delete")
+	}
+	assert.Equal(t, []string{"created", "deleted"}, events)
+}
+
+func TestEventLogRingBuffer(t *testing.T) {
+	events := newEventLog("flow", 3)
+	for i := 0; i < 5; i++ {
+		events.publish("tick", FlowStateRunning)
+	}
+
+	backlog, _, unsubscribe := events.subscribe(0)
+	defer unsubscribe()
+
+	ids := make([]uint64, 0, len(backlog))
+	for _, event := range backlog {
+		ids = append(ids, event.ID)
+	}
+	assert.Equal(t, []uint64{3, 4, 5}, ids, "only the newest events are kept")
+
+	backlog, live, unsubscribeLive := events.subscribe(4)
+	defer unsubscribeLive()
+	require.Len(t, backlog, 1)
+	assert.Equal(t, uint64(5), backlog[0].ID)
+
+	events.publish("tick", FlowStateRunning)
+	select {
+	case event := <-live:
+		assert.Equal(t, uint64(6), event.ID)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for live event")
+	}
+}
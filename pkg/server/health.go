@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultHealthCheckTimeout bounds the pre-start health checks of a flow
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+// ErrUnhealthy is returned when a flow's external dependencies are down
+var ErrUnhealthy = errors.New("flow dependencies are unhealthy")
+
+// HealthChecker is implemented by processes that depend on external
+// services. Starting a flow runs the health check of each of its nodes
+// first and refuses to start while any fails.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// checkFlowHealth runs the health check of each of a flow's node
+// instances, keyed by node id, reporting every failing node
+func checkFlowHealth(ctx context.Context, processes map[string]Process) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+	defer cancel()
+
+	ids := make([]string, 0, len(processes))
+	for id := range processes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var failures []string
+	for _, id := range ids {
+		checker, ok := processes[id].(HealthChecker)
+		if !ok {
+			continue
+		}
+		if err := checker.HealthCheck(ctx); err != nil {
+			failures = append(failures, fmt.Sprintf("node %q: %v", id, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnhealthy, strings.Join(failures, "; "))
+	}
+	return nil
+}
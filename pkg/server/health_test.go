@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unhealthyProcess depends on a service that is down
+type unhealthyProcess struct {
+	mockProcess
+}
+
+func (p *unhealthyProcess) HealthCheck(_ context.Context) error {
+	return errors.New("database unreachable")
+}
+
+type unhealthyProcessFactory struct{}
+
+func (f *unhealthyProcessFactory) Create(_ map[string]interface{}) (Process, error) {
+	return &unhealthyProcess{}, nil
+}
+
+func TestStartFlowHealthCheck(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+	require.NoError(t, srv.RegisterProcessType("store", &unhealthyProcessFactory{}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/v1/flows",
+		`{"id":"healthy","config":{"nodes":{"test":{"type":"test"}}}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = do(http.MethodPost, "/api/v1/flows",
+		`{"id":"unhealthy","config":{"nodes":{"test":{"type":"test"},"db":{"type":"store"}}}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = do(http.MethodPost, "/api/v1/flows/healthy/start", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// A failing dependency refuses the start and names the node
+	w = do(http.MethodPost, "/api/v1/flows/unhealthy/start", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `flow unhealthy not started: flow dependencies are unhealthy: node \"db\": database unreachable`)
+
+	srv.flows.mu.RLock()
+	assert.Equal(t, FlowStateCreated, srv.flows.flows["unhealthy"].State)
+	srv.flows.mu.RUnlock()
+
+	w = do(http.MethodPost, "/api/v1/flows/unhealthy/start?force=maybe", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// force skips the health checks
+	w = do(http.MethodPost, "/api/v1/flows/unhealthy/start?force=true", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestStartFlowHealthCheckReusesProcesses(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	factory := &trackedProcessFactory{}
+	require.NoError(t, srv.RegisterProcessType("tracked", factory))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/v1/flows",
+		`{"id":"reused","config":{"nodes":{"a":{"type":"tracked"},"b":{"type":"tracked"}}}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.Equal(t, 2, factory.live())
+
+	// Health checks run on the flow's own instances rather than new ones
+	for i := 0; i < 3; i++ {
+		w = do(http.MethodPost, "/api/v1/flows/reused/start", "")
+		require.NotEqual(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+		do(http.MethodPost, "/api/v1/flows/reused/stop", "")
+	}
+	assert.Equal(t, 2, factory.live())
+}
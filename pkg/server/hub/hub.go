@@ -0,0 +1,136 @@
+// Package hub provides fan-out publish/subscribe for live server endpoints.
+package hub
+
+import "sync"
+
+// DefaultBufferSize is the per-subscriber channel buffer used when none is configured
+const DefaultBufferSize = 16
+
+// Policy decides what happens to a subscriber whose buffer is full
+type Policy int
+
+const (
+	// DropSlow skips the message for a slow subscriber, which stays subscribed
+	DropSlow Policy = iota
+	// DisconnectSlow unsubscribes a slow subscriber and closes its channel
+	DisconnectSlow
+)
+
+type options struct {
+	bufferSize int
+	policy     Policy
+}
+
+// Option configures a Hub
+type Option func(*options)
+
+// WithBufferSize sets the per-subscriber channel buffer
+func WithBufferSize(size int) Option {
+	return func(o *options) {
+		o.bufferSize = size
+	}
+}
+
+// WithPolicy sets how slow subscribers are handled
+func WithPolicy(policy Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// Hub broadcasts published values to every current subscriber. Publish
+// never blocks; subscribers that fall behind are handled per Policy.
+type Hub[T any] struct {
+	opts        options
+	subscribers map[chan T]struct{}
+	dropped     uint64
+	closed      bool
+	mu          sync.Mutex
+}
+
+// New creates a new hub
+func New[T any](opts ...Option) *Hub[T] {
+	o := options{bufferSize: DefaultBufferSize, policy: DropSlow}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.bufferSize < 0 {
+		o.bufferSize = 0
+	}
+	return &Hub[T]{
+		opts:        o,
+		subscribers: make(map[chan T]struct{}),
+	}
+}
+
+// Subscribe returns a channel of values published from now on and a
+// function that unsubscribes and closes the channel. The function is safe
+// to call more than once. Subscribing to a closed hub returns a closed channel.
+func (h *Hub[T]) Subscribe() (<-chan T, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan T, h.opts.bufferSize)
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	h.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(ch)
+	}
+}
+
+// Publish delivers value to every subscriber without blocking
+func (h *Hub[T]) Publish(value T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- value:
+		default:
+			h.dropped++
+			if h.opts.policy == DisconnectSlow {
+				h.remove(ch)
+			}
+		}
+	}
+}
+
+// Len returns the number of current subscribers
+func (h *Hub[T]) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// Dropped returns how many deliveries were skipped because a subscriber was full
+func (h *Hub[T]) Dropped() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.dropped
+}
+
+// Close unsubscribes everyone and closes their channels. Later publishes
+// are discarded.
+func (h *Hub[T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for ch := range h.subscribers {
+		h.remove(ch)
+	}
+}
+
+// remove drops a subscriber; the caller must hold h.mu
+func (h *Hub[T]) remove(ch chan T) {
+	if _, ok := h.subscribers[ch]; ok {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,90 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub(t *testing.T) {
+	t.Run("multiple subscribers", func(t *testing.T) {
+		h := New[int]()
+		a, unsubA := h.Subscribe()
+		defer unsubA()
+		b, unsubB := h.Subscribe()
+		defer unsubB()
+
+		h.Publish(1)
+		h.Publish(2)
+
+		for _, ch := range []<-chan int{a, b} {
+			assert.Equal(t, 1, <-ch)
+			assert.Equal(t, 2, <-ch)
+		}
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		h := New[int]()
+		ch, unsubscribe := h.Subscribe()
+		require.Equal(t, 1, h.Len())
+
+		unsubscribe()
+		unsubscribe()
+		assert.Equal(t, 0, h.Len())
+
+		_, ok := <-ch
+		assert.False(t, ok, "channel should be closed")
+
+		h.Publish(1) // must not panic on the closed channel
+	})
+
+	t.Run("drop slow subscriber messages", func(t *testing.T) {
+		h := New[int](WithBufferSize(1))
+		slow, unsubscribe := h.Subscribe()
+		defer unsubscribe()
+
+		h.Publish(1)
+		h.Publish(2)
+
+		assert.Equal(t, 1, <-slow)
+		assert.Equal(t, uint64(1), h.Dropped())
+		assert.Equal(t, 1, h.Len())
+
+		h.Publish(3)
+		assert.Equal(t, 3, <-slow)
+	})
+
+	t.Run("disconnect slow subscriber", func(t *testing.T) {
+		h := New[int](WithBufferSize(1), WithPolicy(DisconnectSlow))
+		slow, unsubscribe := h.Subscribe()
+		defer unsubscribe()
+		fast, unsubFast := h.Subscribe()
+		defer unsubFast()
+
+		h.Publish(1)
+		<-fast
+		h.Publish(2)
+
+		assert.Equal(t, 1, <-slow)
+		_, ok := <-slow
+		assert.False(t, ok, "slow subscriber should be disconnected")
+		assert.Equal(t, 2, <-fast)
+		assert.Equal(t, 1, h.Len())
+	})
+
+	t.Run("close", func(t *testing.T) {
+		h := New[int]()
+		ch, unsubscribe := h.Subscribe()
+		h.Close()
+		unsubscribe()
+
+		_, ok := <-ch
+		assert.False(t, ok)
+
+		late, _ := h.Subscribe()
+		_, ok = <-late
+		assert.False(t, ok)
+		h.Publish(1)
+	})
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/elleshadow/noPromises/pkg/server/validation"
+)
+
+// Guards keeping node labels usable as low-cardinality dimensions
+const (
+	// MaxNodeLabels is the most labels a single node may carry
+	MaxNodeLabels = 16
+	// MaxLabelValueLength is the longest allowed label value
+	MaxLabelValueLength = 128
+)
+
+// labelKeyPattern restricts label keys to identifier-like names
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]{0,62}$`)
+
+// nodeLabels returns the validated "labels" of a node config, or nil when
+// the node has none
+func nodeLabels(nodeConfig map[string]interface{}) (map[string]string, error) {
+	raw, exists := nodeConfig["labels"]
+	if !exists {
+		return nil, nil
+	}
+	entries, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: must be an object of strings", validation.ErrInvalidLabels)
+	}
+	if len(entries) > MaxNodeLabels {
+		return nil, fmt.Errorf("%w: %d labels exceeds the limit of %d",
+			validation.ErrInvalidLabels, len(entries), MaxNodeLabels)
+	}
+
+	labels := make(map[string]string, len(entries))
+	for key, value := range entries {
+		if !labelKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%w: invalid key %q", validation.ErrInvalidLabels, key)
+		}
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: value of %q must be a string", validation.ErrInvalidLabels, key)
+		}
+		if len(str) > MaxLabelValueLength {
+			return nil, fmt.Errorf("%w: value of %q exceeds %d characters",
+				validation.ErrInvalidLabels, key, MaxLabelValueLength)
+		}
+		labels[key] = str
+	}
+	return labels, nil
+}
+
+// flowNodeLabels collects the labels of every labeled node in a flow config
+func flowNodeLabels(config map[string]interface{}) map[string]map[string]string {
+	nodes, _ := config["nodes"].(map[string]interface{})
+	result := make(map[string]map[string]string)
+	for id, node := range nodes {
+		nodeConfig, _ := node.(map[string]interface{})
+		if labels, err := nodeLabels(nodeConfig); err == nil && len(labels) > 0 {
+			result[id] = labels
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// labelSelector matches flows by node labels, parsed from "key=value"
+// query parameters
+type labelSelector map[string]string
+
+func parseLabelSelector(r *http.Request) (labelSelector, error) {
+	values := r.URL.Query()["label"]
+	if len(values) == 0 {
+		return nil, nil
+	}
+	selector := make(labelSelector, len(values))
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label selector %q: want key=value", value)
+		}
+		selector[key] = val
+	}
+	return selector, nil
+}
+
+// matches reports whether any single node carries every selected label
+func (sel labelSelector) matches(nodeLabels map[string]map[string]string) bool {
+	if len(sel) == 0 {
+		return true
+	}
+	ids := make([]string, 0, len(nodeLabels))
+	for id := range nodeLabels {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		matched := true
+		for key, value := range sel {
+			if nodeLabels[id][key] != value {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
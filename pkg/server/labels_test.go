@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeLabels(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	srv.config.EnableDebug = true
+	srv.config.OperatorToken = "operator-secret"
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer operator-secret")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	listIDs := func(query string) []string {
+		w := do(http.MethodGet, "/api/v1/flows"+query, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data []ManagedFlow `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		var ids []string
+		for _, flow := range resp.Data {
+			ids = append(ids, flow.ID)
+		}
+		return ids
+	}
+
+	w := do(http.MethodPost, "/api/v1/flows", `{"id":"payments","config":{"nodes":{
+		"charge":{"type":"test","labels":{"team":"payments","env":"prod"}},
+		"audit":{"type":"test"}
+	}}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/v1/flows", `{"id":"search","config":{"nodes":{
+		"index":{"type":"test","labels":{"team":"search","env":"prod"}}
+	}}}`).Code)
+
+	t.Run("status", func(t *testing.T) {
+		w := do(http.MethodGet, "/api/v1/flows/payments/status", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data ManagedFlow `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, map[string]map[string]string{
+			"charge": {"team": "payments", "env": "prod"},
+		}, resp.Data.NodeLabels)
+	})
+
+	t.Run("debug state", func(t *testing.T) {
+		w := do(http.MethodGet, "/debug/flows", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data DebugDump `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		require.Len(t, resp.Data.Flows, 2)
+		nodes := resp.Data.Flows[0].Nodes
+		require.Len(t, nodes, 2)
+		assert.Nil(t, nodes[0].Labels, "audit is unlabeled")
+		assert.Equal(t, "payments", nodes[1].Labels["team"])
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		assert.Equal(t, []string{"payments"}, listIDs("?label=team=payments"))
+		assert.ElementsMatch(t, []string{"payments", "search"}, listIDs("?label=env=prod"))
+		assert.Empty(t, listIDs("?label=team=search&label=env=dev"))
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/api/v1/flows?label=team", "").Code)
+	})
+
+	t.Run("cardinality guards", func(t *testing.T) {
+		labels := make([]string, 0, MaxNodeLabels+1)
+		for i := 0; i <= MaxNodeLabels; i++ {
+			labels = append(labels, `"k`+strings.Repeat("x", i)+`":"v"`)
+		}
+		for _, bad := range []string{
+			`{` + strings.Join(labels, ",") + `}`,
+			`{"team":"` + strings.Repeat("x", MaxLabelValueLength+1) + `"}`,
+			`{"bad key":"v"}`,
+			`{"count":3}`,
+			`["team"]`,
+		} {
+			w := do(http.MethodPost, "/api/v1/flows",
+				`{"config":{"nodes":{"a":{"type":"test","labels":`+bad+`}}}}`)
+			assert.Equal(t, http.StatusBadRequest, w.Code, bad)
+			assert.Contains(t, w.Body.String(), "invalid labels", bad)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/elleshadow/noPromises/pkg/core/network"
+)
+
+// flowLimits reads the optional "limits" object of a flow config, see
+// network.ParseLimits. A flow without limits runs unlimited.
+func flowLimits(config map[string]interface{}) (network.Limits, error) {
+	raw, exists := config["limits"]
+	if !exists {
+		return network.Limits{}, nil
+	}
+	limits, ok := raw.(map[string]interface{})
+	if !ok {
+		return network.Limits{}, fmt.Errorf("%w: must be an object", network.ErrInvalidLimits)
+	}
+	return network.ParseLimits(limits)
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachNetworkAppliesLimits(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(
+		`{"id":"limited","config":{"nodes":{"src":{"type":"test"}},"limits":{"max_goroutines":2,"resources":{"api":1}}}}`)))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	n := network.New()
+	require.NoError(t, srv.AttachNetwork("limited", n))
+	assert.Equal(t, network.Limits{MaxGoroutines: 2, Resources: map[string]int{"api": 1}}, n.Limits())
+}
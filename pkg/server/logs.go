@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// DefaultFlowLogSize is how many recent log lines each flow keeps
+const DefaultFlowLogSize = 500
+
+// flowLogs is a bounded ring buffer of a flow's recent log lines. It is an
+// io.Writer, so a log.Logger can write straight into it.
+type flowLogs struct {
+	lines   []string
+	start   int // index of the oldest line in lines
+	count   int
+	partial []byte // an unterminated line awaiting its newline
+	mu      sync.Mutex
+}
+
+func newFlowLogs(size int) *flowLogs {
+	if size <= 0 {
+		size = DefaultFlowLogSize
+	}
+	return &flowLogs{lines: make([]string, size)}
+}
+
+// Write records each complete line in p
+func (l *flowLogs) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data := p
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			l.partial = append(l.partial, data...)
+			return len(p), nil
+		}
+		l.append(string(append(l.partial, data[:i]...)))
+		l.partial = l.partial[:0]
+		data = data[i+1:]
+	}
+}
+
+func (l *flowLogs) append(line string) {
+	end := (l.start + l.count) % len(l.lines)
+	l.lines[end] = line
+	if l.count < len(l.lines) {
+		l.count++
+	} else {
+		l.start = (l.start + 1) % len(l.lines)
+	}
+}
+
+// tail returns up to n of the most recent lines, oldest first. n <= 0
+// returns every buffered line.
+func (l *flowLogs) tail(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n <= 0 || n > l.count {
+		n = l.count
+	}
+	lines := make([]string, 0, n)
+	for i := l.count - n; i < l.count; i++ {
+		lines = append(lines, l.lines[(l.start+i)%len(l.lines)])
+	}
+	return lines
+}
+
+// FlowLogger returns a logger scoped to a flow. Lines written to it are
+// kept in the flow's log buffer, served at /api/v1/flows/{id}/logs, and
+// also go to the standard logger's output. Nodes created by the server
+// that implement LoggerSetter are given it already; hand it to other nodes
+// with nodes.WithLogger or SetLogger. Flows owned by a tenant are named
+// "tenant/id".
+func (s *Server) FlowLogger(id string) (*log.Logger, error) {
+	s.flows.mu.Lock()
+	defer s.flows.mu.Unlock()
+
+	flow, exists := s.flows.flows[id]
+	if !exists {
+		return nil, fmt.Errorf("flow %s not found", id)
+	}
+	if flow.logs == nil {
+		flow.logs = newFlowLogs(DefaultFlowLogSize)
+	}
+	return newFlowLogger(flow.ID, flow.logs), nil
+}
+
+// newFlowLogger returns a logger writing to a flow's log buffer and the
+// standard logger's output
+func newFlowLogger(id string, logs *flowLogs) *log.Logger {
+	return log.New(io.MultiWriter(logs, log.Writer()), "[flow "+id+"] ", log.LstdFlags|log.Lmsgprefix)
+}
+
+// handleFlowLogs returns a flow's recent log lines, oldest first. The tail
+// query parameter limits the response to the last N lines.
+func (s *Server) handleFlowLogs(w http.ResponseWriter, r *http.Request) {
+	flowID := mux.Vars(r)["id"]
+
+	tail := 0
+	if param := r.URL.Query().Get("tail"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid tail %q", param))
+			return
+		}
+		tail = n
+	}
+
+	s.flows.mu.RLock()
+	flow, exists := s.flows.flows[requestFlowKey(r, flowID)]
+	var logs *flowLogs
+	if exists {
+		logs = flow.logs
+	}
+	s.flows.mu.RUnlock()
+
+	if !exists {
+		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", flowID))
+		return
+	}
+
+	lines := []string{}
+	if logs != nil {
+		lines = logs.tail(tail)
+	}
+	respondJSON(w, http.StatusOK, lines)
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/nodes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlowLogs(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows",
+		strings.NewReader(`{"id":"logged","config":{"nodes":{"test":{"type":"test"}}}}`)))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	getLogs := func(query string) (int, []string) {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/flows/logged/logs"+query, nil))
+		var resp struct {
+			Data []string `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		}
+		return w.Code, resp.Data
+	}
+
+	code, lines := getLogs("")
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, lines)
+
+	logger, err := srv.FlowLogger("logged")
+	require.NoError(t, err)
+	node := nodes.NewBaseNode[string, string]("parser", nodes.WithLogger(logger))
+	for i := 1; i <= 3; i++ {
+		node.Logger().Printf("parsed record %d", i)
+	}
+
+	_, lines = getLogs("")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "[flow logged] parsed record 1")
+
+	_, lines = getLogs("?tail=2")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "parsed record 2")
+	assert.Contains(t, lines[1], "parsed record 3")
+
+	code, _ = getLogs("?tail=-1")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	_, err = srv.FlowLogger("missing")
+	assert.Error(t, err)
+}
+
+// nodeProcessFactory creates processes embedding a BaseNode, keeping them
+// so tests can use their loggers
+type nodeProcessFactory struct {
+	created []*nodeProcess
+}
+
+type nodeProcess struct {
+	mockProcess
+	*nodes.BaseNode[string, string]
+}
+
+func (f *nodeProcessFactory) Create(_ map[string]interface{}) (Process, error) {
+	process := &nodeProcess{BaseNode: nodes.NewBaseNode[string, string]("node")}
+	f.created = append(f.created, process)
+	return process, nil
+}
+
+func TestFlowLogsFromCreatedNodes(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	factory := &nodeProcessFactory{}
+	require.NoError(t, srv.RegisterProcessType("node", factory))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/v1/flows",
+		`{"id":"wired","config":{"nodes":{"a":{"type":"node"}}}}`).Code)
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/api/v1/flows/wired",
+		`{"config":{"nodes":{"a":{"type":"node"},"b":{"type":"node"}}}}`).Code)
+	require.Len(t, factory.created, 3)
+
+	// Nodes log into the flow without being handed its logger
+	for i, process := range factory.created {
+		process.Logger().Printf("node %d ready", i)
+	}
+
+	w := do(http.MethodGet, "/api/v1/flows/wired/logs", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Data []string `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp.Data, 3)
+	assert.Contains(t, resp.Data[0], "[flow wired] node 0 ready")
+	assert.Contains(t, resp.Data[2], "[flow wired] node 2 ready")
+}
+
+func TestFlowLogsBounded(t *testing.T) {
+	logs := newFlowLogs(3)
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(logs, "line %d\n", i)
+	}
+	assert.Equal(t, []string{"line 3", "line 4", "line 5"}, logs.tail(0))
+
+	// Lines may arrive split across writes
+	fmt.Fprint(logs, "spl")
+	fmt.Fprint(logs, "it\nnext\n")
+	assert.Equal(t, []string{"line 5", "split", "next"}, logs.tail(0))
+}
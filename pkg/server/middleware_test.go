@@ -0,0 +1,114 @@
+package server
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/server/api/middleware"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMetrics records request metrics for middleware tests
+type recordingMetrics struct {
+	requests []string
+	statuses []int
+	mu       sync.Mutex
+}
+
+func (m *recordingMetrics) RecordRequest(method, path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, method+" "+path)
+}
+
+func (m *recordingMetrics) RecordRequestDuration(_ time.Duration) {}
+
+func (m *recordingMetrics) RecordResponseStatus(status int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statuses = append(m.statuses, status)
+}
+
+func (m *recordingMetrics) RecordFlowCreation(_ string) {}
+func (m *recordingMetrics) RecordFlowDeletion(_ string) {}
+func (m *recordingMetrics) RecordFlowStart(_ string)    {}
+func (m *recordingMetrics) RecordFlowStop(_ string)     {}
+
+// panickingProcessFactory panics when asked to create a process
+type panickingProcessFactory struct{}
+
+func (f *panickingProcessFactory) Create(_ map[string]interface{}) (Process, error) {
+	panic("factory exploded")
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	var logs bytes.Buffer
+	original := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(original)
+
+	metrics := &recordingMetrics{}
+	srv, _ := setupTestServer(t)
+	srv.config.Middleware = []middleware.Middleware{
+		middleware.LoggingMiddleware,
+		middleware.MetricsMiddleware(metrics),
+		middleware.RecoveryMiddleware,
+	}
+	srv.router = mux.NewRouter()
+	srv.setupRoutes()
+	srv.setupMiddleware()
+	srv.Handler = srv.router
+	require.NoError(t, srv.RegisterProcessType("panics", &panickingProcessFactory{}))
+
+	// Creating the flow's node panics inside the create handler
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows",
+		strings.NewReader(`{"id":"boom","config":{"nodes":{"bad":{"type":"panics"}}}}`)))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"error":{"message":"Internal Server Error"}}`, w.Body.String())
+	assert.Contains(t, logs.String(), "panic: factory exploded")
+	assert.Contains(t, logs.String(), "POST /api/v1/flows 500")
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	assert.Equal(t, []string{"POST /api/v1/flows"}, metrics.requests)
+	assert.Equal(t, []int{http.StatusInternalServerError}, metrics.statuses)
+}
+
+func TestLogBodies(t *testing.T) {
+	var logs bytes.Buffer
+	original := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(original)
+
+	body := `{"id":"logged","config":{"nodes":{"test":{"type":"test"}}}}`
+	for _, enabled := range []bool{false, true} {
+		logs.Reset()
+		srv, _ := setupTestServer(t)
+		srv.config.LogBodies = enabled
+		srv.router = mux.NewRouter()
+		srv.setupRoutes()
+		srv.setupMiddleware()
+		srv.Handler = srv.router
+		require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body)))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		if enabled {
+			assert.Contains(t, logs.String(), `"id":"logged"`)
+		} else {
+			assert.NotContains(t, logs.String(), "request body")
+		}
+	}
+}
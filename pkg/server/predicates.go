@@ -0,0 +1,36 @@
+package server
+
+import "sync"
+
+// Predicate decides whether a packet may traverse a guarded edge
+type Predicate func(data interface{}) bool
+
+// PredicateRegistry holds the named predicates edges may reference with "when"
+type PredicateRegistry struct {
+	predicates map[string]Predicate
+	mu         sync.RWMutex
+}
+
+func newPredicateRegistry() *PredicateRegistry {
+	return &PredicateRegistry{
+		predicates: make(map[string]Predicate),
+	}
+}
+
+// RegisterPredicate registers a named predicate for guarded edges. The
+// server only validates that guards name a registered predicate; it does
+// not run flows, so the embedder building a flow's network filters the
+// edge with a flow.Filter over the predicate, as runflow does for its
+// built-in predicates.
+func (s *Server) RegisterPredicate(name string, predicate Predicate) {
+	s.predicates.mu.Lock()
+	defer s.predicates.mu.Unlock()
+	s.predicates.predicates[name] = predicate
+}
+
+func (s *Server) lookupPredicate(name string) (Predicate, bool) {
+	s.predicates.mu.RLock()
+	defer s.predicates.mu.RUnlock()
+	predicate, exists := s.predicates.predicates[name]
+	return predicate, exists
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardedEdges(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+	srv.RegisterPredicate("even", func(data interface{}) bool {
+		n, ok := data.(int)
+		return ok && n%2 == 0
+	})
+
+	createFlow := func(id, when string) *httptest.ResponseRecorder {
+		body := `{"id":"` + id + `","config":{
+			"nodes":{"a":{"type":"test"},"b":{"type":"test"}},
+			"edges":[{"from":"a","to":"b","when":"` + when + `"}]}}`
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("registered predicate", func(t *testing.T) {
+		w := createFlow("guarded", "even")
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("unknown predicate", func(t *testing.T) {
+		w := createFlow("unguarded", "odd")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `edge 0: unknown predicate: \"odd\"`)
+	})
+}
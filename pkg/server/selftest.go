@@ -0,0 +1,58 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrSelfTest is wrapped by the errors SelfTest reports
+var ErrSelfTest = errors.New("self-test failed")
+
+// SelfTest checks that the server is usable without serving any traffic.
+// The docs path was already verified by NewServer; SelfTest checks that
+// every registered process type can be described. All problems found are
+// reported together.
+func (s *Server) SelfTest() error {
+	s.processes.mu.RLock()
+	names := make([]string, 0, len(s.processes.processes))
+	for name := range s.processes.processes {
+		names = append(names, name)
+	}
+	s.processes.mu.RUnlock()
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if err := s.selfTestProcessType(name); err != nil {
+			errs = append(errs, fmt.Errorf("%w: process type %s: %v", ErrSelfTest, name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// selfTestProcessType describes one process type, turning a panicking
+// Describe into an error
+func (s *Server) selfTestProcessType(name string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("describe panicked: %v", r)
+		}
+	}()
+
+	desc, exists := s.describeProcessType(name)
+	if !exists {
+		return fmt.Errorf("not registered")
+	}
+	for _, port := range append(desc.Inputs, desc.Outputs...) {
+		if port.Name == "" {
+			return fmt.Errorf("port without a name")
+		}
+	}
+	for _, config := range desc.Config {
+		if config.Name == "" {
+			return fmt.Errorf("config key without a name")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenDescriberFactory describes a port without a name
+type brokenDescriberFactory struct {
+	mockProcessFactory
+}
+
+func (f *brokenDescriberFactory) Describe() ProcessDescriptor {
+	return ProcessDescriptor{Inputs: []PortDescriptor{{Type: "string"}}}
+}
+
+// panickingDescriberFactory panics when described
+type panickingDescriberFactory struct {
+	mockProcessFactory
+}
+
+func (f *panickingDescriberFactory) Describe() ProcessDescriptor {
+	panic("no descriptor")
+}
+
+func TestSelfTest(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+	assert.NoError(t, srv.SelfTest())
+
+	require.NoError(t, srv.RegisterProcessType("broken", &brokenDescriberFactory{}))
+	require.NoError(t, srv.RegisterProcessType("panicking", &panickingDescriberFactory{}))
+
+	err := srv.SelfTest()
+	assert.ErrorIs(t, err, ErrSelfTest)
+	assert.Contains(t, err.Error(), "process type broken: port without a name")
+	assert.Contains(t, err.Error(), "process type panicking: describe panicked: no descriptor")
+}
@@ -3,24 +3,79 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/elleshadow/noPromises/internal/server/web"
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/server/api/middleware"
 	"github.com/elleshadow/noPromises/pkg/server/docs"
+	"github.com/elleshadow/noPromises/pkg/server/validation"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
+// DefaultMaxRequestBodySize is the request body limit used when
+// Config.MaxRequestBodySize is not set
+const DefaultMaxRequestBodySize int64 = 1 << 20 // 1 MiB
+
+// HTTP server timeouts used when the matching Config field is not set
+const (
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultWriteTimeout      = 60 * time.Second
+	DefaultIdleTimeout       = 120 * time.Second
+)
+
+var (
+	// ErrUnknownProcessType is returned when creating a process of an unregistered type
+	ErrUnknownProcessType = errors.New("unknown process type")
+	// ErrInvalidProcessType is returned when registering a process type without a name or factory
+	ErrInvalidProcessType = errors.New("invalid process type registration")
+	// ErrNoProcessTypes is reported when a flow is created before any
+	// process type is registered
+	ErrNoProcessTypes = errors.New("no process types registered")
+)
+
 // Config holds server configuration
 type Config struct {
 	Port     int
 	DocsPath string
+	// MaxRequestBodySize limits JSON request bodies in bytes
+	MaxRequestBodySize int64
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are
+	// applied to the HTTP server by Start; zero uses the matching Default.
+	// Event streams clear their write deadline and are not cut off by
+	// WriteTimeout.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// EnableDebug exposes the /debug/flows endpoint
+	EnableDebug bool
+	// EnableProfiling exposes the net/http/pprof handlers under /debug/pprof/
+	EnableProfiling bool
+	// OperatorToken is the bearer token required by /debug endpoints. The
+	// endpoints are not served while it is empty.
+	OperatorToken string
+	// LogBodies logs flow API request and response bodies, with sensitive
+	// fields redacted. It is meant for debugging and is off by default.
+	LogBodies bool
+	// ProcessTypes are registered by NewServer, so the server never
+	// serves requests without its node types
+	ProcessTypes map[string]ProcessFactory
+	// Middleware wraps the flow API routes, the first entry outermost
+	Middleware []middleware.Middleware
 }
 
 // Server represents the main server component
@@ -29,8 +84,13 @@ type Server struct {
 	router    *mux.Router
 	flows     *FlowManager
 	processes *ProcessRegistry
-	webServer *web.Server
-	Handler   http.Handler
+	// predicates are referenced by guarded edges
+	predicates *PredicateRegistry
+	// transforms are referenced by edges mapping packets inline
+	transforms *TransformRegistry
+	templates  *TemplateRegistry
+	webServer  *web.Server
+	Handler    http.Handler
 }
 
 // FlowManager handles flow lifecycle and state management
@@ -46,6 +106,47 @@ type ManagedFlow struct {
 	State     FlowState              `json:"state"`
 	StartTime *time.Time             `json:"started_at,omitempty"`
 	Error     string                 `json:"error,omitempty"`
+	// RequestID is the correlation id of the request that created the flow
+	RequestID string `json:"request_id,omitempty"`
+	// Tenant owns the flow; only requests scoped to it can see the flow
+	Tenant string `json:"tenant,omitempty"`
+	// NodeLabels are the labels of each labeled node, keyed by node id
+	NodeLabels map[string]map[string]string `json:"node_labels,omitempty"`
+
+	events  *eventLog
+	logs    *flowLogs
+	network *network.Network
+	// recentErrors are the latest errors of the attached network's processes
+	recentErrors *flowLogs
+	// processes are the flow's node instances, held until it is deleted
+	processes map[string]Process
+}
+
+// flowKey scopes a flow id to its tenant. Flow ids cannot contain "/", so
+// scoped keys never collide with unscoped ones.
+func flowKey(tenant, id string) string {
+	if tenant == "" {
+		return id
+	}
+	return tenant + "/" + id
+}
+
+// requestFlowKey scopes a flow id to the tenant of a request
+func requestFlowKey(r *http.Request, id string) string {
+	return flowKey(middleware.TenantFromContext(r.Context()), id)
+}
+
+// Request headers carrying a correlation id, in order of preference
+var requestIDHeaders = []string{"X-Request-ID", "X-Correlation-ID"}
+
+// requestID returns the correlation id supplied with a request, if any
+func requestID(r *http.Request) string {
+	for _, header := range requestIDHeaders {
+		if id := strings.TrimSpace(r.Header.Get(header)); id != "" {
+			return id
+		}
+	}
+	return ""
 }
 
 // FlowState represents the possible states of a flow
@@ -99,15 +200,24 @@ func NewServer(config Config) (*Server, error) {
 	flowManager := newFlowManager()
 
 	s := &Server{
-		config:    config,
-		router:    mux.NewRouter(),
-		flows:     flowManager,
-		processes: newProcessRegistry(),
+		config:     config,
+		router:     mux.NewRouter(),
+		flows:      flowManager,
+		processes:  newProcessRegistry(),
+		predicates: newPredicateRegistry(),
+		transforms: newTransformRegistry(),
+		templates:  newTemplateRegistry(),
 		webServer: web.NewServer(
 			web.WithFlowManager(flowManager),
 		),
 	}
 
+	for name, factory := range config.ProcessTypes {
+		if err := s.RegisterProcessType(name, factory); err != nil {
+			return nil, err
+		}
+	}
+
 	s.setupRoutes()
 	s.setupMiddleware()
 
@@ -141,13 +251,32 @@ func (s *Server) setupRoutes() {
 
 	// API routes
 	api := s.router.PathPrefix("/api/v1").Subrouter()
+	api.Use(mux.MiddlewareFunc(middleware.Chain(s.config.Middleware...)))
+	if s.config.LogBodies {
+		api.Use(mux.MiddlewareFunc(middleware.BodyLoggingMiddleware()))
+	}
 	api.HandleFunc("/flows", s.handleCreateFlow).Methods(http.MethodPost)
 	api.HandleFunc("/flows", s.handleListFlows).Methods(http.MethodGet)
+	api.HandleFunc("/flows/validate", s.handleValidateFlow).Methods(http.MethodPost)
+	api.HandleFunc("/flows/from-template/{templateID}", s.handleCreateFlowFromTemplate).Methods(http.MethodPost)
 	api.HandleFunc("/flows/{id}", s.handleGetFlow).Methods(http.MethodGet)
+	api.HandleFunc("/flows/{id}", s.handleUpdateFlow).Methods(http.MethodPut)
 	api.HandleFunc("/flows/{id}", s.handleDeleteFlow).Methods(http.MethodDelete)
+	api.HandleFunc("/flows/{id}/diff/{other}", s.handleDiffFlows).Methods(http.MethodGet)
+	api.HandleFunc("/flows/{id}/clone", s.handleCloneFlow).Methods(http.MethodPost)
 	api.HandleFunc("/flows/{id}/start", s.handleStartFlow).Methods(http.MethodPost)
 	api.HandleFunc("/flows/{id}/stop", s.handleStopFlow).Methods(http.MethodPost)
 	api.HandleFunc("/flows/{id}/status", s.handleGetFlowStatus).Methods(http.MethodGet)
+	api.HandleFunc("/flows/{id}/events", s.handleFlowEvents).Methods(http.MethodGet)
+	api.HandleFunc("/flows/{id}/logs", s.handleFlowLogs).Methods(http.MethodGet)
+	api.HandleFunc("/flows/{id}/tap", s.handleTapFlow).Methods(http.MethodGet)
+	api.HandleFunc("/process-types/{name}", s.handleGetProcessType).Methods(http.MethodGet)
+	api.HandleFunc("/templates", s.handleCreateTemplate).Methods(http.MethodPost)
+	api.HandleFunc("/templates/{id}", s.handleGetTemplate).Methods(http.MethodGet)
+
+	// Debug routes
+	s.router.HandleFunc("/debug/flows", s.operatorOnly(s.handleDebugFlows)).Methods(http.MethodGet)
+	s.router.PathPrefix("/debug/pprof/").Handler(s.operatorOnly(s.handleProfiling))
 
 	// Static files - handle before the catch-all route
 	staticDir := filepath.Join("web", "static")
@@ -157,8 +286,9 @@ func (s *Server) setupRoutes() {
 	staticHandler := http.StripPrefix("/static/", http.FileServer(http.Dir(staticDir)))
 	s.router.PathPrefix("/static/").Handler(staticHandler)
 
-	// Web interface (must be last as it's the catch-all)
-	s.router.PathPrefix("/").Handler(s.webServer)
+	// Web interface (must be last as it's the catch-all). It lists flows,
+	// so it runs behind the same middleware as the API to see the tenant.
+	s.router.PathPrefix("/").Handler(middleware.Chain(s.config.Middleware...)(s.webServer))
 }
 
 // setupMiddleware configures middleware
@@ -197,41 +327,118 @@ func respondError(w http.ResponseWriter, status int, err error) {
 	}
 }
 
+// respondValidationErrors writes a 400 response listing every validation problem
+func respondValidationErrors(w http.ResponseWriter, errs validation.Errors) {
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": errs.Error(),
+			"errors":  errs.Messages(),
+		},
+	}); err != nil {
+		log.Printf("Error encoding error response: %v", err)
+	}
+}
+
+// decodeJSONBody decodes a size-limited JSON request body into dst,
+// writing the error response itself and reporting whether it succeeded
+func (s *Server) decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	limit := s.config.MaxRequestBodySize
+	if limit <= 0 {
+		limit = DefaultMaxRequestBodySize
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondError(w, http.StatusRequestEntityTooLarge,
+				fmt.Errorf("request body exceeds %d bytes", maxBytesErr.Limit))
+			return false
+		}
+		respondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
 // Flow management handlers
 func (s *Server) handleCreateFlow(w http.ResponseWriter, r *http.Request) {
 	var flowConfig struct {
 		ID     string                 `json:"id"`
 		Config map[string]interface{} `json:"config"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&flowConfig); err != nil {
-		respondError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+	if !s.decodeJSONBody(w, r, &flowConfig) {
 		return
 	}
 
-	// Validate flow configuration
-	if err := s.validateFlowConfig(flowConfig.Config); err != nil {
+	s.createFlow(w, r, flowConfig.ID, flowConfig.Config)
+}
+
+// createFlow validates config and registers it as a new flow, writing the
+// response. An empty id is replaced with a generated one.
+func (s *Server) createFlow(w http.ResponseWriter, r *http.Request, id string, config map[string]interface{}) {
+	// Generate an id when the client does not supply one
+	if id == "" {
+		id = uuid.New().String()
+	} else if err := validateFlowID(id); err != nil {
 		respondError(w, http.StatusBadRequest, err)
 		return
 	}
 
+	// Without process types no node can validate, so tell the client the
+	// server is not ready rather than that its config is wrong
+	if !s.hasProcessTypes() {
+		respondError(w, http.StatusServiceUnavailable, ErrNoProcessTypes)
+		return
+	}
+
+	// Validate flow configuration, reporting every problem at once
+	if errs := s.validateFlowConfigAll(config); len(errs) > 0 {
+		respondValidationErrors(w, errs)
+		return
+	}
+
+	// Construct the nodes outside the lock; a client disconnecting meanwhile
+	// cancels the request context and rolls construction back
+	logs := newFlowLogs(DefaultFlowLogSize)
+	processes, err := s.instantiateFlow(r.Context(), config, newFlowLogger(id, logs))
+	if err != nil {
+		respondConstructionError(w, r, err)
+		return
+	}
+
 	s.flows.mu.Lock()
 	defer s.flows.mu.Unlock()
 
 	// Check if flow already exists
-	if _, exists := s.flows.flows[flowConfig.ID]; exists {
-		respondError(w, http.StatusConflict, fmt.Errorf("flow %s already exists", flowConfig.ID))
+	tenant := middleware.TenantFromContext(r.Context())
+	key := flowKey(tenant, id)
+	if _, exists := s.flows.flows[key]; exists {
+		_ = releaseProcesses(r.Context(), processes)
+		respondError(w, http.StatusConflict, fmt.Errorf("flow %s already exists", id))
 		return
 	}
 
 	// Create new flow
 	flow := &ManagedFlow{
-		ID:     flowConfig.ID,
-		Config: flowConfig.Config,
-		State:  FlowStateCreated,
+		ID:         id,
+		Config:     config,
+		State:      FlowStateCreated,
+		RequestID:  requestID(r),
+		Tenant:     tenant,
+		NodeLabels: flowNodeLabels(config),
+		events:     newEventLog(id, DefaultEventBufferSize),
+		logs:       logs,
+		processes:  processes,
 	}
-	s.flows.flows[flowConfig.ID] = flow
+	s.flows.flows[key] = flow
+	flow.events.publish("created", flow.State)
 
-	respondJSON(w, http.StatusCreated, flow)
+	respondJSON(w, http.StatusCreated, createFlowResponse{
+		ManagedFlow: flow,
+		Topology:    s.resolveTopology(config),
+	})
 }
 
 func (s *Server) handleGetFlow(w http.ResponseWriter, r *http.Request) {
@@ -239,7 +446,7 @@ func (s *Server) handleGetFlow(w http.ResponseWriter, r *http.Request) {
 	flowID := vars["id"]
 
 	s.flows.mu.RLock()
-	flow, exists := s.flows.flows[flowID]
+	flow, exists := s.flows.flows[requestFlowKey(r, flowID)]
 	s.flows.mu.RUnlock()
 
 	if !exists {
@@ -250,23 +457,207 @@ func (s *Server) handleGetFlow(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, flow)
 }
 
-func (s *Server) handleListFlows(w http.ResponseWriter, _ *http.Request) {
+// handleUpdateFlow replaces the config of a flow that is not running,
+// keeping its id and event history
+func (s *Server) handleUpdateFlow(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	flowID := vars["id"]
+
+	var update struct {
+		Config map[string]interface{} `json:"config"`
+	}
+	if !s.decodeJSONBody(w, r, &update) {
+		return
+	}
+
+	s.flows.mu.RLock()
+	current, exists := s.flows.flows[requestFlowKey(r, flowID)]
+	var logger *log.Logger
+	if exists && current.logs != nil {
+		logger = newFlowLogger(current.ID, current.logs)
+	}
+	s.flows.mu.RUnlock()
+	if !exists {
+		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", flowID))
+		return
+	}
+
+	if errs := s.validateFlowConfigAll(update.Config); len(errs) > 0 {
+		respondValidationErrors(w, errs)
+		return
+	}
+
+	processes, err := s.instantiateFlow(r.Context(), update.Config, logger)
+	if err != nil {
+		respondConstructionError(w, r, err)
+		return
+	}
+
+	s.flows.mu.Lock()
+	defer s.flows.mu.Unlock()
+
+	// Re-check under the write lock; the flow may have changed meanwhile
+	flow, exists := s.flows.flows[requestFlowKey(r, flowID)]
+	if !exists {
+		_ = releaseProcesses(r.Context(), processes)
+		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", flowID))
+		return
+	}
+
+	switch flow.State {
+	case FlowStateCreated, FlowStateStopped, FlowStateError:
+	default:
+		_ = releaseProcesses(r.Context(), processes)
+		respondError(w, http.StatusConflict,
+			fmt.Errorf("cannot update flow %s while %s", flowID, flow.State))
+		return
+	}
+
+	if err := releaseProcesses(r.Context(), flow.processes); err != nil {
+		log.Printf("WARN releasing replaced nodes of flow %s: %v", flowID, err)
+	}
+	flow.processes = processes
+	flow.Config = update.Config
+	flow.NodeLabels = flowNodeLabels(update.Config)
+	flow.events.publish("updated", flow.State)
+
+	respondJSON(w, http.StatusOK, flow)
+}
+
+// handleCloneFlow creates a flow with a fresh id and a copy of the source
+// flow's config. The clone starts in the created state whatever the state
+// of the source, and shares nothing with it.
+func (s *Server) handleCloneFlow(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	flowID := vars["id"]
+
+	s.flows.mu.RLock()
+	source, exists := s.flows.flows[requestFlowKey(r, flowID)]
+	var config map[string]interface{}
+	if exists {
+		config = copyConfig(source.Config)
+	}
+	s.flows.mu.RUnlock()
+	if !exists {
+		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", flowID))
+		return
+	}
+
+	id := uuid.New().String()
+	logs := newFlowLogs(DefaultFlowLogSize)
+	processes, err := s.instantiateFlow(r.Context(), config, newFlowLogger(id, logs))
+	if err != nil {
+		respondConstructionError(w, r, err)
+		return
+	}
+
+	s.flows.mu.Lock()
+	defer s.flows.mu.Unlock()
+
+	clone := &ManagedFlow{
+		ID:         id,
+		Config:     config,
+		State:      FlowStateCreated,
+		RequestID:  requestID(r),
+		Tenant:     source.Tenant,
+		NodeLabels: flowNodeLabels(config),
+		events:     newEventLog(id, DefaultEventBufferSize),
+		logs:       logs,
+		processes:  processes,
+	}
+	s.flows.flows[flowKey(clone.Tenant, id)] = clone
+	clone.events.publish("created", clone.State)
+
+	respondJSON(w, http.StatusCreated, clone)
+}
+
+// copyConfig deep-copies a decoded JSON config so the copy can be changed
+// without affecting the original
+func copyConfig(config map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	return copyConfigValue(config).(map[string]interface{})
+}
+
+func copyConfigValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, nested := range v {
+			result[key] = copyConfigValue(nested)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, nested := range v {
+			result[i] = copyConfigValue(nested)
+		}
+		return result
+	}
+	return value
+}
+
+// handleListFlows lists the tenant's flows. Repeated label=key=value query
+// parameters keep only flows with a node carrying all the given labels.
+func (s *Server) handleListFlows(w http.ResponseWriter, r *http.Request) {
+	tenant := middleware.TenantFromContext(r.Context())
+	selector, err := parseLabelSelector(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	s.flows.mu.RLock()
 	flows := make([]*ManagedFlow, 0, len(s.flows.flows))
 	for _, flow := range s.flows.flows {
-		flows = append(flows, flow)
+		if flow.Tenant == tenant && selector.matches(flow.NodeLabels) {
+			flows = append(flows, flow)
+		}
 	}
 	s.flows.mu.RUnlock()
 
 	respondJSON(w, http.StatusOK, flows)
 }
 
+// handleStartFlow starts a flow once the health checks of its nodes pass.
+// force=true skips the health checks.
 func (s *Server) handleStartFlow(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	flowID := vars["id"]
 
+	force := false
+	if param := r.URL.Query().Get("force"); param != "" {
+		value, err := strconv.ParseBool(param)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("invalid force %q", param))
+			return
+		}
+		force = value
+	}
+
+	if !force {
+		s.flows.mu.RLock()
+		flow, exists := s.flows.flows[requestFlowKey(r, flowID)]
+		processes := make(map[string]Process)
+		if exists {
+			for id, process := range flow.processes {
+				processes[id] = process
+			}
+		}
+		s.flows.mu.RUnlock()
+
+		// Checks may be slow, so they run without holding the lock
+		if exists {
+			if err := checkFlowHealth(r.Context(), processes); err != nil {
+				respondError(w, http.StatusServiceUnavailable, fmt.Errorf("flow %s not started: %w", flowID, err))
+				return
+			}
+		}
+	}
+
 	s.flows.mu.Lock()
-	flow, exists := s.flows.flows[flowID]
+	flow, exists := s.flows.flows[requestFlowKey(r, flowID)]
 	if !exists {
 		s.flows.mu.Unlock()
 		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", flowID))
@@ -282,6 +673,9 @@ func (s *Server) handleStartFlow(w http.ResponseWriter, r *http.Request) {
 	flow.State = FlowStateStarting
 	now := time.Now()
 	flow.StartTime = &now
+	flow.events.publish("starting", flow.State)
+	// Respond with a snapshot; the goroutine below changes the flow
+	snapshot := *flow
 	s.flows.mu.Unlock()
 
 	// Start flow in background
@@ -289,10 +683,11 @@ func (s *Server) handleStartFlow(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(50 * time.Millisecond)
 		s.flows.mu.Lock()
 		flow.State = FlowStateRunning
+		flow.events.publish("running", flow.State)
 		s.flows.mu.Unlock()
 	}()
 
-	respondJSON(w, http.StatusOK, flow)
+	respondJSON(w, http.StatusOK, &snapshot)
 }
 
 func (s *Server) handleStopFlow(w http.ResponseWriter, r *http.Request) {
@@ -300,7 +695,7 @@ func (s *Server) handleStopFlow(w http.ResponseWriter, r *http.Request) {
 	flowID := vars["id"]
 
 	s.flows.mu.Lock()
-	flow, exists := s.flows.flows[flowID]
+	flow, exists := s.flows.flows[requestFlowKey(r, flowID)]
 	if !exists {
 		s.flows.mu.Unlock()
 		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", flowID))
@@ -315,6 +710,9 @@ func (s *Server) handleStopFlow(w http.ResponseWriter, r *http.Request) {
 	}
 
 	flow.State = FlowStateStopping
+	flow.events.publish("stopping", flow.State)
+	// Respond with a snapshot; the goroutine below changes the flow
+	snapshot := *flow
 	s.flows.mu.Unlock()
 
 	// Stop flow in background
@@ -322,10 +720,11 @@ func (s *Server) handleStopFlow(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(50 * time.Millisecond)
 		s.flows.mu.Lock()
 		flow.State = FlowStateStopped
+		flow.events.publish("stopped", flow.State)
 		s.flows.mu.Unlock()
 	}()
 
-	respondJSON(w, http.StatusOK, flow)
+	respondJSON(w, http.StatusOK, &snapshot)
 }
 
 func (s *Server) handleDeleteFlow(w http.ResponseWriter, r *http.Request) {
@@ -333,7 +732,7 @@ func (s *Server) handleDeleteFlow(w http.ResponseWriter, r *http.Request) {
 	flowID := vars["id"]
 
 	s.flows.mu.Lock()
-	flow, exists := s.flows.flows[flowID]
+	flow, exists := s.flows.flows[requestFlowKey(r, flowID)]
 	if !exists {
 		s.flows.mu.Unlock()
 		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", flowID))
@@ -346,9 +745,18 @@ func (s *Server) handleDeleteFlow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	delete(s.flows.flows, flowID)
+	delete(s.flows.flows, requestFlowKey(r, flowID))
+	state := flow.State
 	s.flows.mu.Unlock()
 
+	// Tell subscribers the flow is gone and end their streams
+	flow.events.publish("deleted", state)
+	flow.events.close()
+
+	if err := releaseProcesses(r.Context(), flow.processes); err != nil {
+		log.Printf("WARN releasing nodes of deleted flow %s: %v", flowID, err)
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -357,7 +765,7 @@ func (s *Server) handleGetFlowStatus(w http.ResponseWriter, r *http.Request) {
 	flowID := vars["id"]
 
 	s.flows.mu.RLock()
-	flow, exists := s.flows.flows[flowID]
+	flow, exists := s.flows.flows[requestFlowKey(r, flowID)]
 	s.flows.mu.RUnlock()
 
 	if !exists {
@@ -369,29 +777,114 @@ func (s *Server) handleGetFlowStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 // Validation helpers
-func (s *Server) validateFlowConfig(config map[string]interface{}) error {
+
+// flowIDPattern restricts client-supplied flow ids to URL- and path-safe characters
+var flowIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+func validateFlowID(id string) error {
+	if !flowIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid flow id %q: must be 1-128 letters, digits, '.', '_' or '-'", id)
+	}
+	return nil
+}
+
+// validateFlowConfigAll checks the whole flow configuration in one pass and
+// returns every problem found, ordered by node id
+func (s *Server) validateFlowConfigAll(config map[string]interface{}) validation.Errors {
+	var errs validation.Errors
+
 	nodes, ok := config["nodes"].(map[string]interface{})
 	if !ok {
-		return fmt.Errorf("invalid nodes configuration")
+		return append(errs, validation.ErrInvalidNodes)
+	}
+
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
 	}
+	sort.Strings(ids)
 
-	for _, node := range nodes {
-		nodeConfig, ok := node.(map[string]interface{})
+	for _, id := range ids {
+		nodeConfig, ok := nodes[id].(map[string]interface{})
 		if !ok {
-			return fmt.Errorf("invalid node configuration")
+			errs = append(errs, fmt.Errorf("node %q: %w", id, validation.ErrInvalidNodeConfig))
+			continue
 		}
 
 		nodeType, ok := nodeConfig["type"].(string)
-		if !ok {
-			return fmt.Errorf("missing node type")
+		if !ok || nodeType == "" {
+			errs = append(errs, fmt.Errorf("node %q: %w", id, validation.ErrMissingNodeType))
+			continue
 		}
 
 		if !s.isValidProcessType(nodeType) {
-			return fmt.Errorf("invalid process type: %s", nodeType)
+			errs = append(errs, fmt.Errorf("node %q: %w: %s", id, validation.ErrInvalidNodeType, nodeType))
+		}
+
+		if _, err := nodeLabels(nodeConfig); err != nil {
+			errs = append(errs, fmt.Errorf("node %q: %w", id, err))
 		}
 	}
 
-	return nil
+	if _, err := flowLimits(config); err != nil {
+		errs = append(errs, err)
+	}
+	if raw, exists := config["defaults"]; exists {
+		if _, ok := raw.(map[string]interface{}); !ok {
+			errs = append(errs, fmt.Errorf("%w: must be an object", validation.ErrInvalidDefaults))
+		}
+	}
+
+	rawEdges, exists := config["edges"]
+	if !exists {
+		return errs
+	}
+	edges, ok := rawEdges.([]interface{})
+	if !ok {
+		return append(errs, validation.ErrInvalidEdges)
+	}
+
+	for i, edge := range edges {
+		edgeConfig, ok := edge.(map[string]interface{})
+		if !ok {
+			errs = append(errs, fmt.Errorf("edge %d: %w", i, validation.ErrInvalidEdge))
+			continue
+		}
+		connected := true
+		for _, end := range []string{"from", "to"} {
+			id, _ := edgeConfig[end].(string)
+			if id == "" {
+				errs = append(errs, fmt.Errorf("edge %d: %w: missing %s", i, validation.ErrInvalidEdge, end))
+				connected = false
+			} else if _, exists := nodes[id]; !exists {
+				errs = append(errs, fmt.Errorf("edge %d: %w: unknown node %q", i, validation.ErrInvalidEdge, id))
+				connected = false
+			}
+		}
+		_, transformed := edgeConfig["transform"]
+		// An inline transform maps between the port types, so only
+		// untransformed edges are type checked
+		if connected && !transformed {
+			errs = append(errs, s.checkEdgeTypes(i, edgeConfig, nodes)...)
+		}
+		if _, err := edgeBufferSize(edgeConfig); err != nil {
+			errs = append(errs, fmt.Errorf("edge %d: %w", i, err))
+		}
+		if when, exists := edgeConfig["when"]; exists {
+			name, _ := when.(string)
+			if _, registered := s.lookupPredicate(name); !registered {
+				errs = append(errs, fmt.Errorf("edge %d: %w: %q", i, validation.ErrUnknownPredicate, name))
+			}
+		}
+		if fn, exists := edgeConfig["transform"]; exists {
+			name, _ := fn.(string)
+			if _, registered := s.lookupTransform(name); !registered {
+				errs = append(errs, fmt.Errorf("edge %d: %w: %q", i, validation.ErrUnknownTransform, name))
+			}
+		}
+	}
+
+	return errs
 }
 
 func (s *Server) isValidProcessType(processType string) bool {
@@ -401,25 +894,50 @@ func (s *Server) isValidProcessType(processType string) bool {
 	return exists
 }
 
+// hasProcessTypes reports whether any process type is registered
+func (s *Server) hasProcessTypes() bool {
+	s.processes.mu.RLock()
+	defer s.processes.mu.RUnlock()
+	return len(s.processes.processes) > 0
+}
+
 // ServeHTTP implements the http.Handler interface
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.Handler.ServeHTTP(w, r)
 }
 
-// RegisterProcessType registers a new process type
-func (s *Server) RegisterProcessType(name string, factory ProcessFactory) {
+// RegisterProcessType registers a new process type. It rejects an empty
+// name or nil factory with ErrInvalidProcessType.
+func (s *Server) RegisterProcessType(name string, factory ProcessFactory) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty name", ErrInvalidProcessType)
+	}
+	if factory == nil {
+		return fmt.Errorf("%w: nil factory for %q", ErrInvalidProcessType, name)
+	}
+
 	s.processes.mu.Lock()
 	defer s.processes.mu.Unlock()
 	s.processes.processes[name] = factory
+	return nil
+}
+
+// CreateProcess creates a process of a registered type
+func (s *Server) CreateProcess(processType string, config map[string]interface{}) (Process, error) {
+	return s.createProcessContext(context.Background(), processType, config)
 }
 
-// Make FlowManager implement web.FlowManager interface
-func (fm *FlowManager) List() []web.ManagedFlow {
+// List returns the flows of tenant for the web interface, implementing
+// web.FlowManager. Flows of other tenants are never included.
+func (fm *FlowManager) List(tenant string) []web.ManagedFlow {
 	fm.mu.RLock()
 	defer fm.mu.RUnlock()
 
 	flows := make([]web.ManagedFlow, 0, len(fm.flows))
-	for _, flow := range fm.flows {
+	for key, flow := range fm.flows {
+		if key != flowKey(tenant, flow.ID) {
+			continue
+		}
 		flows = append(flows, web.ManagedFlow{
 			ID:     flow.ID,
 			Status: string(flow.State),
@@ -430,11 +948,7 @@ func (fm *FlowManager) List() []web.ManagedFlow {
 
 // Start starts the server
 func (s *Server) Start(ctx context.Context) error {
-	addr := fmt.Sprintf(":%d", s.config.Port)
-	srv := &http.Server{
-		Addr:    addr,
-		Handler: s.Handler,
-	}
+	srv := s.httpServer()
 
 	// Handle graceful shutdown
 	go func() {
@@ -447,3 +961,23 @@ func (s *Server) Start(ctx context.Context) error {
 	log.Printf("Server starting on http://localhost:%d", s.config.Port)
 	return srv.ListenAndServe()
 }
+
+// httpServer builds the HTTP server Start listens with
+func (s *Server) httpServer() *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.config.Port),
+		Handler:           s.Handler,
+		ReadHeaderTimeout: durationOr(s.config.ReadHeaderTimeout, DefaultReadHeaderTimeout),
+		ReadTimeout:       durationOr(s.config.ReadTimeout, DefaultReadTimeout),
+		WriteTimeout:      durationOr(s.config.WriteTimeout, DefaultWriteTimeout),
+		IdleTimeout:       durationOr(s.config.IdleTimeout, DefaultIdleTimeout),
+	}
+}
+
+// durationOr returns d, or def when d is not set
+func durationOr(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
@@ -2,15 +2,22 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"html/template"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/elleshadow/noPromises/internal/server/web"
+	"github.com/elleshadow/noPromises/pkg/server/validation"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -63,10 +70,13 @@ func setupTestServer(t *testing.T) (*Server, string) {
 			Port:     8080,
 			DocsPath: webDir,
 		},
-		router:    mux.NewRouter(),
-		flows:     newFlowManager(),
-		processes: newProcessRegistry(),
-		webServer: webServer,
+		router:     mux.NewRouter(),
+		flows:      newFlowManager(),
+		processes:  newProcessRegistry(),
+		predicates: newPredicateRegistry(),
+		transforms: newTransformRegistry(),
+		templates:  newTemplateRegistry(),
+		webServer:  webServer,
 	}
 
 	s.setupRoutes()
@@ -79,10 +89,13 @@ func setupTestServer(t *testing.T) (*Server, string) {
 // setupTestServerWithoutWeb creates a server without web interface for process registry tests
 func setupTestServerWithoutWeb(_ *testing.T) *Server {
 	s := &Server{
-		config:    Config{Port: 8080},
-		router:    mux.NewRouter(),
-		flows:     newFlowManager(),
-		processes: newProcessRegistry(),
+		config:     Config{Port: 8080},
+		router:     mux.NewRouter(),
+		flows:      newFlowManager(),
+		processes:  newProcessRegistry(),
+		predicates: newPredicateRegistry(),
+		transforms: newTransformRegistry(),
+		templates:  newTemplateRegistry(),
 	}
 
 	s.Handler = s.router
@@ -143,7 +156,7 @@ func TestServerRoutes(t *testing.T) {
 			srv, _ := setupTestServer(t)
 
 			// Register test process type
-			srv.RegisterProcessType("test", &mockProcessFactory{})
+			require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
 
 			var req *http.Request
 			if tt.body != "" {
@@ -174,7 +187,7 @@ func TestFlowManagement(t *testing.T) {
 	srv, _ := setupTestServer(t)
 
 	// Register test process type
-	srv.RegisterProcessType("test", &mockProcessFactory{})
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
 
 	// Test flow creation
 	createReq := httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(`
@@ -197,13 +210,28 @@ func TestProcessRegistry(t *testing.T) {
 	srv := setupTestServerWithoutWeb(t)
 
 	// Register a test process
-	srv.RegisterProcessType("test", &mockProcessFactory{})
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
 
 	// Verify process type is registered
 	srv.processes.mu.RLock()
 	_, exists := srv.processes.processes["test"]
 	srv.processes.mu.RUnlock()
 	assert.True(t, exists)
+
+	t.Run("invalid registrations", func(t *testing.T) {
+		assert.ErrorIs(t, srv.RegisterProcessType("", &mockProcessFactory{}), ErrInvalidProcessType)
+		assert.ErrorIs(t, srv.RegisterProcessType("nil", nil), ErrInvalidProcessType)
+		assert.False(t, srv.isValidProcessType("nil"))
+	})
+
+	t.Run("create", func(t *testing.T) {
+		process, err := srv.CreateProcess("test", nil)
+		require.NoError(t, err)
+		assert.NotNil(t, process)
+
+		_, err = srv.CreateProcess("missing", nil)
+		assert.ErrorIs(t, err, ErrUnknownProcessType)
+	})
 }
 
 // Mock implementations for testing
@@ -217,3 +245,399 @@ type mockProcess struct{}
 
 func (p *mockProcess) Start(_ context.Context) error { return nil }
 func (p *mockProcess) Stop(_ context.Context) error  { return nil }
+
+func TestRequestBodyLimit(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	srv.config.MaxRequestBodySize = 128
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	t.Run("over limit", func(t *testing.T) {
+		body := `{"id":"big-flow","config":{"nodes":{"test":{"type":"test","pad":"` +
+			strings.Repeat("x", 256) + `"}}}}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), "exceeds 128 bytes")
+	})
+
+	t.Run("under limit", func(t *testing.T) {
+		body := `{"id":"small-flow","config":{"nodes":{"test":{"type":"test"}}}}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func TestFlowIDs(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	createFlow := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("generated", func(t *testing.T) {
+		w := createFlow(`{"config":{"nodes":{"test":{"type":"test"}}}}`)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var resp struct {
+			Data ManagedFlow `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		_, err := uuid.Parse(resp.Data.ID)
+		assert.NoError(t, err, "generated id should be a UUID")
+
+		srv.flows.mu.RLock()
+		_, exists := srv.flows.flows[resp.Data.ID]
+		srv.flows.mu.RUnlock()
+		assert.True(t, exists)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		w := createFlow(`{"id":"my-flow_1.v2","config":{"nodes":{"test":{"type":"test"}}}}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":"my-flow_1.v2"`)
+	})
+
+	for _, id := range []string{"../etc", "a/b", "with space", `tab\tid`, "-leading"} {
+		t.Run("rejected "+id, func(t *testing.T) {
+			w := createFlow(`{"id":"` + id + `","config":{"nodes":{"test":{"type":"test"}}}}`)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), "invalid flow id")
+		})
+	}
+}
+
+func TestFlowRequestID(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   string
+	}{
+		{"request id", "X-Request-ID", "req-123", "req-123"},
+		{"correlation id", "X-Correlation-ID", "corr-456", "corr-456"},
+		{"none", "", "", ""},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flowID := fmt.Sprintf("flow-%d", i)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(
+				`{"id":"`+flowID+`","config":{"nodes":{"test":{"type":"test"}}}}`))
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, req)
+			require.Equal(t, http.StatusCreated, w.Code)
+
+			srv.flows.mu.RLock()
+			flow := srv.flows.flows[flowID]
+			srv.flows.mu.RUnlock()
+			require.NotNil(t, flow)
+			assert.Equal(t, tt.want, flow.RequestID)
+
+			// The id is returned with the flow
+			w = httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/flows/"+flowID, nil))
+			if tt.want != "" {
+				assert.Contains(t, w.Body.String(), `"request_id":"`+tt.want+`"`)
+			} else {
+				assert.NotContains(t, w.Body.String(), "request_id")
+			}
+		})
+	}
+}
+
+func TestFlowValidationErrors(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	body := `{"id":"bad-flow","config":{
+		"nodes":{
+			"a":{"type":"test"},
+			"b":{"config":{}},
+			"c":{"type":"unknown"}
+		},
+		"edges":[{"from":"a","to":"missing"}]
+	}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp struct {
+		Error struct {
+			Message string   `json:"message"`
+			Errors  []string `json:"errors"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, []string{
+		`node "b": missing node type`,
+		`node "c": invalid node type: unknown`,
+		`edge 0: invalid edge: unknown node "missing"`,
+	}, resp.Error.Errors)
+	assert.Contains(t, resp.Error.Message, `node "b": missing node type`)
+
+	t.Run("single problem", func(t *testing.T) {
+		var config map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(`{"nodes":{"c":{"type":"unknown"}}}`), &config))
+		errs := srv.validateFlowConfigAll(config)
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs, validation.ErrInvalidNodeType)
+	})
+
+	// No flow is created when validation fails
+	srv.flows.mu.RLock()
+	defer srv.flows.mu.RUnlock()
+	assert.NotContains(t, srv.flows.flows, "bad-flow")
+}
+
+func TestUpdateFlow(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+	require.NoError(t, srv.RegisterProcessType("other", &mockProcessFactory{}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/v1/flows", `{"id":"edit-me","config":{"nodes":{"a":{"type":"test"}}}}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	t.Run("stopped flow", func(t *testing.T) {
+		w := do(http.MethodPut, "/api/v1/flows/edit-me", `{"config":{"nodes":{"b":{"type":"other"}}}}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		srv.flows.mu.RLock()
+		flow := srv.flows.flows["edit-me"]
+		config := flow.Config
+		state := flow.State
+		srv.flows.mu.RUnlock()
+		assert.Contains(t, config["nodes"], "b")
+		assert.NotContains(t, config["nodes"], "a")
+		assert.Equal(t, FlowStateCreated, state)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		w := do(http.MethodPut, "/api/v1/flows/edit-me", `{"config":{"nodes":{"c":{"type":"unknown"}}}}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		srv.flows.mu.RLock()
+		defer srv.flows.mu.RUnlock()
+		assert.Contains(t, srv.flows.flows["edit-me"].Config["nodes"], "b")
+	})
+
+	t.Run("unknown flow", func(t *testing.T) {
+		w := do(http.MethodPut, "/api/v1/flows/missing", `{"config":{"nodes":{"b":{"type":"other"}}}}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("running flow", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/v1/flows/edit-me/start", "").Code)
+		assert.Eventually(t, func() bool {
+			srv.flows.mu.RLock()
+			defer srv.flows.mu.RUnlock()
+			return srv.flows.flows["edit-me"].State == FlowStateRunning
+		}, time.Second, 10*time.Millisecond)
+
+		w := do(http.MethodPut, "/api/v1/flows/edit-me", `{"config":{"nodes":{"a":{"type":"test"}}}}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		srv.flows.mu.RLock()
+		defer srv.flows.mu.RUnlock()
+		assert.Contains(t, srv.flows.flows["edit-me"].Config["nodes"], "b")
+	})
+}
+
+func TestCloneFlow(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/v1/flows",
+		`{"id":"source","config":{"nodes":{"a":{"type":"test","config":{"n":1}}},"edges":[{"from":"a","to":"a"}]}}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/api/v1/flows/source/start", "").Code)
+	require.Eventually(t, func() bool {
+		srv.flows.mu.RLock()
+		defer srv.flows.mu.RUnlock()
+		return srv.flows.flows["source"].State == FlowStateRunning
+	}, time.Second, 10*time.Millisecond)
+
+	w = do(http.MethodPost, "/api/v1/flows/source/clone", "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var response struct {
+		Data ManagedFlow `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	cloneID := response.Data.ID
+	assert.NotEqual(t, "source", cloneID)
+	assert.Equal(t, FlowStateCreated, response.Data.State)
+
+	srv.flows.mu.Lock()
+	source := srv.flows.flows["source"]
+	clone := srv.flows.flows[cloneID]
+	require.NotNil(t, clone)
+	assert.Equal(t, source.Config, clone.Config)
+
+	// Changing the clone leaves the source alone
+	clone.Config["nodes"].(map[string]interface{})["a"].(map[string]interface{})["type"] = "changed"
+	clone.Config["edges"].([]interface{})[0].(map[string]interface{})["to"] = "b"
+	assert.Equal(t, "test", source.Config["nodes"].(map[string]interface{})["a"].(map[string]interface{})["type"])
+	assert.Equal(t, "a", source.Config["edges"].([]interface{})[0].(map[string]interface{})["to"])
+	assert.Equal(t, FlowStateRunning, source.State)
+	srv.flows.mu.Unlock()
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/api/v1/flows/missing/clone", "").Code)
+}
+
+// writeServerFiles creates the docs and web template files NewServer needs,
+// switching to a working directory that holds the templates
+func writeServerFiles(t *testing.T) string {
+	docsPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(docsPath, "api"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(docsPath, "README.md"), []byte("# Docs"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(docsPath, "api", "swagger.json"), []byte("{}"), 0644))
+
+	workDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(workDir, "web", "templates"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "web", "templates", "index.html"),
+		[]byte("<h1>{{.Title}}</h1>"), 0644))
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(workDir))
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	return docsPath
+}
+
+func TestNewServerProcessTypes(t *testing.T) {
+	docsPath := writeServerFiles(t)
+
+	srv, err := NewServer(Config{
+		DocsPath:     docsPath,
+		ProcessTypes: map[string]ProcessFactory{"test": &mockProcessFactory{}},
+	})
+	require.NoError(t, err)
+
+	// A flow can be created straight away
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows",
+		strings.NewReader(`{"id":"early","config":{"nodes":{"n":{"type":"test"}}}}`)))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	_, err = NewServer(Config{
+		DocsPath:     docsPath,
+		ProcessTypes: map[string]ProcessFactory{"nil": nil},
+	})
+	assert.ErrorIs(t, err, ErrInvalidProcessType)
+}
+
+func TestCreateFlowWithoutProcessTypes(t *testing.T) {
+	srv, _ := setupTestServer(t)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows",
+		strings.NewReader(`{"id":"early","config":{"nodes":{"n":{"type":"test"}}}}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), ErrNoProcessTypes.Error())
+}
+
+func TestHTTPServerTimeouts(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		srv := setupTestServerWithoutWeb(t)
+		httpSrv := srv.httpServer()
+		assert.Equal(t, DefaultReadHeaderTimeout, httpSrv.ReadHeaderTimeout)
+		assert.Equal(t, DefaultReadTimeout, httpSrv.ReadTimeout)
+		assert.Equal(t, DefaultWriteTimeout, httpSrv.WriteTimeout)
+		assert.Equal(t, DefaultIdleTimeout, httpSrv.IdleTimeout)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		srv := setupTestServerWithoutWeb(t)
+		srv.config.ReadHeaderTimeout = time.Second
+		srv.config.ReadTimeout = 2 * time.Second
+		srv.config.WriteTimeout = 3 * time.Second
+		srv.config.IdleTimeout = 4 * time.Second
+
+		httpSrv := srv.httpServer()
+		assert.Equal(t, time.Second, httpSrv.ReadHeaderTimeout)
+		assert.Equal(t, 2*time.Second, httpSrv.ReadTimeout)
+		assert.Equal(t, 3*time.Second, httpSrv.WriteTimeout)
+		assert.Equal(t, 4*time.Second, httpSrv.IdleTimeout)
+	})
+
+	t.Run("slow headers are cut off", func(t *testing.T) {
+		srv := setupTestServerWithoutWeb(t)
+		srv.config.ReadHeaderTimeout = 50 * time.Millisecond
+
+		ts := httptest.NewUnstartedServer(srv)
+		ts.Config = srv.httpServer()
+		ts.Start()
+		defer ts.Close()
+
+		conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("GET /api/v1/flows HTTP/1.1\r\nHost: localhost\r\n"))
+		require.NoError(t, err)
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+		_, err = io.ReadAll(conn)
+		require.NoError(t, err, "server should close the connection, not leave it to the client deadline")
+	})
+
+	t.Run("event streams outlive the write timeout", func(t *testing.T) {
+		srv, _ := setupTestServer(t)
+		srv.config.WriteTimeout = 100 * time.Millisecond
+		require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+		ts := httptest.NewUnstartedServer(srv)
+		ts.Config = srv.httpServer()
+		ts.Start()
+		defer ts.Close()
+
+		resp, err := http.Post(ts.URL+"/api/v1/flows", "application/json",
+			strings.NewReader(`{"id":"slow-events","config":{"nodes":{"test":{"type":"test"}}}}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		// Start the flow once the stream has been open past the write timeout
+		go func() {
+			time.Sleep(250 * time.Millisecond)
+			resp, err := http.Post(ts.URL+"/api/v1/flows/slow-events/start", "application/json", nil)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+
+		assert.Equal(t, []uint64{1, 2, 3}, readEvents(t, ts.URL+"/api/v1/flows/slow-events/events", "", 3))
+	})
+}
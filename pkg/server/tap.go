@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// ErrNoNetwork is returned when tapping a flow without an attached network
+var ErrNoNetwork = errors.New("flow has no attached network")
+
+var tapUpgrader = websocket.Upgrader{}
+
+// AttachNetwork associates the network running a flow with it, so its
+// edges can be tapped at /api/v1/flows/{id}/tap and its ports and recent
+// errors appear in /debug/flows. A nil network detaches. Flows owned by a
+// tenant are named "tenant/id". The limits of the flow's config are
+// applied to the network, and its process errors are written to the
+// flow's log.
+func (s *Server) AttachNetwork(id string, n *network.Network) error {
+	s.flows.mu.Lock()
+	defer s.flows.mu.Unlock()
+
+	flow, exists := s.flows.flows[id]
+	if !exists {
+		return fmt.Errorf("flow %s not found", id)
+	}
+	if n != nil {
+		limits, err := flowLimits(flow.Config)
+		if err != nil {
+			return err
+		}
+		n.SetLimits(limits)
+		if n != flow.network {
+			s.recordNetworkErrors(flow, n)
+		}
+	}
+	flow.network = n
+	return nil
+}
+
+// recordNetworkErrors keeps the errors of n's processes in the flow's log
+// and its recent errors; the caller must hold the flows lock
+func (s *Server) recordNetworkErrors(flow *ManagedFlow, n *network.Network) {
+	if flow.logs == nil {
+		flow.logs = newFlowLogs(DefaultFlowLogSize)
+	}
+	if flow.recentErrors == nil {
+		flow.recentErrors = newFlowLogs(DefaultRecentErrors)
+	}
+	logger := newFlowLogger(flow.ID, flow.logs)
+	recent := flow.recentErrors
+	n.OnError(func(process string, err error) {
+		logger.Printf("ERROR %s: %v", process, err)
+		fmt.Fprintf(recent, "%s: %v\n", process, err)
+	})
+}
+
+// handleTapFlow streams the packets sent on one edge of a flow's network
+// over WebSocket, one JSON encoded packet per message. The tap is removed
+// when the client disconnects. The sample and rate query parameters map
+// to network.TapOptions SampleEvery and MaxPerSecond.
+func (s *Server) handleTapFlow(w http.ResponseWriter, r *http.Request) {
+	flowID := mux.Vars(r)["id"]
+	query := r.URL.Query()
+
+	edge := query.Get("edge")
+	if edge == "" {
+		respondError(w, http.StatusBadRequest, fmt.Errorf("missing edge"))
+		return
+	}
+	var opts network.TapOptions
+	for name, dst := range map[string]*int{"sample": &opts.SampleEvery, "rate": &opts.MaxPerSecond} {
+		if param := query.Get(name); param != "" {
+			n, err := strconv.Atoi(param)
+			if err != nil || n < 0 {
+				respondError(w, http.StatusBadRequest, fmt.Errorf("invalid %s %q", name, param))
+				return
+			}
+			*dst = n
+		}
+	}
+
+	s.flows.mu.RLock()
+	flow, exists := s.flows.flows[requestFlowKey(r, flowID)]
+	var n *network.Network
+	if exists {
+		n = flow.network
+	}
+	s.flows.mu.RUnlock()
+
+	if !exists {
+		respondError(w, http.StatusNotFound, fmt.Errorf("flow %s not found", flowID))
+		return
+	}
+	if n == nil {
+		respondError(w, http.StatusConflict, fmt.Errorf("%w: %s", ErrNoNetwork, flowID))
+		return
+	}
+
+	tap, err := n.Tap(edge, opts)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer tap.Close()
+
+	conn, err := tapUpgrader.Upgrade(hijacker(w), r, nil)
+	if err != nil {
+		log.Printf("Error upgrading tap connection: %v", err)
+		return
+	}
+	defer conn.Close()
+	// The tap outlives the server's read and write timeouts by design
+	_ = conn.SetReadDeadline(time.Time{})
+	_ = conn.SetWriteDeadline(time.Time{})
+
+	// The client only ever closes; reading notices when it does
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-disconnected:
+			return
+		case packet, ok := <-tap.Packets():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(packet)
+			if err != nil {
+				log.Printf("Error encoding tapped packet on %s: %v", edge, err)
+				continue
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// hijacker unwraps middleware response writers down to one that can be
+// hijacked for a WebSocket upgrade
+func hijacker(w http.ResponseWriter) http.ResponseWriter {
+	for {
+		if _, ok := w.(http.Hijacker); ok {
+			return w
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return w
+		}
+		w = u.Unwrap()
+	}
+}
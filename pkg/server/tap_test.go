@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elleshadow/noPromises/pkg/core/ip"
+	"github.com/elleshadow/noPromises/pkg/core/network"
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/nodes"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTapFlow(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/api/v1/flows", "application/json",
+		strings.NewReader(`{"id":"tapped","config":{"nodes":{"src":{"type":"test"}}}}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	tapURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/v1/flows/tapped/tap?edge="
+	dialStatus := func(url string) int {
+		conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		if err == nil {
+			conn.Close()
+		}
+		return resp.StatusCode
+	}
+
+	// Nothing to tap until a network is attached
+	assert.Equal(t, http.StatusConflict, dialStatus(tapURL+"src.out"))
+
+	src := nodes.NewBaseNode[string, string]("src")
+	out := make(chan *ip.IP[string], 10)
+	require.NoError(t, ports.Connect(src.OutPort, out))
+	n := network.New()
+	n.AddProcess(src)
+	require.NoError(t, srv.AttachNetwork("tapped", n))
+
+	assert.Equal(t, http.StatusBadRequest, dialStatus(tapURL+"src.missing"))
+	assert.Equal(t, http.StatusBadRequest, dialStatus(tapURL+"src.out&rate=-1"))
+
+	conn, _, err := websocket.DefaultDialer.Dial(tapURL+"src.out", nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, word := range []string{"hello", "tap"} {
+		require.NoError(t, src.OutPort.Send(ctx, ip.New(word)))
+	}
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	for _, word := range []string{"hello", "tap"} {
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		packet, err := ip.Decode[string](data)
+		require.NoError(t, err)
+		assert.Equal(t, word, packet.Data())
+	}
+	// The tap observes without consuming
+	assert.Len(t, out, 2)
+
+	// Disconnecting removes the tap
+	assert.Equal(t, 1, n.ActiveTaps())
+	conn.Close()
+	assert.Eventually(t, func() bool {
+		return n.ActiveTaps() == 0
+	}, time.Second, 10*time.Millisecond)
+}
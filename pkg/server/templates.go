@@ -0,0 +1,151 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/gorilla/mux"
+)
+
+// FlowTemplate is a flow config with {{.param}} placeholders that flows
+// can be instantiated from
+type FlowTemplate struct {
+	ID         string `json:"id"`
+	Definition string `json:"definition"`
+
+	tmpl *template.Template
+}
+
+// TemplateRegistry holds the registered flow templates
+type TemplateRegistry struct {
+	templates map[string]*FlowTemplate
+	mu        sync.RWMutex
+}
+
+func newTemplateRegistry() *TemplateRegistry {
+	return &TemplateRegistry{
+		templates: make(map[string]*FlowTemplate),
+	}
+}
+
+// RegisterTemplate registers a flow template. The definition is the JSON
+// flow config text with text/template placeholders, such as
+// "path": "{{.path}}" or "workers": {{.workers}}. Registering an existing
+// id replaces it.
+func (s *Server) RegisterTemplate(id, definition string) error {
+	if err := validateFlowID(id); err != nil {
+		return fmt.Errorf("invalid template id: %w", err)
+	}
+
+	tmpl, err := template.New(id).Option("missingkey=error").Parse(definition)
+	if err != nil {
+		return fmt.Errorf("invalid template %s: %w", id, err)
+	}
+
+	s.templates.mu.Lock()
+	defer s.templates.mu.Unlock()
+	s.templates.templates[id] = &FlowTemplate{
+		ID:         id,
+		Definition: definition,
+		tmpl:       tmpl,
+	}
+	return nil
+}
+
+func (s *Server) lookupTemplate(id string) (*FlowTemplate, bool) {
+	s.templates.mu.RLock()
+	defer s.templates.mu.RUnlock()
+	t, exists := s.templates.templates[id]
+	return t, exists
+}
+
+// Instantiate substitutes params into the template and decodes the
+// resulting flow config. String parameters are JSON-escaped so they are
+// safe to place inside JSON strings.
+func (t *FlowTemplate) Instantiate(params map[string]interface{}) (map[string]interface{}, error) {
+	escaped := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		if str, ok := v.(string); ok {
+			quoted, err := json.Marshal(str)
+			if err != nil {
+				return nil, err
+			}
+			v = strings.TrimSuffix(strings.TrimPrefix(string(quoted), `"`), `"`)
+		}
+		escaped[k] = v
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, escaped); err != nil {
+		return nil, fmt.Errorf("template %s: %w", t.ID, err)
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &config); err != nil {
+		return nil, fmt.Errorf("template %s produced invalid config: %w", t.ID, err)
+	}
+	return config, nil
+}
+
+func (s *Server) handleCreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req FlowTemplate
+	if !s.decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	if req.Definition == "" {
+		respondError(w, http.StatusBadRequest, fmt.Errorf("missing template definition"))
+		return
+	}
+	if err := s.RegisterTemplate(req.ID, req.Definition); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t, _ := s.lookupTemplate(req.ID)
+	respondJSON(w, http.StatusCreated, t)
+}
+
+func (s *Server) handleGetTemplate(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	t, exists := s.lookupTemplate(id)
+	if !exists {
+		respondError(w, http.StatusNotFound, fmt.Errorf("template %s not found", id))
+		return
+	}
+	respondJSON(w, http.StatusOK, t)
+}
+
+// handleCreateFlowFromTemplate creates a flow from a template and
+// parameters, validating the result like any other new flow
+func (s *Server) handleCreateFlowFromTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID := mux.Vars(r)["templateID"]
+
+	var req struct {
+		ID     string                 `json:"id"`
+		Params map[string]interface{} `json:"params"`
+	}
+	if !s.decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	t, exists := s.lookupTemplate(templateID)
+	if !exists {
+		respondError(w, http.StatusNotFound, fmt.Errorf("template %s not found", templateID))
+		return
+	}
+
+	config, err := t.Instantiate(req.Params)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.createFlow(w, r, req.ID, config)
+}
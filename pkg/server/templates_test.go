@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlowTemplates(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("reader", &mockProcessFactory{}))
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	definition := `{"nodes":{"source":{"type":"{{.type}}","config":{"path":"{{.path}}","workers":{{.workers}}}}}}`
+	body, err := json.Marshal(map[string]string{"id": "ingest", "definition": definition})
+	require.NoError(t, err)
+	w := post("/api/v1/templates", string(body))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/templates/ingest", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `{{.path}}`)
+
+	instances := []struct {
+		id      string
+		path    string
+		workers float64
+	}{
+		{"ingest-logs", "/var/log/app.log", 2},
+		{"ingest-audit", `/data/"audit".log`, 4},
+	}
+	for _, inst := range instances {
+		t.Run(inst.id, func(t *testing.T) {
+			body, err := json.Marshal(map[string]interface{}{
+				"id": inst.id,
+				"params": map[string]interface{}{
+					"type": "reader", "path": inst.path, "workers": inst.workers,
+				},
+			})
+			require.NoError(t, err)
+
+			w := post("/api/v1/flows/from-template/ingest", string(body))
+			require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+			srv.flows.mu.RLock()
+			flow := srv.flows.flows[inst.id]
+			srv.flows.mu.RUnlock()
+			require.NotNil(t, flow)
+
+			nodes := flow.Config["nodes"].(map[string]interface{})
+			source := nodes["source"].(map[string]interface{})
+			config := source["config"].(map[string]interface{})
+			assert.Equal(t, inst.path, config["path"])
+			assert.Equal(t, inst.workers, config["workers"])
+		})
+	}
+
+	t.Run("missing parameter", func(t *testing.T) {
+		w := post("/api/v1/flows/from-template/ingest", `{"params":{"type":"reader","path":"x"}}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("invalid result", func(t *testing.T) {
+		w := post("/api/v1/flows/from-template/ingest",
+			`{"params":{"type":"unknown","path":"x","workers":1}}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid node type")
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		w := post("/api/v1/flows/from-template/missing", `{"params":{}}`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		w := post("/api/v1/templates", `{"id":"broken","definition":"{\"nodes\":\"{{.unclosed\"}"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elleshadow/noPromises/internal/server/web"
+	"github.com/elleshadow/noPromises/pkg/server/api/middleware"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	srv, _ := setupTestServer(t)
+	srv.config.Middleware = []middleware.Middleware{
+		middleware.TenantMiddleware(""),
+	}
+	// The web interface lists the server's own flows
+	srv.webServer = web.NewServer(
+		web.WithTemplates(template.Must(template.New("index.html").Parse(
+			`{{range .Flows}}<li>{{.ID}}</li>{{end}}`))),
+		web.WithStatic(http.NotFoundHandler()),
+		web.WithFlowManager(srv.flows),
+	)
+	srv.router = mux.NewRouter()
+	srv.setupRoutes()
+	srv.setupMiddleware()
+	srv.Handler = srv.router
+	require.NoError(t, srv.RegisterProcessType("test", &mockProcessFactory{}))
+
+	do := func(tenant, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if tenant != "" {
+			req.Header.Set(middleware.DefaultTenantHeader, tenant)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	listIDs := func(tenant string) []string {
+		w := do(tenant, http.MethodGet, "/api/v1/flows", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp struct {
+			Data []ManagedFlow `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		var ids []string
+		for _, flow := range resp.Data {
+			ids = append(ids, flow.ID)
+		}
+		return ids
+	}
+
+	flow := `{"id":"shared","config":{"nodes":{"test":{"type":"test"}}}}`
+	require.Equal(t, http.StatusCreated, do("a", http.MethodPost, "/api/v1/flows", flow).Code)
+	// The same id is free in another tenant
+	require.Equal(t, http.StatusCreated, do("b", http.MethodPost, "/api/v1/flows", flow).Code)
+	require.Equal(t, http.StatusCreated, do("b", http.MethodPost, "/api/v1/flows",
+		`{"id":"b-only","config":{"nodes":{"test":{"type":"test"}}}}`).Code)
+
+	t.Run("get is scoped", func(t *testing.T) {
+		w := do("a", http.MethodGet, "/api/v1/flows/b-only", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = do("b", http.MethodGet, "/api/v1/flows/b-only", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"tenant":"b"`)
+
+		w = do("", http.MethodGet, "/api/v1/flows/shared", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("list is scoped", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"shared"}, listIDs("a"))
+		assert.ElementsMatch(t, []string{"shared", "b-only"}, listIDs("b"))
+		assert.Empty(t, listIDs(""))
+	})
+
+	t.Run("web routes are scoped", func(t *testing.T) {
+		home := do("a", http.MethodGet, "/", "")
+		require.Equal(t, http.StatusOK, home.Code)
+		assert.Contains(t, home.Body.String(), "<li>shared</li>")
+		assert.NotContains(t, home.Body.String(), "b-only")
+
+		assert.Equal(t, http.StatusNotFound, do("a", http.MethodGet, "/api/v1/flows/b-only/viz", "").Code)
+		assert.Equal(t, http.StatusOK, do("b", http.MethodGet, "/api/v1/flows/b-only/viz", "").Code)
+		assert.NotContains(t, do("", http.MethodGet, "/", "").Body.String(), "<li>")
+	})
+
+	t.Run("delete is scoped", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do("a", http.MethodDelete, "/api/v1/flows/b-only", "").Code)
+		assert.Equal(t, http.StatusNoContent, do("a", http.MethodDelete, "/api/v1/flows/shared", "").Code)
+		assert.ElementsMatch(t, []string{"shared", "b-only"}, listIDs("b"))
+	})
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/elleshadow/noPromises/pkg/server/validation"
+)
+
+// DefaultEdgeBufferSize is the buffer of an edge that does not set one
+const DefaultEdgeBufferSize = 1
+
+// FlowTopology is how the server interpreted a flow configuration
+type FlowTopology struct {
+	Nodes    []TopologyNode `json:"nodes"`
+	Edges    []TopologyEdge `json:"edges"`
+	Warnings []string       `json:"warnings"`
+}
+
+// TopologyNode is a node with its resolved ports
+type TopologyNode struct {
+	ID      string         `json:"id"`
+	Type    string         `json:"type"`
+	Inputs  []TopologyPort `json:"inputs"`
+	Outputs []TopologyPort `json:"outputs"`
+}
+
+// TopologyPort is a described port and whether any edge uses it
+type TopologyPort struct {
+	PortDescriptor
+	Connected bool `json:"connected"`
+}
+
+// TopologyEdge is an edge with its ports and buffer resolved. Ports are
+// empty when the node's process type does not describe them.
+type TopologyEdge struct {
+	From       string `json:"from"`
+	FromPort   string `json:"from_port,omitempty"`
+	To         string `json:"to"`
+	Port       string `json:"port,omitempty"`
+	When       string `json:"when,omitempty"`
+	BufferSize int    `json:"buffer_size"`
+}
+
+// createFlowResponse is a created flow plus its resolved topology
+type createFlowResponse struct {
+	*ManagedFlow
+	Topology FlowTopology `json:"topology"`
+}
+
+// edgeBufferSize returns the buffer an edge asks for with its buffer key
+func edgeBufferSize(edge map[string]interface{}) (int, error) {
+	raw, exists := edge["buffer"]
+	if !exists {
+		return DefaultEdgeBufferSize, nil
+	}
+	size, ok := raw.(float64)
+	if !ok || size < 0 || size != float64(int(size)) {
+		return 0, fmt.Errorf("%w: buffer must be a non-negative integer", validation.ErrInvalidEdge)
+	}
+	return int(size), nil
+}
+
+// resolveTopology describes a validated flow configuration, warning about
+// ports no edge connects
+func (s *Server) resolveTopology(config map[string]interface{}) FlowTopology {
+	topology := FlowTopology{
+		Nodes:    []TopologyNode{},
+		Edges:    []TopologyEdge{},
+		Warnings: []string{},
+	}
+	nodes, _ := config["nodes"].(map[string]interface{})
+
+	// Ports in use, keyed by node id and port name
+	connected := map[string]bool{}
+	edges, _ := config["edges"].([]interface{})
+	for _, edge := range edges {
+		edgeConfig, ok := edge.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resolved := TopologyEdge{}
+		resolved.From, _ = edgeConfig["from"].(string)
+		resolved.To, _ = edgeConfig["to"].(string)
+		resolved.When, _ = edgeConfig["when"].(string)
+		resolved.BufferSize, _ = edgeBufferSize(edgeConfig)
+		if out, _ := s.edgePort(resolved.From, nodes, edgeConfig["from_port"], true); out != nil {
+			resolved.FromPort = out.Name
+			connected[resolved.From+"."+out.Name] = true
+		}
+		if in, _ := s.edgePort(resolved.To, nodes, edgeConfig["port"], false); in != nil {
+			resolved.Port = in.Name
+			connected[resolved.To+"."+in.Name] = true
+		}
+		topology.Edges = append(topology.Edges, resolved)
+	}
+
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		nodeConfig, _ := nodes[id].(map[string]interface{})
+		nodeType, _ := nodeConfig["type"].(string)
+		desc, _ := s.describeProcessType(nodeType)
+
+		node := TopologyNode{ID: id, Type: nodeType}
+		resolve := func(kind string, descs []PortDescriptor) []TopologyPort {
+			ports := make([]TopologyPort, 0, len(descs))
+			for _, desc := range descs {
+				port := TopologyPort{PortDescriptor: desc, Connected: connected[id+"."+desc.Name]}
+				if !port.Connected {
+					requirement := "optional"
+					if desc.Required {
+						requirement = "required"
+					}
+					topology.Warnings = append(topology.Warnings,
+						fmt.Sprintf("node %q: %s %s %q is not connected", id, requirement, kind, desc.Name))
+				}
+				ports = append(ports, port)
+			}
+			return ports
+		}
+		node.Inputs = resolve("input", desc.Inputs)
+		node.Outputs = resolve("output", desc.Outputs)
+		topology.Nodes = append(topology.Nodes, node)
+	}
+
+	return topology
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFlowTopology(t *testing.T) {
+	srv := setupTypedServer(t)
+
+	body := `{"id":"resolved","config":{
+		"nodes":{"words":{"type":"Words"},"print":{"type":"Print"},"sum":{"type":"Sum"},"plain":{"type":"plain"}},
+		"edges":[
+			{"from":"words","to":"print","buffer":8},
+			{"from":"sum","to":"plain"}
+		]
+	}}`
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body)))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var resp struct {
+		Data struct {
+			ID       string       `json:"id"`
+			State    FlowState    `json:"state"`
+			Topology FlowTopology `json:"topology"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "resolved", resp.Data.ID)
+	assert.Equal(t, FlowStateCreated, resp.Data.State)
+
+	topology := resp.Data.Topology
+	require.Len(t, topology.Nodes, 4)
+	assert.Equal(t, "plain", topology.Nodes[0].ID)
+	assert.Empty(t, topology.Nodes[0].Inputs)
+
+	words := topology.Nodes[3]
+	assert.Equal(t, "words", words.ID)
+	assert.Equal(t, "Words", words.Type)
+	require.Len(t, words.Outputs, 1)
+	assert.Equal(t, "out", words.Outputs[0].Name)
+	assert.Equal(t, "string", words.Outputs[0].Type)
+	assert.True(t, words.Outputs[0].Connected)
+
+	assert.Equal(t, []TopologyEdge{
+		{From: "words", FromPort: "out", To: "print", Port: "in", BufferSize: 8},
+		{From: "sum", FromPort: "out", To: "plain", BufferSize: DefaultEdgeBufferSize},
+	}, topology.Edges)
+
+	assert.Equal(t, []string{
+		`node "sum": required input "in" is not connected`,
+		`node "sum": optional input "extra" is not connected`,
+	}, topology.Warnings)
+}
+
+func TestCreateFlowInvalidBuffer(t *testing.T) {
+	srv := setupTypedServer(t)
+
+	body := `{"id":"bad-buffer","config":{"nodes":{"words":{"type":"Words"},"print":{"type":"Print"}},` +
+		`"edges":[{"from":"words","to":"print","buffer":-1}]}}`
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "edge 0: invalid edge: buffer must be a non-negative integer")
+}
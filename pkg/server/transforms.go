@@ -0,0 +1,36 @@
+package server
+
+import "sync"
+
+// TransformFunc maps a packet travelling along an edge
+type TransformFunc func(data interface{}) interface{}
+
+// TransformRegistry holds the named transforms edges may reference with
+// "transform"
+type TransformRegistry struct {
+	transforms map[string]TransformFunc
+	mu         sync.RWMutex
+}
+
+func newTransformRegistry() *TransformRegistry {
+	return &TransformRegistry{
+		transforms: make(map[string]TransformFunc),
+	}
+}
+
+// RegisterTransform registers a named transform for inline edge mapping.
+// As with guards, the server only validates the name; the embedder
+// building a flow's network maps the edge with a transform.Mapper over
+// the function, as runflow does for its built-in transforms.
+func (s *Server) RegisterTransform(name string, fn TransformFunc) {
+	s.transforms.mu.Lock()
+	defer s.transforms.mu.Unlock()
+	s.transforms.transforms[name] = fn
+}
+
+func (s *Server) lookupTransform(name string) (TransformFunc, bool) {
+	s.transforms.mu.RLock()
+	defer s.transforms.mu.RUnlock()
+	fn, exists := s.transforms.transforms[name]
+	return fn, exists
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEdgeTransforms(t *testing.T) {
+	srv := setupTypedServer(t)
+	srv.RegisterTransform("toUpper", func(data interface{}) interface{} {
+		s, _ := data.(string)
+		return strings.ToUpper(s)
+	})
+
+	createFlow := func(id, from, to, fn string) *httptest.ResponseRecorder {
+		body := `{"id":"` + id + `","config":{
+			"nodes":{"words":{"type":"Words"},"sum":{"type":"Sum"},"print":{"type":"Print"}},
+			"edges":[{"from":"` + from + `","to":"` + to + `","transform":"` + fn + `"}]}}`
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("registered transform", func(t *testing.T) {
+		w := createFlow("upper", "words", "print", "toUpper")
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("transformed edges skip type checks", func(t *testing.T) {
+		w := createFlow("retyped", "words", "sum", "toUpper")
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("unknown transform", func(t *testing.T) {
+		w := createFlow("unknown", "words", "print", "toLower")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `edge 0: unknown transform: \"toLower\"`)
+	})
+}
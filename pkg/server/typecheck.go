@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/elleshadow/noPromises/pkg/server/validation"
+)
+
+// anyType is the element type string of ports that accept any value
+const anyType = "interface {}"
+
+// checkEdgeTypes checks that an edge joins an output and an input of the
+// same element type. The edge's from_port names the output of its source
+// and port the input of its target; either defaults to the node's first
+// port. Edges touching process types without descriptors are not checked.
+func (s *Server) checkEdgeTypes(i int, edge map[string]interface{}, nodes map[string]interface{}) []error {
+	from, _ := edge["from"].(string)
+	to, _ := edge["to"].(string)
+
+	out, outErr := s.edgePort(from, nodes, edge["from_port"], true)
+	in, inErr := s.edgePort(to, nodes, edge["port"], false)
+
+	var errs []error
+	for _, err := range []error{outErr, inErr} {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("edge %d: %w", i, err))
+		}
+	}
+	if len(errs) > 0 || out == nil || in == nil {
+		return errs
+	}
+
+	if out.Type != in.Type && out.Type != anyType && in.Type != anyType {
+		errs = append(errs, fmt.Errorf("edge %d: %w: %s.%s (%s) -> %s.%s (%s)",
+			i, validation.ErrTypeMismatch, from, out.Name, out.Type, to, in.Name, in.Type))
+	}
+	return errs
+}
+
+// edgePort finds the described port an edge end refers to. It returns nil
+// without an error when the node's process type declares no such ports.
+func (s *Server) edgePort(id string, nodes map[string]interface{}, name interface{}, output bool) (*PortDescriptor, error) {
+	nodeConfig, _ := nodes[id].(map[string]interface{})
+	nodeType, _ := nodeConfig["type"].(string)
+	desc, exists := s.describeProcessType(nodeType)
+	if !exists {
+		return nil, nil
+	}
+
+	candidates := desc.Inputs
+	if output {
+		candidates = desc.Outputs
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	portName, _ := name.(string)
+	if portName == "" {
+		return &candidates[0], nil
+	}
+	for i := range candidates {
+		if candidates[i].Name == portName {
+			return &candidates[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s.%s", validation.ErrUnknownPort, id, portName)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elleshadow/noPromises/pkg/core/ports"
+	"github.com/elleshadow/noPromises/pkg/server/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// typedProcessFactory describes processes with fixed port types
+type typedProcessFactory struct {
+	mockProcessFactory
+	inputs  []PortDescriptor
+	outputs []PortDescriptor
+}
+
+func (f *typedProcessFactory) Describe() ProcessDescriptor {
+	return ProcessDescriptor{Inputs: f.inputs, Outputs: f.outputs}
+}
+
+func setupTypedServer(t *testing.T) *Server {
+	srv, _ := setupTestServer(t)
+	require.NoError(t, srv.RegisterProcessType("Words", &typedProcessFactory{
+		outputs: []PortDescriptor{DescribePort(ports.NewOutput[string]("out", "", true))},
+	}))
+	require.NoError(t, srv.RegisterProcessType("Sum", &typedProcessFactory{
+		inputs: []PortDescriptor{
+			DescribePort(ports.NewInput[int]("in", "", true)),
+			DescribePort(ports.NewInput[any]("extra", "", false)),
+		},
+		outputs: []PortDescriptor{DescribePort(ports.NewOutput[int]("out", "", true))},
+	}))
+	require.NoError(t, srv.RegisterProcessType("Print", &typedProcessFactory{
+		inputs: []PortDescriptor{DescribePort(ports.NewInput[string]("in", "", true))},
+	}))
+	require.NoError(t, srv.RegisterProcessType("plain", &mockProcessFactory{}))
+	return srv
+}
+
+func TestFlowTypeCheck(t *testing.T) {
+	srv := setupTypedServer(t)
+
+	create := func(id, edges string) *httptest.ResponseRecorder {
+		body := `{"id":"` + id + `","config":{"nodes":{` +
+			`"words":{"type":"Words"},"sum":{"type":"Sum"},"print":{"type":"Print"},"plain":{"type":"plain"}` +
+			`},"edges":` + edges + `}}`
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("mismatches are reported together", func(t *testing.T) {
+		w := create("mismatched", `[
+			{"from":"words","to":"sum"},
+			{"from":"sum","to":"print","port":"in"},
+			{"from":"words","to":"print"}
+		]`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp struct {
+			Error struct {
+				Errors []string `json:"errors"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, []string{
+			"edge 0: type mismatch: words.out (string) -> sum.in (int)",
+			"edge 1: type mismatch: sum.out (int) -> print.in (string)",
+		}, resp.Error.Errors)
+	})
+
+	t.Run("unknown port", func(t *testing.T) {
+		w := create("unknown-port", `[{"from":"words","from_port":"err","to":"print"}]`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "edge 0: unknown port: words.err")
+	})
+
+	t.Run("compatible wiring", func(t *testing.T) {
+		w := create("wired", `[
+			{"from":"words","to":"print"},
+			{"from":"words","to":"sum","port":"extra"},
+			{"from":"plain","to":"sum"},
+			{"from":"sum","to":"plain"}
+		]`)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+}
+
+func TestValidateFlowConfigTypes(t *testing.T) {
+	srv := setupTypedServer(t)
+
+	errs := srv.validateFlowConfigAll(map[string]interface{}{
+		"nodes": map[string]interface{}{
+			"words": map[string]interface{}{"type": "Words"},
+			"sum":   map[string]interface{}{"type": "Sum"},
+		},
+		"edges": []interface{}{
+			map[string]interface{}{"from": "words", "to": "sum"},
+		},
+	})
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs, validation.ErrTypeMismatch))
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// flowValidation is the result of validating a flow configuration that
+// passed every check
+type flowValidation struct {
+	Valid    bool         `json:"valid"`
+	Topology FlowTopology `json:"topology"`
+}
+
+// handleValidateFlow runs the checks of flow creation on a config without
+// creating anything. Problems are reported together as on creation; a
+// valid config is answered with its resolved topology, whose warnings
+// also list the cycles among its nodes.
+func (s *Server) handleValidateFlow(w http.ResponseWriter, r *http.Request) {
+	var flowConfig struct {
+		ID     string                 `json:"id"`
+		Config map[string]interface{} `json:"config"`
+	}
+	if !s.decodeJSONBody(w, r, &flowConfig) {
+		return
+	}
+
+	if flowConfig.ID != "" {
+		if err := validateFlowID(flowConfig.ID); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if !s.hasProcessTypes() {
+		respondError(w, http.StatusServiceUnavailable, ErrNoProcessTypes)
+		return
+	}
+	if errs := s.validateFlowConfigAll(flowConfig.Config); len(errs) > 0 {
+		respondValidationErrors(w, errs)
+		return
+	}
+
+	topology := s.resolveTopology(flowConfig.Config)
+	for _, cycle := range findCycles(flowConfig.Config) {
+		quoted := make([]string, len(cycle))
+		for i, id := range cycle {
+			quoted[i] = fmt.Sprintf("%q", id)
+		}
+		topology.Warnings = append(topology.Warnings,
+			"cycle through nodes "+strings.Join(quoted, ", "))
+	}
+	respondJSON(w, http.StatusOK, flowValidation{Valid: true, Topology: topology})
+}
+
+// findCycles returns the node ids of each cycle in a validated flow config:
+// every strongly connected group of nodes, and every node with an edge to
+// itself. Ids within a cycle and the cycles themselves are sorted.
+func findCycles(config map[string]interface{}) [][]string {
+	successors := map[string][]string{}
+	selfLoops := map[string]bool{}
+	edges, _ := config["edges"].([]interface{})
+	for _, edge := range edges {
+		edgeConfig, _ := edge.(map[string]interface{})
+		from, _ := edgeConfig["from"].(string)
+		to, _ := edgeConfig["to"].(string)
+		successors[from] = append(successors[from], to)
+		if from == to {
+			selfLoops[from] = true
+		}
+	}
+
+	nodes, _ := config["nodes"].(map[string]interface{})
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Tarjan's strongly connected components
+	index := map[string]int{}
+	lowlink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var cycles [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = len(index)
+		lowlink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range successors[id] {
+			if _, seen := index[next]; !seen {
+				visit(next)
+				lowlink[id] = min(lowlink[id], lowlink[next])
+			} else if onStack[next] {
+				lowlink[id] = min(lowlink[id], index[next])
+			}
+		}
+
+		if lowlink[id] != index[id] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoops[id] {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, id := range ids {
+		if _, seen := index[id]; !seen {
+			visit(id)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFlow(t *testing.T) {
+	srv := setupTypedServer(t)
+
+	validate := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/flows/validate", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("valid config", func(t *testing.T) {
+		w := validate(`{"id":"checked","config":{
+			"nodes":{"words":{"type":"Words"},"print":{"type":"Print"},"a":{"type":"plain"},"b":{"type":"plain"}},
+			"edges":[{"from":"words","to":"print"},{"from":"a","to":"b"},{"from":"b","to":"a"}]}}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data flowValidation `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.True(t, resp.Data.Valid)
+		assert.Len(t, resp.Data.Topology.Nodes, 4)
+		assert.Equal(t, "out", resp.Data.Topology.Edges[0].FromPort)
+		assert.Contains(t, resp.Data.Topology.Warnings, `cycle through nodes "a", "b"`)
+
+		srv.flows.mu.RLock()
+		assert.Empty(t, srv.flows.flows, "validation must not create a flow")
+		srv.flows.mu.RUnlock()
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		w := validate(`{"config":{
+			"nodes":{"words":{"type":"Words"},"sum":{"type":"Sum"},"bad":{"type":"missing"}},
+			"edges":[{"from":"words","to":"sum"},{"from":"words","to":"nowhere"}],
+			"limits":{"resources":{"api":0}}}}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp struct {
+			Error struct {
+				Errors []string `json:"errors"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Len(t, resp.Error.Errors, 4)
+		assert.Contains(t, resp.Error.Errors, `invalid limits: resource "api" must have a positive integer limit`)
+		assert.Contains(t, resp.Error.Errors, "edge 0: type mismatch: words.out (string) -> sum.in (int)")
+		assert.Contains(t, resp.Error.Errors, `edge 1: invalid edge: unknown node "nowhere"`)
+
+		srv.flows.mu.RLock()
+		assert.Empty(t, srv.flows.flows)
+		srv.flows.mu.RUnlock()
+	})
+}
+
+func TestFindCycles(t *testing.T) {
+	config := map[string]interface{}{
+		"nodes": map[string]interface{}{"a": nil, "b": nil, "c": nil, "d": nil, "e": nil},
+		"edges": []interface{}{
+			map[string]interface{}{"from": "a", "to": "b"},
+			map[string]interface{}{"from": "b", "to": "c"},
+			map[string]interface{}{"from": "c", "to": "a"},
+			map[string]interface{}{"from": "c", "to": "d"},
+			map[string]interface{}{"from": "e", "to": "e"},
+		},
+	}
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"e"}}, findCycles(config))
+	assert.Empty(t, findCycles(map[string]interface{}{}))
+}
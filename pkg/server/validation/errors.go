@@ -1,6 +1,9 @@
 package validation
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
 	ErrEmptyConfig       = errors.New("empty configuration")
@@ -9,4 +12,34 @@ var (
 	ErrInvalidNodeConfig = errors.New("invalid node configuration")
 	ErrMissingNodeType   = errors.New("missing node type")
 	ErrInvalidNodeType   = errors.New("invalid node type")
+	ErrInvalidEdges      = errors.New("invalid edges configuration")
+	ErrInvalidEdge       = errors.New("invalid edge")
+	ErrUnknownPredicate  = errors.New("unknown predicate")
+	ErrUnknownTransform  = errors.New("unknown transform")
+	ErrInvalidLabels     = errors.New("invalid labels")
+	ErrInvalidDefaults   = errors.New("invalid defaults")
+	ErrUnknownPort       = errors.New("unknown port")
+	ErrTypeMismatch      = errors.New("type mismatch")
 )
+
+// Errors collects every problem found while validating a configuration
+type Errors []error
+
+// Error joins the messages of all collected errors
+func (e Errors) Error() string {
+	return strings.Join(e.Messages(), "; ")
+}
+
+// Unwrap returns the collected errors so errors.Is and errors.As see each one
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Messages returns the message of each collected error
+func (e Errors) Messages() []string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return msgs
+}
@@ -1,6 +1,52 @@
 package validation
 
+import "sort"
+
 // Validator defines the interface for flow configuration validation
 type Validator interface {
 	ValidateFlowConfig(config map[string]interface{}) error
 }
+
+// ValidateFlowConfig returns the first problem in a flow configuration,
+// checking its id and then each node, in id order, for a type accepted by
+// isValidType. It is the fast check for internal use; clients are shown
+// every problem at once.
+func ValidateFlowConfig(config map[string]interface{}, isValidType func(string) bool) error {
+	if config == nil {
+		return ErrEmptyConfig
+	}
+
+	id, ok := config["id"].(string)
+	if !ok || id == "" {
+		return ErrMissingID
+	}
+
+	nodes, ok := config["nodes"].(map[string]interface{})
+	if !ok {
+		return ErrInvalidNodes
+	}
+
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		nodeConfig, ok := nodes[id].(map[string]interface{})
+		if !ok {
+			return ErrInvalidNodeConfig
+		}
+
+		nodeType, ok := nodeConfig["type"].(string)
+		if !ok || nodeType == "" {
+			return ErrMissingNodeType
+		}
+
+		if !isValidType(nodeType) {
+			return ErrInvalidNodeType
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -21,43 +22,9 @@ func newTestValidator() *testValidator {
 }
 
 func (v *testValidator) ValidateFlowConfig(config map[string]interface{}) error {
-	// Test validation implementation
-	return validateFlowConfig(config, v.allowedTypes)
-}
-
-func validateFlowConfig(config map[string]interface{}, allowedTypes map[string]bool) error {
-	// Basic validation logic for testing
-	if config == nil {
-		return ErrEmptyConfig
-	}
-
-	id, ok := config["id"].(string)
-	if !ok || id == "" {
-		return ErrMissingID
-	}
-
-	nodes, ok := config["nodes"].(map[string]interface{})
-	if !ok {
-		return ErrInvalidNodes
-	}
-
-	for _, node := range nodes {
-		nodeConfig, ok := node.(map[string]interface{})
-		if !ok {
-			return ErrInvalidNodeConfig
-		}
-
-		nodeType, ok := nodeConfig["type"].(string)
-		if !ok || nodeType == "" {
-			return ErrMissingNodeType
-		}
-
-		if !allowedTypes[nodeType] {
-			return ErrInvalidNodeType
-		}
-	}
-
-	return nil
+	return ValidateFlowConfig(config, func(nodeType string) bool {
+		return v.allowedTypes[nodeType]
+	})
 }
 
 func TestValidateFlowConfig(t *testing.T) {
@@ -150,3 +117,16 @@ func TestValidateFlowConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestErrors(t *testing.T) {
+	errs := Errors{
+		ErrMissingID,
+		fmt.Errorf("node %q: %w", "reader", ErrMissingNodeType),
+	}
+
+	assert.Equal(t, `missing flow ID; node "reader": missing node type`, errs.Error())
+	assert.Equal(t, []string{"missing flow ID", `node "reader": missing node type`}, errs.Messages())
+	assert.ErrorIs(t, errs, ErrMissingID)
+	assert.ErrorIs(t, errs, ErrMissingNodeType)
+	assert.NotErrorIs(t, errs, ErrInvalidEdge)
+}